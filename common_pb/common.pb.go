@@ -15,6 +15,10 @@ It has these top-level messages:
 	EqualsOneOfTwo
 	VerifiableShuffle
 	DiscreteLogEquality
+	Accusation
+	Echo
+	TranscriptRecord
+	Handshake
 */
 package common_pb
 
@@ -37,6 +41,9 @@ type OuterStruct struct {
 	Clientid int32  `protobuf:"varint,1,opt,name=clientid" json:"clientid,omitempty"`
 	Stepid   int32  `protobuf:"varint,2,opt,name=stepid" json:"stepid,omitempty"`
 	Data     []byte `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	// Signature of the sender over all other fields
+	Signature []byte `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
+	Auctionid string `protobuf:"bytes,5,opt,name=auctionid" json:"auctionid,omitempty"`
 }
 
 func (m *OuterStruct) Reset()                    { *m = OuterStruct{} }
@@ -89,20 +96,11 @@ func (m *EqualsOneOfTwo) String() string            { return proto.CompactTextSt
 func (*EqualsOneOfTwo) ProtoMessage()               {}
 func (*EqualsOneOfTwo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
 
+// A proof that a list of ciphertexts is a shuffle of another. Every round
+// holds an intermediate shuffle, and the permutation and nonces of the half
+// of the shuffle that its challenge bit opens.
 type VerifiableShuffle struct {
-	C         [][]byte `protobuf:"bytes,1,rep,name=c,proto3" json:"c,omitempty"`
-	Cd        []byte   `protobuf:"bytes,2,opt,name=cd,proto3" json:"cd,omitempty"`
-	CCapitalD []byte   `protobuf:"bytes,3,opt,name=cCapitalD,proto3" json:"cCapitalD,omitempty"`
-	ERalpha   []byte   `protobuf:"bytes,4,opt,name=ERalpha,proto3" json:"ERalpha,omitempty"`
-	ERbeta    []byte   `protobuf:"bytes,5,opt,name=ERbeta,proto3" json:"ERbeta,omitempty"`
-	F         [][]byte `protobuf:"bytes,6,rep,name=f,proto3" json:"f,omitempty"`
-	Fd        []byte   `protobuf:"bytes,7,opt,name=fd,proto3" json:"fd,omitempty"`
-	Yd        []byte   `protobuf:"bytes,8,opt,name=yd,proto3" json:"yd,omitempty"`
-	Zd        []byte   `protobuf:"bytes,9,opt,name=zd,proto3" json:"zd,omitempty"`
-	BigF      [][]byte `protobuf:"bytes,10,rep,name=bigF,proto3" json:"bigF,omitempty"`
-	YCapitalD []byte   `protobuf:"bytes,11,opt,name=yCapitalD,proto3" json:"yCapitalD,omitempty"`
-	ZCapitalD []byte   `protobuf:"bytes,12,opt,name=zCapitalD,proto3" json:"zCapitalD,omitempty"`
-	CapitalZ  []byte   `protobuf:"bytes,13,opt,name=capitalZ,proto3" json:"capitalZ,omitempty"`
+	Rounds []*VerifiableShuffle_Round `protobuf:"bytes,14,rep,name=rounds" json:"rounds,omitempty"`
 }
 
 func (m *VerifiableShuffle) Reset()                    { *m = VerifiableShuffle{} }
@@ -110,6 +108,25 @@ func (m *VerifiableShuffle) String() string            { return proto.CompactTex
 func (*VerifiableShuffle) ProtoMessage()               {}
 func (*VerifiableShuffle) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
 
+func (m *VerifiableShuffle) GetRounds() []*VerifiableShuffle_Round {
+	if m != nil {
+		return m.Rounds
+	}
+	return nil
+}
+
+type VerifiableShuffle_Round struct {
+	Alphas      [][]byte `protobuf:"bytes,1,rep,name=alphas,proto3" json:"alphas,omitempty"`
+	Betas       [][]byte `protobuf:"bytes,2,rep,name=betas,proto3" json:"betas,omitempty"`
+	Permutation []int32  `protobuf:"varint,3,rep,packed,name=permutation" json:"permutation,omitempty"`
+	Randomness  [][]byte `protobuf:"bytes,4,rep,name=randomness,proto3" json:"randomness,omitempty"`
+}
+
+func (m *VerifiableShuffle_Round) Reset()                    { *m = VerifiableShuffle_Round{} }
+func (m *VerifiableShuffle_Round) String() string            { return proto.CompactTextString(m) }
+func (*VerifiableShuffle_Round) ProtoMessage()               {}
+func (*VerifiableShuffle_Round) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4, 0} }
+
 type DiscreteLogEquality struct {
 	Ts [][]byte `protobuf:"bytes,1,rep,name=ts,proto3" json:"ts,omitempty"`
 	R  []byte   `protobuf:"bytes,2,opt,name=r,proto3" json:"r,omitempty"`
@@ -120,13 +137,96 @@ func (m *DiscreteLogEquality) String() string            { return proto.CompactT
 func (*DiscreteLogEquality) ProtoMessage()               {}
 func (*DiscreteLogEquality) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
 
+// An accusation of a party that sent a message that does not check.
+type Accusation struct {
+	Accused int32  `protobuf:"varint,1,opt,name=accused" json:"accused,omitempty"`
+	Round   int32  `protobuf:"varint,2,opt,name=round" json:"round,omitempty"`
+	Reason  string `protobuf:"bytes,3,opt,name=reason" json:"reason,omitempty"`
+	// The message of the accused party that failed the check
+	Evidence *OuterStruct `protobuf:"bytes,4,opt,name=evidence" json:"evidence,omitempty"`
+}
+
+func (m *Accusation) Reset()                    { *m = Accusation{} }
+func (m *Accusation) String() string            { return proto.CompactTextString(m) }
+func (*Accusation) ProtoMessage()               {}
+func (*Accusation) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+func (m *Accusation) GetEvidence() *OuterStruct {
+	if m != nil {
+		return m.Evidence
+	}
+	return nil
+}
+
+// What a party received in a round: the hash of the data and the signature
+// of the message of every party, by id.
+type Echo struct {
+	Digests    [][]byte `protobuf:"bytes,1,rep,name=digests,proto3" json:"digests,omitempty"`
+	Signatures [][]byte `protobuf:"bytes,2,rep,name=signatures,proto3" json:"signatures,omitempty"`
+}
+
+func (m *Echo) Reset()                    { *m = Echo{} }
+func (m *Echo) String() string            { return proto.CompactTextString(m) }
+func (*Echo) ProtoMessage()               {}
+func (*Echo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+// A record of a transcript file. The first one describes the run of the
+// auction, and another one the keys of its parties once connected; all
+// others hold a message the party that wrote the file sent or received.
+// Every record holds the hash of the one before it.
+type TranscriptRecord struct {
+	Prev      []byte   `protobuf:"bytes,1,opt,name=prev,proto3" json:"prev,omitempty"`
+	Auctionid string   `protobuf:"bytes,2,opt,name=auctionid" json:"auctionid,omitempty"`
+	Hosts     []string `protobuf:"bytes,3,rep,name=hosts" json:"hosts,omitempty"`
+	Myid      int32    `protobuf:"varint,4,opt,name=myid" json:"myid,omitempty"`
+	// The public keys of the parties, in PKIX form
+	Keys    [][]byte     `protobuf:"bytes,5,rep,name=keys,proto3" json:"keys,omitempty"`
+	Message *OuterStruct `protobuf:"bytes,6,opt,name=message" json:"message,omitempty"`
+	// Whether the message was sent to peer, rather than received
+	Sent bool  `protobuf:"varint,7,opt,name=sent" json:"sent,omitempty"`
+	Peer int32 `protobuf:"varint,8,opt,name=peer" json:"peer,omitempty"`
+}
+
+func (m *TranscriptRecord) Reset()                    { *m = TranscriptRecord{} }
+func (m *TranscriptRecord) String() string            { return proto.CompactTextString(m) }
+func (*TranscriptRecord) ProtoMessage()               {}
+func (*TranscriptRecord) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *TranscriptRecord) GetMessage() *OuterStruct {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+// The parameters of the auction a party is about to run, which every party
+// publishes before the auction starts: the protocol and its version, the
+// parameters by name, and the hash of all of them.
+type Handshake struct {
+	Protocol string   `protobuf:"bytes,1,opt,name=protocol" json:"protocol,omitempty"`
+	Version  int32    `protobuf:"varint,2,opt,name=version" json:"version,omitempty"`
+	Digest   []byte   `protobuf:"bytes,3,opt,name=digest,proto3" json:"digest,omitempty"`
+	Names    []string `protobuf:"bytes,4,rep,name=names" json:"names,omitempty"`
+	Values   []string `protobuf:"bytes,5,rep,name=values" json:"values,omitempty"`
+}
+
+func (m *Handshake) Reset()                    { *m = Handshake{} }
+func (m *Handshake) String() string            { return proto.CompactTextString(m) }
+func (*Handshake) ProtoMessage()               {}
+func (*Handshake) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
 func init() {
 	proto.RegisterType((*OuterStruct)(nil), "common_pb.OuterStruct")
 	proto.RegisterType((*Key)(nil), "common_pb.Key")
 	proto.RegisterType((*DiscreteLogKnowledge)(nil), "common_pb.DiscreteLogKnowledge")
 	proto.RegisterType((*EqualsOneOfTwo)(nil), "common_pb.EqualsOneOfTwo")
 	proto.RegisterType((*VerifiableShuffle)(nil), "common_pb.VerifiableShuffle")
+	proto.RegisterType((*VerifiableShuffle_Round)(nil), "common_pb.VerifiableShuffle.Round")
 	proto.RegisterType((*DiscreteLogEquality)(nil), "common_pb.DiscreteLogEquality")
+	proto.RegisterType((*Accusation)(nil), "common_pb.Accusation")
+	proto.RegisterType((*Echo)(nil), "common_pb.Echo")
+	proto.RegisterType((*TranscriptRecord)(nil), "common_pb.TranscriptRecord")
+	proto.RegisterType((*Handshake)(nil), "common_pb.Handshake")
 }
 
 func init() {
@@ -134,34 +234,49 @@ func init() {
 }
 
 var fileDescriptor0 = []byte{
-	// 459 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x6c, 0x92, 0xcd, 0x8e, 0xd3, 0x30,
-	0x14, 0x85, 0x95, 0x74, 0xda, 0x99, 0xde, 0x86, 0x01, 0x0c, 0x42, 0x16, 0x42, 0xa2, 0xca, 0x6a,
-	0x56, 0xad, 0x92, 0x8a, 0x27, 0x60, 0xca, 0x66, 0x10, 0x95, 0x32, 0xc0, 0x82, 0x4d, 0xe4, 0xf8,
-	0xa7, 0xb5, 0x48, 0xe3, 0xe0, 0x38, 0xaa, 0xd2, 0x27, 0xe1, 0x1d, 0x78, 0x49, 0xe4, 0x9f, 0x06,
-	0x90, 0xd8, 0xdd, 0xf3, 0x1d, 0xe7, 0xde, 0xe3, 0x5c, 0xc3, 0x66, 0x2f, 0xcd, 0xa1, 0xaf, 0x56,
-	0x54, 0x1d, 0xd7, 0xa4, 0x3b, 0x9c, 0x64, 0xd3, 0xe9, 0x35, 0xe9, 0xa9, 0x91, 0xaa, 0xe9, 0xd6,
-	0x54, 0x1d, 0x8f, 0xaa, 0x29, 0xdb, 0x2a, 0x54, 0xab, 0x56, 0x2b, 0xa3, 0xd0, 0x7c, 0xe4, 0xe9,
-	0x17, 0x58, 0xec, 0x7a, 0xc3, 0xf5, 0xa3, 0xd1, 0x3d, 0x35, 0xe8, 0x35, 0xdc, 0xd0, 0x5a, 0xf2,
-	0xc6, 0x48, 0x86, 0xa3, 0x65, 0x74, 0x37, 0x2d, 0x46, 0x8d, 0x5e, 0xc1, 0xac, 0x33, 0xbc, 0x95,
-	0x0c, 0xc7, 0xce, 0x09, 0x0a, 0x21, 0xb8, 0x62, 0xc4, 0x10, 0x3c, 0x59, 0x46, 0x77, 0x49, 0xe1,
-	0xea, 0xf4, 0x13, 0x4c, 0x1e, 0xf8, 0x80, 0x9e, 0xc1, 0xe4, 0x3b, 0x1f, 0x5c, 0xa7, 0xa4, 0xb0,
-	0x25, 0x7a, 0x07, 0xd3, 0x56, 0x2b, 0x25, 0x5c, 0x8f, 0x45, 0xfe, 0x76, 0x35, 0x46, 0x59, 0xdd,
-	0xcb, 0x8e, 0x6a, 0x6e, 0xf8, 0x47, 0xb5, 0x7f, 0x68, 0xd4, 0xa9, 0xe6, 0x6c, 0xcf, 0x0b, 0x7f,
-	0x3a, 0xcd, 0xe1, 0xe5, 0xff, 0x6c, 0x94, 0x40, 0x64, 0x42, 0xfb, 0xc8, 0x58, 0xa5, 0x5d, 0xe3,
-	0xa4, 0x88, 0x74, 0xfa, 0x33, 0x82, 0xdb, 0xed, 0x8f, 0x9e, 0xd4, 0xdd, 0xae, 0xe1, 0x3b, 0xf1,
-	0xf9, 0xa4, 0xd0, 0x53, 0x98, 0x90, 0x32, 0x0b, 0x1f, 0xc4, 0x24, 0xf3, 0x20, 0x0f, 0xdf, 0xc4,
-	0x24, 0xb7, 0xa0, 0x2a, 0xb3, 0x70, 0x97, 0xb8, 0xca, 0x3c, 0xc8, 0xf1, 0x55, 0x00, 0xee, 0x04,
-	0x2b, 0x33, 0x3c, 0xf5, 0x80, 0x65, 0x1e, 0xe4, 0x78, 0x16, 0x80, 0x3b, 0xa1, 0xcb, 0x0c, 0x5f,
-	0x7b, 0xa0, 0x33, 0x0f, 0x72, 0x7c, 0x13, 0x40, 0x9e, 0xfe, 0x8a, 0xe1, 0xf9, 0x57, 0xae, 0xa5,
-	0x90, 0xa4, 0xaa, 0xf9, 0xe3, 0xa1, 0x17, 0xa2, 0x76, 0x97, 0xa1, 0x38, 0x5a, 0x4e, 0x6c, 0x7c,
-	0x8a, 0x6e, 0x21, 0xa6, 0xec, 0x92, 0x8c, 0x32, 0xf4, 0x06, 0xe6, 0xf4, 0x3d, 0x69, 0xa5, 0x21,
-	0xf5, 0x7d, 0xc8, 0xf7, 0x07, 0x20, 0x0c, 0xd7, 0xdb, 0x82, 0xd4, 0xed, 0x81, 0x84, 0xa8, 0x17,
-	0x69, 0xd7, 0xb6, 0x2d, 0x2a, 0x6e, 0x48, 0x88, 0x1c, 0x94, 0x9d, 0x26, 0xf0, 0xcc, 0x4f, 0x13,
-	0x76, 0x9a, 0x60, 0x97, 0xc8, 0x82, 0x59, 0x3d, 0xb0, 0x4b, 0xe2, 0xc1, 0xe9, 0x33, 0xc3, 0x73,
-	0xaf, 0xcf, 0x6e, 0xe9, 0x95, 0xdc, 0x7f, 0xc0, 0xe0, 0x1a, 0xb8, 0xda, 0x26, 0x1c, 0xc6, 0x84,
-	0x0b, 0x9f, 0x70, 0x04, 0xd6, 0x3d, 0x8f, 0x6e, 0xe2, 0xdd, 0x11, 0xb8, 0x87, 0xe7, 0xeb, 0x6f,
-	0xf8, 0x89, 0x33, 0x47, 0x9d, 0x6e, 0xe0, 0xc5, 0x5f, 0xcb, 0x77, 0x2b, 0x95, 0x66, 0xb0, 0x91,
-	0x4c, 0x17, 0xfe, 0x57, 0x6c, 0xba, 0x7f, 0xb7, 0x5f, 0xcd, 0xdc, 0x53, 0xdf, 0xfc, 0x0e, 0x00,
-	0x00, 0xff, 0xff, 0x3b, 0x99, 0xc0, 0x94, 0x21, 0x03, 0x00, 0x00,
+	// 703 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x54, 0xc1, 0x6e, 0xdb, 0x38,
+	0x10, 0x85, 0x24, 0xcb, 0xb1, 0x68, 0xc3, 0x9b, 0xe5, 0x06, 0x01, 0x11, 0x2c, 0x76, 0x0d, 0x9d,
+	0x7c, 0x72, 0x2a, 0x05, 0xbd, 0xf4, 0xd4, 0x02, 0x0d, 0x50, 0x34, 0x45, 0x03, 0x30, 0x41, 0xaf,
+	0x06, 0x25, 0x8d, 0x6d, 0x22, 0x92, 0xa8, 0x92, 0x94, 0x0d, 0x7f, 0x41, 0x7b, 0xed, 0xad, 0x5f,
+	0xd5, 0x2f, 0xe8, 0xc7, 0x14, 0xa4, 0x28, 0xc7, 0x4d, 0x8b, 0xde, 0xe6, 0x3d, 0x0e, 0xc9, 0x79,
+	0x33, 0x8f, 0x44, 0x57, 0x6b, 0xae, 0x37, 0x6d, 0xb6, 0xc8, 0x45, 0x75, 0xc9, 0xd4, 0x66, 0xc7,
+	0x6b, 0x25, 0x2f, 0x59, 0x9b, 0x6b, 0x2e, 0x6a, 0x75, 0x99, 0x8b, 0xaa, 0x12, 0xf5, 0xb2, 0xc9,
+	0x5c, 0xb4, 0x68, 0xa4, 0xd0, 0x02, 0x47, 0x07, 0x3e, 0xfe, 0xe2, 0xa1, 0xf1, 0x6d, 0xab, 0x41,
+	0xde, 0x69, 0xd9, 0xe6, 0x1a, 0x5f, 0xa0, 0x51, 0x5e, 0x72, 0xa8, 0x35, 0x2f, 0x88, 0x37, 0xf3,
+	0xe6, 0x21, 0x3d, 0x60, 0x7c, 0x8e, 0x86, 0x4a, 0x43, 0xc3, 0x0b, 0xe2, 0xdb, 0x15, 0x87, 0x30,
+	0x46, 0x83, 0x82, 0x69, 0x46, 0x82, 0x99, 0x37, 0x9f, 0x50, 0x1b, 0xe3, 0x7f, 0x51, 0xa4, 0xf8,
+	0xba, 0x66, 0xba, 0x95, 0x40, 0x06, 0x76, 0xe1, 0x91, 0x30, 0xab, 0xae, 0x46, 0x5e, 0x90, 0x70,
+	0xe6, 0xcd, 0x23, 0xfa, 0x48, 0xc4, 0xef, 0x51, 0x70, 0x03, 0x7b, 0x7c, 0x8a, 0x82, 0x07, 0xd8,
+	0xdb, 0x2a, 0x26, 0xd4, 0x84, 0xf8, 0x39, 0x0a, 0x1b, 0x29, 0xc4, 0xca, 0xde, 0x3f, 0x4e, 0xff,
+	0x5f, 0x1c, 0x74, 0x2c, 0x5e, 0x73, 0x95, 0x4b, 0xd0, 0xf0, 0x4e, 0xac, 0x6f, 0x6a, 0xb1, 0x2b,
+	0xa1, 0x58, 0x03, 0xed, 0xb2, 0xe3, 0x14, 0x9d, 0xfd, 0x6e, 0x19, 0x4f, 0x90, 0xa7, 0xdd, 0xf1,
+	0x9e, 0x36, 0x48, 0xda, 0x83, 0x27, 0xd4, 0x93, 0xf1, 0x57, 0x0f, 0x4d, 0xaf, 0x3f, 0xb6, 0xac,
+	0x54, 0xb7, 0x35, 0xdc, 0xae, 0xee, 0x77, 0x02, 0xff, 0x85, 0x02, 0xb6, 0x4c, 0xdc, 0x06, 0x9f,
+	0x25, 0x1d, 0x91, 0xba, 0x3d, 0x3e, 0x4b, 0x0d, 0x91, 0x2d, 0x13, 0xd7, 0x07, 0x3f, 0x4b, 0x3a,
+	0x22, 0x75, 0xfa, 0xfd, 0xcc, 0x66, 0x14, 0xcb, 0xc4, 0x4a, 0x9e, 0x50, 0xbf, 0x48, 0x3a, 0x22,
+	0x25, 0x43, 0x47, 0xd8, 0x0c, 0xb9, 0x4c, 0xc8, 0x49, 0x47, 0xc8, 0xa4, 0x23, 0x52, 0x32, 0x72,
+	0x44, 0x1a, 0x7f, 0xf3, 0xd0, 0xdf, 0x1f, 0x40, 0xf2, 0x15, 0x67, 0x59, 0x09, 0x77, 0x9b, 0x76,
+	0xb5, 0x2a, 0x01, 0xbf, 0x40, 0x43, 0x29, 0xda, 0xba, 0x50, 0x64, 0x3a, 0x0b, 0xe6, 0xe3, 0x34,
+	0x3e, 0x6a, 0xce, 0x2f, 0xd9, 0x0b, 0x6a, 0x52, 0xa9, 0xdb, 0x71, 0xb1, 0x43, 0xa1, 0x25, 0xcc,
+	0x84, 0x59, 0xd9, 0x6c, 0x98, 0x22, 0xde, 0x2c, 0x98, 0x4f, 0xa8, 0x43, 0xf8, 0x0c, 0x85, 0x19,
+	0x68, 0xa6, 0x88, 0x6f, 0xe9, 0x0e, 0xe0, 0x19, 0x1a, 0x37, 0x20, 0xab, 0x56, 0x33, 0x33, 0x38,
+	0x12, 0xcc, 0x82, 0x79, 0x48, 0x8f, 0x29, 0xfc, 0x1f, 0x42, 0x92, 0xd5, 0x85, 0xa8, 0x6a, 0x50,
+	0x8a, 0x0c, 0xec, 0xe6, 0x23, 0xe6, 0xed, 0x60, 0xe4, 0x9d, 0x4e, 0xe3, 0x2b, 0xf4, 0xcf, 0xd1,
+	0x7c, 0x6c, 0xd7, 0xb9, 0xde, 0xe3, 0x29, 0xf2, 0x75, 0x5f, 0x88, 0xaf, 0xd5, 0x93, 0x01, 0x7d,
+	0xf6, 0x10, 0x7a, 0x95, 0xe7, 0xad, 0xea, 0x6e, 0x22, 0xe8, 0x84, 0x19, 0x04, 0xbd, 0x6d, 0x7b,
+	0x68, 0x6a, 0xb7, 0x32, 0x9d, 0x69, 0x43, 0xd9, 0x2b, 0x95, 0xc0, 0x94, 0x2d, 0xdb, 0xd8, 0xcf,
+	0x21, 0x9c, 0xa2, 0x11, 0x6c, 0x79, 0x01, 0x75, 0xde, 0xd9, 0x76, 0x9c, 0x9e, 0x1f, 0x35, 0xf2,
+	0xe8, 0xa5, 0xd0, 0x43, 0x5e, 0xfc, 0x12, 0x0d, 0xae, 0xf3, 0x8d, 0x30, 0x35, 0x14, 0x7c, 0x0d,
+	0xea, 0x50, 0x75, 0x0f, 0x4d, 0x1f, 0x0e, 0xe6, 0xef, 0x9b, 0x78, 0xc4, 0xc4, 0xdf, 0x3d, 0x74,
+	0x7a, 0x2f, 0x59, 0xad, 0x72, 0xc9, 0x1b, 0x4d, 0x21, 0x17, 0xd2, 0x3e, 0xab, 0x46, 0xc2, 0xd6,
+	0x19, 0xce, 0xc6, 0x3f, 0x3f, 0x1c, 0xff, 0xc9, 0xc3, 0x31, 0x52, 0x37, 0xc2, 0x5c, 0x6f, 0x46,
+	0x11, 0xd1, 0x0e, 0x98, 0x73, 0xaa, 0x3d, 0x2f, 0xac, 0x9c, 0x90, 0xda, 0xd8, 0x70, 0x0f, 0xb0,
+	0x57, 0x24, 0xb4, 0xa5, 0xd8, 0x18, 0x3f, 0x43, 0x27, 0x15, 0x28, 0xc5, 0xd6, 0x40, 0x86, 0x7f,
+	0x54, 0xde, 0xa7, 0x99, 0x53, 0x14, 0xd4, 0xda, 0x9a, 0x75, 0x44, 0x6d, 0x6c, 0xab, 0x06, 0x90,
+	0xd6, 0xaf, 0x21, 0xb5, 0x71, 0xfc, 0xc9, 0x43, 0xd1, 0x1b, 0x56, 0x17, 0x6a, 0xc3, 0x1e, 0xc0,
+	0x7c, 0x31, 0xf6, 0x1b, 0xca, 0x45, 0x69, 0xb5, 0x45, 0xf4, 0x80, 0x4d, 0x0b, 0xb7, 0x20, 0x95,
+	0xb1, 0x53, 0x37, 0xae, 0x1e, 0x9a, 0x81, 0x75, 0xdd, 0x74, 0xcf, 0xcb, 0x21, 0xa3, 0xb9, 0x66,
+	0x15, 0x74, 0xee, 0x8a, 0x68, 0x07, 0x4c, 0xf6, 0x96, 0x95, 0x2d, 0x74, 0x0a, 0x23, 0xea, 0x50,
+	0x36, 0xb4, 0x37, 0x5d, 0xfd, 0x18, 0x00, 0x3b, 0x9e, 0x57, 0x94, 0x37, 0x05, 0x00, 0x00,
 }
@@ -1,6 +1,7 @@
 package common_pb
 
 import (
+	"fmt"
 	"math/big"
 
 	"github.com/ashwinsr/auctions/zkp"
@@ -54,43 +55,43 @@ func DestructDiscreteLogEquality(proof *DiscreteLogEquality) (ts []big.Int, r bi
 	return
 }
 
-func CreateVerifiableSecretShuffle(c []big.Int, cd big.Int, cD big.Int, ER zkp.Ciphertext,
-	f []big.Int, fd big.Int, yd big.Int, zd big.Int, F []big.Int,
-	yD big.Int, zD big.Int, Z big.Int) *VerifiableShuffle {
-	return &VerifiableShuffle{
-		C:         BigIntSliceToByteSlice(c),
-		Cd:        cd.Bytes(),
-		CCapitalD: cD.Bytes(),
-		ERalpha:   ER.Alpha.Bytes(),
-		ERbeta:    ER.Beta.Bytes(),
-		F:         BigIntSliceToByteSlice(f),
-		Fd:        fd.Bytes(),
-		Yd:        yd.Bytes(),
-		Zd:        zd.Bytes(),
-		BigF:      BigIntSliceToByteSlice(F),
-		YCapitalD: yD.Bytes(),
-		ZCapitalD: zD.Bytes(),
-		CapitalZ:  Z.Bytes(),
+func CreateVerifiableSecretShuffle(rounds []zkp.ShuffleRound) *VerifiableShuffle {
+	proof := &VerifiableShuffle{}
+	for _, round := range rounds {
+		alphas, betas := zkp.CipherTextsToAlphasBetas(round.Mixed)
+		permutation := make([]int32, len(round.Permutation))
+		for i, j := range round.Permutation {
+			permutation[i] = int32(j)
+		}
+		proof.Rounds = append(proof.Rounds, &VerifiableShuffle_Round{
+			Alphas:      BigIntSliceToByteSlice(alphas),
+			Betas:       BigIntSliceToByteSlice(betas),
+			Permutation: permutation,
+			Randomness:  BigIntSliceToByteSlice(round.Randomness),
+		})
 	}
+	return proof
 }
 
-func DestructVerifiableSecretShuffle(proof *VerifiableShuffle) (
-	c []big.Int, cd big.Int, cD big.Int, ER zkp.Ciphertext,
-	f []big.Int, fd big.Int, yd big.Int, zd big.Int, F []big.Int,
-	yD big.Int, zD big.Int, Z big.Int) {
-	c = ByteSliceToBigIntSlice(proof.C)
-	cd.SetBytes(proof.Cd)
-	cD.SetBytes(proof.CCapitalD)
-	ER.Alpha.SetBytes(proof.ERalpha)
-	ER.Beta.SetBytes(proof.ERbeta)
-	f = ByteSliceToBigIntSlice(proof.F)
-	fd.SetBytes(proof.Fd)
-	yd.SetBytes(proof.Yd)
-	zd.SetBytes(proof.Zd)
-	F = ByteSliceToBigIntSlice(proof.BigF)
-	yD.SetBytes(proof.YCapitalD)
-	zD.SetBytes(proof.ZCapitalD)
-	Z.SetBytes(proof.CapitalZ)
+// DestructVerifiableSecretShuffle returns the rounds of proof. A round whose
+// lists differ in length is returned as it is, for the check of the proof
+// to reject.
+func DestructVerifiableSecretShuffle(proof *VerifiableShuffle) (rounds []zkp.ShuffleRound) {
+	for _, round := range proof.Rounds {
+		var mixed []zkp.Ciphertext
+		if len(round.Alphas) == len(round.Betas) {
+			mixed = zkp.AlphasBetasToCipherTexts(ByteSliceToBigIntSlice(round.Alphas), ByteSliceToBigIntSlice(round.Betas))
+		}
+		permutation := make([]int, len(round.Permutation))
+		for i, j := range round.Permutation {
+			permutation[i] = int(j)
+		}
+		rounds = append(rounds, zkp.ShuffleRound{
+			Mixed:       mixed,
+			Permutation: permutation,
+			Randomness:  ByteSliceToBigIntSlice(round.Randomness),
+		})
+	}
 	return
 }
 
@@ -107,3 +108,71 @@ func ByteSliceToBigIntSlice(ints [][]byte) (res []big.Int) {
 	}
 	return
 }
+
+// The functions below are the counterparts of the ones above for proofs
+// computed in an arbitrary zkp.Group. Elements are encoded with the group's
+// canonical encoding, and decoding checks that they are group elements.
+
+func CreateGroupDiscreteLogKnowledge(group zkp.Group, t zkp.Element, r *big.Int) *DiscreteLogKnowledge {
+	return &DiscreteLogKnowledge{T: group.Encode(t), R: r.Bytes()}
+}
+
+func DestructGroupDiscreteLogKnowledge(group zkp.Group, proof *DiscreteLogKnowledge) (t zkp.Element, r *big.Int, err error) {
+	if proof == nil {
+		return nil, nil, fmt.Errorf("missing discrete log knowledge proof")
+	}
+	if t, err = group.Decode(proof.T); err != nil {
+		return nil, nil, err
+	}
+	return t, new(big.Int).SetBytes(proof.R), nil
+}
+
+func CreateGroupDiscreteLogEquality(group zkp.Group, ts []zkp.Element, r *big.Int) *DiscreteLogEquality {
+	return &DiscreteLogEquality{
+		Ts: zkp.EncodeElements(group, ts),
+		R:  r.Bytes(),
+	}
+}
+
+func DestructGroupDiscreteLogEquality(group zkp.Group, proof *DiscreteLogEquality) (ts []zkp.Element, r *big.Int, err error) {
+	if proof == nil {
+		return nil, nil, fmt.Errorf("missing discrete log equality proof")
+	}
+	if ts, err = zkp.DecodeElements(group, proof.Ts); err != nil {
+		return nil, nil, err
+	}
+	return ts, new(big.Int).SetBytes(proof.R), nil
+}
+
+func CreateGroupIsOneOfTwo(group zkp.Group, a_1, a_2, b_1, b_2 zkp.Element, d_1, d_2, r_1, r_2 *big.Int) *EqualsOneOfTwo {
+	return &EqualsOneOfTwo{
+		A_1: group.Encode(a_1),
+		A_2: group.Encode(a_2),
+		B_1: group.Encode(b_1),
+		B_2: group.Encode(b_2),
+		D_1: d_1.Bytes(),
+		D_2: d_2.Bytes(),
+		R_1: r_1.Bytes(),
+		R_2: r_2.Bytes(),
+	}
+}
+
+func DestructGroupIsOneOfTwo(group zkp.Group, proof *EqualsOneOfTwo) (
+	a_1, a_2, b_1, b_2 zkp.Element, d_1, d_2, r_1, r_2 *big.Int, err error) {
+	if proof == nil {
+		err = fmt.Errorf("missing one of two proof")
+		return
+	}
+
+	elems, err := zkp.DecodeElements(group, [][]byte{proof.A_1, proof.A_2, proof.B_1, proof.B_2})
+	if err != nil {
+		return
+	}
+	a_1, a_2, b_1, b_2 = elems[0], elems[1], elems[2], elems[3]
+
+	d_1 = new(big.Int).SetBytes(proof.D_1)
+	d_2 = new(big.Int).SetBytes(proof.D_2)
+	r_1 = new(big.Int).SetBytes(proof.R_1)
+	r_2 = new(big.Int).SetBytes(proof.R_2)
+	return
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ashwinsr/auctions/zkp"
+	"github.com/golang/protobuf/proto"
+)
+
+// fpCheckpoint is an FpState as checkpointed, with every element encoded.
+// Missing elements and structs are encoded as null.
+type fpCheckpoint struct {
+	Bid uint `json:"bid"`
+
+	TieBreakNonce       []byte   `json:"tieBreakNonce"`
+	TieBreakCommitments [][]byte `json:"tieBreakCommitments"`
+	TieBreakNonces      [][]byte `json:"tieBreakNonces"`
+	TieBreakAhead       [][]int  `json:"tieBreakAhead"`
+
+	PrivateKey  *big.Int `json:"privateKey"`
+	MyPublicKey []byte   `json:"myPublicKey"`
+	Keys        [][]byte `json:"keys"`
+	PublicKey   []byte   `json:"publicKey"`
+
+	AlphasBetas []*encodedPair `json:"alphasBetas"`
+
+	GammasDeltasBeforeExponentiation []*encodedPair   `json:"gammasDeltasBeforeExponentiation"`
+	GammasDeltasAfterExponentiation  [][]*encodedPair `json:"gammasDeltasAfterExponentiation"`
+
+	PhisBeforeExponentiation [][][]byte   `json:"phisBeforeExponentiation"`
+	PhisAfterExponentiation  [][][][]byte `json:"phisAfterExponentiation"`
+
+	Winner       int    `json:"winner"`
+	Price        uint   `json:"price"`
+	SellerRound3 []byte `json:"sellerRound3"`
+}
+
+// encodedPair is an AlphaBetaStruct or a GammaDeltaStruct.
+type encodedPair struct {
+	A [][]byte `json:"a"`
+	B [][]byte `json:"b"`
+}
+
+// Checkpoint returns the state, for lib.Checkpointer.
+func (s *FpState) Checkpoint() ([]byte, error) {
+	sellerRound3, err := proto.Marshal(&s.sellerRound3)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &fpCheckpoint{
+		Bid: s.bid,
+
+		TieBreakNonce:       s.tieBreakNonce,
+		TieBreakCommitments: s.tieBreakCommitments,
+		TieBreakNonces:      s.tieBreakNonces,
+		TieBreakAhead:       s.tieBreakAhead,
+
+		PrivateKey:  s.myPrivateKey,
+		MyPublicKey: s.encode(s.myPublicKey),
+		Keys:        zkp.EncodeElements(s.group, s.keys),
+		PublicKey:   s.encode(s.publicKey),
+
+		GammasDeltasBeforeExponentiation: s.encodeGammasDeltas(s.GammasDeltasBeforeExponentiation),
+
+		Winner:       s.winner,
+		Price:        s.price,
+		SellerRound3: sellerRound3,
+	}
+
+	for _, ab := range s.AlphasBetas {
+		var p *encodedPair
+		if ab != nil {
+			p = &encodedPair{zkp.EncodeElements(s.group, ab.alphas), zkp.EncodeElements(s.group, ab.betas)}
+		}
+		c.AlphasBetas = append(c.AlphasBetas, p)
+	}
+	for _, gds := range s.GammasDeltasAfterExponentiation {
+		c.GammasDeltasAfterExponentiation = append(c.GammasDeltasAfterExponentiation, s.encodeGammasDeltas(gds))
+	}
+	for _, phis := range s.PhisBeforeExponentiation {
+		c.PhisBeforeExponentiation = append(c.PhisBeforeExponentiation, zkp.EncodeElements(s.group, phis))
+	}
+	for _, doublePhis := range s.PhisAfterExponentiation {
+		var encs [][][]byte
+		for _, phis := range doublePhis {
+			encs = append(encs, zkp.EncodeElements(s.group, phis))
+		}
+		c.PhisAfterExponentiation = append(c.PhisAfterExponentiation, encs)
+	}
+
+	return json.Marshal(c)
+}
+
+// Restore sets the state to one returned by Checkpoint, for
+// lib.Checkpointer.
+func (s *FpState) Restore(data []byte) error {
+	var c fpCheckpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return err
+	}
+
+	var err error
+	s.bid = c.Bid
+	s.tieBreakNonce = c.TieBreakNonce
+	s.tieBreakCommitments = c.TieBreakCommitments
+	s.tieBreakNonces = c.TieBreakNonces
+	s.tieBreakAhead = c.TieBreakAhead
+
+	s.myPrivateKey = c.PrivateKey
+	if s.myPublicKey, err = s.decode(c.MyPublicKey); err != nil {
+		return fmt.Errorf("my public key: %v", err)
+	}
+	if s.keys, err = zkp.DecodeElements(s.group, c.Keys); err != nil {
+		return fmt.Errorf("keys: %v", err)
+	}
+	if s.publicKey, err = s.decode(c.PublicKey); err != nil {
+		return fmt.Errorf("public key: %v", err)
+	}
+
+	s.AlphasBetas = nil
+	for _, p := range c.AlphasBetas {
+		var ab *AlphaBetaStruct
+		if p != nil {
+			ab = new(AlphaBetaStruct)
+			if ab.alphas, err = zkp.DecodeElements(s.group, p.A); err != nil {
+				return fmt.Errorf("alphas: %v", err)
+			}
+			if ab.betas, err = zkp.DecodeElements(s.group, p.B); err != nil {
+				return fmt.Errorf("betas: %v", err)
+			}
+		}
+		s.AlphasBetas = append(s.AlphasBetas, ab)
+	}
+
+	if s.GammasDeltasBeforeExponentiation, err = s.decodeGammasDeltas(c.GammasDeltasBeforeExponentiation); err != nil {
+		return err
+	}
+	s.GammasDeltasAfterExponentiation = nil
+	for _, p := range c.GammasDeltasAfterExponentiation {
+		gds, err := s.decodeGammasDeltas(p)
+		if err != nil {
+			return err
+		}
+		s.GammasDeltasAfterExponentiation = append(s.GammasDeltasAfterExponentiation, gds)
+	}
+
+	s.PhisBeforeExponentiation = nil
+	for _, encs := range c.PhisBeforeExponentiation {
+		phis, err := zkp.DecodeElements(s.group, encs)
+		if err != nil {
+			return fmt.Errorf("phis: %v", err)
+		}
+		s.PhisBeforeExponentiation = append(s.PhisBeforeExponentiation, phis)
+	}
+	s.PhisAfterExponentiation = nil
+	for _, doubleEncs := range c.PhisAfterExponentiation {
+		var doublePhis [][]zkp.Element
+		for _, encs := range doubleEncs {
+			phis, err := zkp.DecodeElements(s.group, encs)
+			if err != nil {
+				return fmt.Errorf("phis: %v", err)
+			}
+			doublePhis = append(doublePhis, phis)
+		}
+		s.PhisAfterExponentiation = append(s.PhisAfterExponentiation, doublePhis)
+	}
+
+	s.winner = c.Winner
+	s.price = c.Price
+	s.sellerRound3 = Round3{}
+	return proto.Unmarshal(c.SellerRound3, &s.sellerRound3)
+}
+
+// encode encodes e, which may be missing.
+func (s *FpState) encode(e zkp.Element) []byte {
+	if e == nil {
+		return nil
+	}
+	return s.group.Encode(e)
+}
+
+// decode decodes an element encoded by encode.
+func (s *FpState) decode(enc []byte) (zkp.Element, error) {
+	if enc == nil {
+		return nil, nil
+	}
+	return s.group.Decode(enc)
+}
+
+// encodeGammasDeltas encodes the gammas and deltas of every bidder.
+func (s *FpState) encodeGammasDeltas(gds []*GammaDeltaStruct) []*encodedPair {
+	var res []*encodedPair
+	for _, gd := range gds {
+		var p *encodedPair
+		if gd != nil {
+			p = &encodedPair{zkp.EncodeElements(s.group, gd.gammas), zkp.EncodeElements(s.group, gd.deltas)}
+		}
+		res = append(res, p)
+	}
+	return res
+}
+
+// decodeGammasDeltas decodes what encodeGammasDeltas encoded.
+func (s *FpState) decodeGammasDeltas(ps []*encodedPair) ([]*GammaDeltaStruct, error) {
+	var res []*GammaDeltaStruct
+	for _, p := range ps {
+		var gd *GammaDeltaStruct
+		if p != nil {
+			var err error
+			gd = new(GammaDeltaStruct)
+			if gd.gammas, err = zkp.DecodeElements(s.group, p.A); err != nil {
+				return nil, fmt.Errorf("gammas: %v", err)
+			}
+			if gd.deltas, err = zkp.DecodeElements(s.group, p.B); err != nil {
+				return nil, fmt.Errorf("deltas: %v", err)
+			}
+		}
+		res = append(res, gd)
+	}
+	return res, nil
+}
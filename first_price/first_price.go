@@ -8,12 +8,16 @@
  *
  * To invoke, run:
  *          go run *.go -bid=<BID VALUE>
+ *
+ * To verify the transcript of an auction, run:
+ *          go run *.go verify <TRANSCRIPT FILE>
  */
 
 package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"math/big"
 
@@ -21,30 +25,58 @@ import (
 	"github.com/ashwinsr/auctions/lib"
 	"github.com/ashwinsr/auctions/zkp"
 	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
 )
 
 var (
 	myAddress = flag.String("address", "localhost:1234", "address")
-	id        = new(int)
-	bid       = flag.Uint("bid", 0, "Amount of money")
+	bid       = flag.String("bid", "0", "Amount of money, one of the prices of the hosts file")
 )
 
-// The maximum bid amount
-var K uint = 100
+// The label of the protocol in the transcripts of its proofs
+const protocolLabel = "auctions/first_price"
+
+// The version of the protocol, which changes whenever its messages do
+const protocolVersion = 1
+
+// The step ids of the rounds, as numbered by lib.Session.Run
+const (
+	stepHandshake = iota + 1
+	stepPrologue
+	stepRound1
+	stepTieBreak
+	stepRound2
+	stepRound3
+)
 
 type AlphaBetaStruct struct {
-	alphas, betas []big.Int
+	alphas, betas []zkp.Element
 }
 
 type GammaDeltaStruct struct {
-	gammas, deltas []big.Int
+	gammas, deltas []zkp.Element
 }
 
 type FpState struct {
-	myPrivateKey big.Int
-	myPublicKey  big.Int
-	keys         []big.Int
-	publicKey    big.Int
+	session *lib.Session
+	id      int
+	bid     uint // the index of the bid in prices
+
+	prices    *lib.PriceLadder
+	group     zkp.Group
+	auctionID string
+	tieBreak  lib.TieBreak
+	hostID    func(i int) int // the id of party i in the hosts file
+
+	tieBreakNonce       []byte
+	tieBreakCommitments [][]byte
+	tieBreakNonces      [][]byte
+	tieBreakAhead       [][]int // indices (i): the bidders that win a tie against i
+
+	myPrivateKey *big.Int
+	myPublicKey  zkp.Element
+	keys         []zkp.Element
+	publicKey    zkp.Element
 	currRound    int
 
 	AlphasBetas []*AlphaBetaStruct
@@ -52,8 +84,11 @@ type FpState struct {
 	GammasDeltasBeforeExponentiation []*GammaDeltaStruct   // indices (i, j)
 	GammasDeltasAfterExponentiation  [][]*GammaDeltaStruct // indices (a, i, j)
 
-	PhisBeforeExponentiation [][]big.Int   // indices (i, j)
-	PhisAfterExponentiation  [][][]big.Int // indices (a, i, j)
+	PhisBeforeExponentiation [][]zkp.Element   // indices (i, j)
+	PhisAfterExponentiation  [][][]zkp.Element // indices (a, i, j)
+
+	winner int  // the id of the winner in this run, not in the hosts file
+	price  uint // the index of the selling price in prices
 
 	sellerRound3 Round3
 }
@@ -61,44 +96,78 @@ type FpState struct {
 func main() {
 	flag.Parse()
 
-	myState := &FpState{}
+	config := lib.GetAuctionConfig("first_price")
+	if flag.Arg(0) == "verify" {
+		result, err := lib.Verify(config, flag.Arg(1), verify)
+		if err != nil {
+			log.Fatalf("Verification failed: %v", err)
+		}
+		lib.PrintResult(result)
+		return
+	}
 
-	hosts, myID := lib.GetHostsAndID()
-	*id = myID
+	index, err := config.Prices.Index(*bid)
+	if err != nil {
+		log.Fatalf("Invalid bid: %v", err)
+	}
 
-	myAddr := hosts[*id]
-	lib.Init(*id)
+	log.Println("My address is: ", config.Hosts[config.MyID])
+	log.Println("My ID is: ", config.MyID)
 
-	log.Println("My address is: ", myAddr)
-	log.Println("My ID is: ", *id)
+	_, myState, err := lib.RunAuction(context.Background(), config, lib.Protocol{
+		NewTransport: lib.NewGRPCTransport,
+		Start: func(config *lib.AuctionConfig, session *lib.Session) ([]lib.Round, interface{}, error) {
+			return fpRounds(config), newFpState(session, config, uint(index)), nil
+		},
+	})
+	if err != nil {
+		log.Fatalf("Auction failed: %v", err)
+	}
 
-	go lib.RunServer(myAddr)
+	lib.PrintResult(myState.(*FpState).Result())
+}
 
-	lib.InitClients(hosts, myAddr)
+// newFpState returns the state of a party bidding the price with index bid
+// in session.
+func newFpState(session *lib.Session, config *lib.AuctionConfig, bid uint) *FpState {
+	return &FpState{
+		session:   session,
+		id:        config.MyID,
+		bid:       bid,
+		prices:    config.Prices,
+		group:     config.Group,
+		auctionID: config.AuctionID,
+		tieBreak:  config.TieBreak,
+		hostID:    config.HostID,
+	}
+}
 
-	var rounds []lib.Round
+// fpRounds returns the rounds of the party config.MyID. They start with
+// the handshake, checking that all parties agree on the auction.
+func fpRounds(config *lib.AuctionConfig) []lib.Round {
+	handshake := lib.HandshakeRound(config, protocolVersion, lib.Parameter{Name: "proof label", Value: protocolLabel})
 
-	// Register all the computation rounds with the library
-	if *id == 0 {
+	if config.MyID == 0 {
 		// If seller
-		rounds = []lib.Round{
+		return []lib.Round{
+			handshake,
 			{computePrologue, checkPrologue, receivePrologue},
 			{computeRound1, checkRound1, receiveRound1},
+			{computeTieBreak, checkTieBreak, receiveTieBreak},
 			{computeRound2, checkRound2, receiveRound2},
 			{computeRound3, checkRound3, sellerReceiveRound3},
 		}
-	} else {
-		// If bidder
-		rounds = []lib.Round{
-			{computePrologue, checkPrologue, receivePrologue},
-			{computeRound1, checkRound1, receiveRound1},
-			{computeRound2, checkRound2, receiveRound2},
-			{computeRound3, checkRound3, receiveRound3},
-		}
 	}
 
-	lib.Register(rounds, myState)
-
+	// If bidder
+	return []lib.Round{
+		handshake,
+		{computePrologue, checkPrologue, receivePrologue},
+		{computeRound1, checkRound1, receiveRound1},
+		{computeTieBreak, checkTieBreak, receiveTieBreak},
+		{computeRound2, checkRound2, receiveRound2},
+		{computeRound3, checkRound3, receiveRound3},
+	}
 }
 
 func getFpState(state interface{}) (s *FpState) {
@@ -109,22 +178,55 @@ func getFpState(state interface{}) (s *FpState) {
 	return
 }
 
+// Result returns the outcome of the auction, once it is over.
+func (s *FpState) Result() *lib.Result {
+	r := s.session.Result()
+	r.Winners = []int{s.hostID(s.winner)}
+	r.Price = s.prices.Price(int(s.price))
+	return r
+}
+
+// numPrices returns the number of prices a bidder may bid.
+func (s *FpState) numPrices() int {
+	return s.prices.Len()
+}
+
+// transcript returns the transcript of the proofs sent by client in step.
+func (s *FpState) transcript(step int, client int) *zkp.Transcript {
+	return zkp.NewRoundTranscript(protocolLabel, s.auctionID, s.group, step, client)
+}
+
 func checkPrologue(state interface{}, result *pb.OuterStruct) (err error) {
-	var key pb.Key
+	s := getFpState(state)
+	client := int(result.Clientid)
+	var prologue Prologue
 
-	err = proto.Unmarshal(result.Data, &key)
+	err = proto.Unmarshal(result.Data, &prologue)
 	if err != nil {
-		log.Fatalf("Failed to unmarshal pb.Key.\n")
+		return lib.NewError(lib.DecodeError, client, "Failed to unmarshal Prologue: %v", err)
+	}
+
+	key := prologue.Key
+	if key == nil {
+		return lib.NewError(lib.DecodeError, client, "Missing key")
+	}
+	if s.tieBreak == lib.TieBreakRandom && len(prologue.TieBreakCommitment) == 0 {
+		return lib.NewError(lib.DecodeError, client, "Missing tie-breaking commitment")
 	}
 
-	t, r := pb.DestructDiscreteLogKnowledge(key.Proof)
+	k, err := zkp.DecodeKey(s.group, key.Key)
+	if err != nil {
+		return lib.NewError(lib.DecodeError, client, "Received invalid key: %v", err)
+	}
 
-	var k big.Int
-	k.SetBytes(key.Key)
+	t, r, err := pb.DestructGroupDiscreteLogKnowledge(s.group, key.Proof)
+	if err != nil {
+		return lib.NewError(lib.DecodeError, client, "Received invalid zero-knowledge proof of key: %v", err)
+	}
 
-	err = zkp.CheckDiscreteLogKnowledgeProof(*zkp.G, k, t, r, *zkp.P, *zkp.Q)
+	err = zkp.CheckGroupDiscreteLogKnowledgeProof(s.transcript(stepPrologue, client), s.group, s.group.Generator(), k, t, r)
 	if err != nil {
-		log.Fatalf("Received incorrect zero-knowledge proof. Key=%v, t=%v, r=%v", k, t, r)
+		return lib.NewError(lib.ProofError, client, "Received incorrect zero-knowledge proof of key %x: %v", key.Key, err)
 	}
 
 	return
@@ -132,27 +234,39 @@ func checkPrologue(state interface{}, result *pb.OuterStruct) (err error) {
 
 func checkRound1(state interface{}, result *pb.OuterStruct) (err error) {
 	s := getFpState(state)
+	client := int(result.Clientid)
 	var in Round1
 
 	err = proto.Unmarshal(result.Data, &in)
 	if err != nil {
-		log.Fatalf("Failed to unmarshal Round1.\n")
+		return lib.NewError(lib.DecodeError, client, "Failed to unmarshal Round1: %v", err)
+	}
+
+	if len(in.Alphas) != len(in.Betas) || len(in.Proofs) != len(in.Betas) || len(in.Proofs) != s.numPrices() {
+		return lib.NewError(lib.DecodeError, client, "Incorrect number of alpha/betas in round 1")
 	}
 
-	if len(in.Alphas) != len(in.Betas) || len(in.Proofs) != len(in.Betas) || uint(len(in.Proofs)) != K {
-		log.Fatalf("Incorrect number of alpha/betas in round 1")
+	alphas, err := zkp.DecodeElements(s.group, in.Alphas)
+	if err != nil {
+		return lib.NewError(lib.DecodeError, client, "Received invalid alphas: %v", err)
+	}
+	betas, err := zkp.DecodeElements(s.group, in.Betas)
+	if err != nil {
+		return lib.NewError(lib.DecodeError, client, "Received invalid betas: %v", err)
 	}
 
-	alphas := pb.ByteSliceToBigIntSlice(in.Alphas)
-	betas := pb.ByteSliceToBigIntSlice(in.Betas)
+	tr := s.transcript(stepRound1, client)
 
 	for i := 0; i < len(in.Alphas); i++ {
-		a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2 := pb.DestructIsOneOfTwo(in.Proofs[i])
+		a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2, err := pb.DestructGroupIsOneOfTwo(s.group, in.Proofs[i])
+		if err != nil {
+			return lib.NewError(lib.DecodeError, client, "Received invalid zero-knowledge proof for alpha/beta: %v", err)
+		}
 
-		if err := zkp.CheckEncryptedValueIsOneOfTwo(alphas[i], betas[i], *zkp.P, *zkp.Q,
+		if err := zkp.CheckGroupEncryptedValueIsOneOfTwo(tr.Fork("bid", i), s.group, alphas[i], betas[i],
 			a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2,
-			*zkp.G, s.publicKey, *zkp.Y_Mill); err != nil {
-			log.Fatalf("Received incorrect zero-knowledge proof for alpha/beta")
+			s.group.Generator(), s.publicKey, s.group.SecondGenerator()); err != nil {
+			return lib.NewError(lib.ProofError, client, "Received incorrect zero-knowledge proof for alpha/beta: %v", err)
 		}
 	}
 
@@ -162,21 +276,22 @@ func checkRound1(state interface{}, result *pb.OuterStruct) (err error) {
 	// Then multiplying all of the betas together gives us g^(sum of the r's).
 	// Therefore we check that these two have the same exponent!
 
-	yExpSumR := *Multiply(0, len(alphas), zkp.P, func(i int) *big.Int { return &alphas[i] })
-	gExpSumR := *Multiply(0, len(betas), zkp.P, func(i int) *big.Int { return &betas[i] })
+	yExpSumR := Multiply(s.group, 0, len(alphas), func(i int) zkp.Element { return alphas[i] })
+	gExpSumR := Multiply(s.group, 0, len(betas), func(i int) zkp.Element { return betas[i] })
 
 	// divide by Y
-	YInv := new(big.Int).ModInverse(zkp.Y_Mill, zkp.P)
-	yExpSumR.Mul(&yExpSumR, YInv)
-	yExpSumR.Mod(&yExpSumR, zkp.P)
+	yExpSumR = zkp.Divide(s.group, yExpSumR, s.group.SecondGenerator())
 
-	bases := []big.Int{s.publicKey, *zkp.G}
-	results := []big.Int{yExpSumR, gExpSumR}
+	bases := []zkp.Element{s.publicKey, s.group.Generator()}
+	results := []zkp.Element{yExpSumR, gExpSumR}
 
-	ts, r := pb.DestructDiscreteLogEquality(in.Proof)
+	ts, r, err := pb.DestructGroupDiscreteLogEquality(s.group, in.Proof)
+	if err != nil {
+		return lib.NewError(lib.DecodeError, client, "Received invalid zero-knowledge proof for alphas/betas: %v", err)
+	}
 
-	if err := zkp.CheckDiscreteLogEqualityProof(bases, results, ts, r, *zkp.P, *zkp.Q); err != nil {
-		log.Fatalf("Received incorrect zero-knowledge proof for alphas/betas: bidder bid multiple values?")
+	if err := zkp.CheckGroupDiscreteLogEqualityProof(tr.Fork("sum"), s.group, bases, results, ts, r); err != nil {
+		return lib.NewError(lib.ProofError, client, "Received incorrect zero-knowledge proof for alphas/betas: bidder bid multiple values? %v", err)
 	}
 
 	return
@@ -184,46 +299,60 @@ func checkRound1(state interface{}, result *pb.OuterStruct) (err error) {
 
 func checkRound2(state interface{}, result *pb.OuterStruct) (err error) {
 	s := getFpState(state)
+	client := int(result.Clientid)
 	var in Round2
 
 	err = proto.Unmarshal(result.Data, &in)
 	if err != nil {
-		log.Fatalf("Failed to unmarshal Round2.\n")
+		return lib.NewError(lib.DecodeError, client, "Failed to unmarshal Round2: %v", err)
 	}
 
 	if len(in.DoubleGammas) != len(in.DoubleDeltas) ||
 		len(in.DoubleDeltas) != len(in.DoubleProofs) ||
 		len(in.DoubleGammas) != len(s.keys) {
-		log.Fatalf("Incorrect number of double gamma/deltas")
+		return lib.NewError(lib.DecodeError, client, "Incorrect number of double gamma/deltas")
 	}
 
+	tr := s.transcript(stepRound2, client)
+
 	for i := 0; i < len(in.DoubleGammas); i++ {
+		if in.DoubleGammas[i] == nil || in.DoubleDeltas[i] == nil || in.DoubleProofs[i] == nil {
+			return lib.NewError(lib.DecodeError, client, "Missing gammas, deltas or proofs in round 2")
+		}
+
 		if len(in.DoubleGammas[i].Gammas) != len(in.DoubleDeltas[i].Deltas) ||
 			len(in.DoubleDeltas[i].Deltas) != len(in.DoubleProofs[i].Proofs) ||
-			len(in.DoubleGammas[i].Gammas) != int(K) {
-			log.Fatalf("Incorrect number of proofs in round 2 %v %v %v\n",
+			len(in.DoubleGammas[i].Gammas) != s.numPrices() {
+			return lib.NewError(lib.DecodeError, client, "Incorrect number of proofs in round 2 %v %v %v",
 				len(in.DoubleGammas[i].Gammas),
 				len(in.DoubleDeltas[i].Deltas),
 				len(in.DoubleProofs[i].Proofs))
 		}
 
-		gammas := pb.ByteSliceToBigIntSlice(in.DoubleGammas[i].Gammas)
-		deltas := pb.ByteSliceToBigIntSlice(in.DoubleDeltas[i].Deltas)
+		gammas, err := zkp.DecodeElements(s.group, in.DoubleGammas[i].Gammas)
+		if err != nil {
+			return lib.NewError(lib.DecodeError, client, "Received invalid gammas: %v", err)
+		}
+		deltas, err := zkp.DecodeElements(s.group, in.DoubleDeltas[i].Deltas)
+		if err != nil {
+			return lib.NewError(lib.DecodeError, client, "Received invalid deltas: %v", err)
+		}
 
 		for j := 0; j < len(in.DoubleGammas[i].Gammas); j++ {
-			ts, r := pb.DestructDiscreteLogEquality(in.DoubleProofs[i].Proofs[j])
+			ts, r, err := pb.DestructGroupDiscreteLogEquality(s.group, in.DoubleProofs[i].Proofs[j])
+			if err != nil {
+				return lib.NewError(lib.DecodeError, client, "Received invalid zero-knowledge proof for gamma/delta: %v", err)
+			}
 
 			// bases are their gammas and deltas before exponentiation!
-			bases := []big.Int{
+			bases := []zkp.Element{
 				s.GammasDeltasBeforeExponentiation[i].gammas[j],
 				s.GammasDeltasBeforeExponentiation[i].deltas[j],
 			}
-			results := []big.Int{gammas[j], deltas[j]}
-			log.Printf("Received gamma/delta %v/%v with proof values %v, %v, and bases %v",
-				gammas[j], deltas[j], ts, r, bases)
+			results := []zkp.Element{gammas[j], deltas[j]}
 
-			if err := zkp.CheckDiscreteLogEqualityProof(bases, results, ts, r, *zkp.P, *zkp.Q); err != nil {
-				log.Fatalf("Received incorrect zero-knowledge proof for gamma/delta")
+			if err := zkp.CheckGroupDiscreteLogEqualityProof(tr.Fork("gammadelta", i, j), s.group, bases, results, ts, r); err != nil {
+				return lib.NewError(lib.ProofError, client, "Received incorrect zero-knowledge proof for gamma/delta: %v", err)
 			}
 		}
 	}
@@ -233,42 +362,53 @@ func checkRound2(state interface{}, result *pb.OuterStruct) (err error) {
 
 func checkRound3(state interface{}, result *pb.OuterStruct) (err error) {
 	s := getFpState(state)
+	client := int(result.Clientid)
 	var in Round3
 
 	err = proto.Unmarshal(result.Data, &in)
 	if err != nil {
-		log.Fatalf("Failed to unmarshal Round2.\n")
+		return lib.NewError(lib.DecodeError, client, "Failed to unmarshal Round3: %v", err)
 	}
 
 	if len(in.DoublePhis) != len(in.DoubleProofs) ||
 		len(in.DoubleProofs) != len(s.keys) {
-		log.Fatalf("Incorrect number of double phis in round 3")
+		return lib.NewError(lib.DecodeError, client, "Incorrect number of double phis in round 3")
 	}
 
+	tr := s.transcript(stepRound3, client)
+
 	for i := 0; i < len(in.DoublePhis); i++ {
+		if in.DoublePhis[i] == nil || in.DoubleProofs[i] == nil {
+			return lib.NewError(lib.DecodeError, client, "Missing phis or proofs in round 3")
+		}
+
 		if len(in.DoublePhis[i].Phis) != len(in.DoubleProofs[i].Proofs) ||
-			len(in.DoubleProofs[i].Proofs) != int(K) {
-			log.Fatalf("Incorrect number of proofs in round 3 %v %v\n",
+			len(in.DoubleProofs[i].Proofs) != s.numPrices() {
+			return lib.NewError(lib.DecodeError, client, "Incorrect number of proofs in round 3 %v %v",
 				len(in.DoublePhis[i].Phis),
 				len(in.DoubleProofs[i].Proofs))
 		}
 
-		phis := pb.ByteSliceToBigIntSlice(in.DoublePhis[i].Phis)
+		phis, err := zkp.DecodeElements(s.group, in.DoublePhis[i].Phis)
+		if err != nil {
+			return lib.NewError(lib.DecodeError, client, "Received invalid phis: %v", err)
+		}
 
 		for j := 0; j < len(in.DoublePhis[i].Phis); j++ {
-			ts, r := pb.DestructDiscreteLogEquality(in.DoubleProofs[i].Proofs[j])
+			ts, r, err := pb.DestructGroupDiscreteLogEquality(s.group, in.DoubleProofs[i].Proofs[j])
+			if err != nil {
+				return lib.NewError(lib.DecodeError, client, "Received invalid zero-knowledge proof for phis: %v", err)
+			}
 
-			// bases are their gammas and deltas before exponentiation!
-			bases := []big.Int{
+			// bases are the phis before exponentiation and the generator
+			bases := []zkp.Element{
 				s.PhisBeforeExponentiation[i][j],
-				*zkp.G,
+				s.group.Generator(),
 			}
-			results := []big.Int{phis[j], s.keys[result.Clientid]}
-			log.Printf("Received phi %v with proof values %v, %v, and bases %v",
-				phis[j], ts, r, bases)
+			results := []zkp.Element{phis[j], s.keys[result.Clientid]}
 
-			if err := zkp.CheckDiscreteLogEqualityProof(bases, results, ts, r, *zkp.P, *zkp.Q); err != nil {
-				log.Fatalf("Received incorrect zero-knowledge proof for phis")
+			if err := zkp.CheckGroupDiscreteLogEqualityProof(tr.Fork("phi", i, j), s.group, bases, results, ts, r); err != nil {
+				return lib.NewError(lib.ProofError, client, "Received incorrect zero-knowledge proof for phis: %v", err)
 			}
 		}
 	}
@@ -276,94 +416,108 @@ func checkRound3(state interface{}, result *pb.OuterStruct) (err error) {
 	return
 }
 
-func receivePrologue(FpState interface{}, results []*pb.OuterStruct) {
+func receivePrologue(FpState interface{}, results []*pb.OuterStruct) error {
 	s := getFpState(FpState)
-	var key pb.Key
+	var prologue Prologue
 
-	s.keys = make([]big.Int, len(results))
+	s.keys = make([]zkp.Element, len(results))
+	s.tieBreakCommitments = make([][]byte, len(results))
 
-	s.keys[*id] = s.myPublicKey
+	s.keys[s.id] = s.myPublicKey
 
 	for i := 0; i < len(results); i++ {
-		if i == *id {
+		if i == s.id {
 			continue
 		}
-		err := proto.Unmarshal(results[i].Data, &key)
+		err := proto.Unmarshal(results[i].Data, &prologue)
+		if err != nil {
+			return lib.NewError(lib.DecodeError, i, "Failed to unmarshal Prologue: %v", err)
+		}
+		s.keys[i], err = zkp.DecodeKey(s.group, prologue.Key.Key)
 		if err != nil {
-			log.Fatalf("Failed to unmarshal pb.Key.\n")
+			return lib.NewError(lib.DecodeError, i, "Failed to decode key: %v", err)
 		}
-		var k big.Int
-		k.SetBytes(key.Key)
-		s.keys[i] = k
+		s.tieBreakCommitments[i] = prologue.TieBreakCommitment
 	}
 
 	// Calculating final public key by multiplying them all together
-	s.publicKey = *Multiply(0, len(s.keys), zkp.P, func(i int) *big.Int { return &s.keys[i] })
+	s.publicKey = Multiply(s.group, 0, len(s.keys), func(i int) zkp.Element { return s.keys[i] })
 
-	log.Printf("Calculated public key: %v\n", s.publicKey.String())
+	log.Printf("Calculated public key: %x\n", s.group.Encode(s.publicKey))
+
+	return nil
 }
 
-func receiveRound1(FpState interface{}, results []*pb.OuterStruct) {
+func receiveRound1(FpState interface{}, results []*pb.OuterStruct) error {
 	s := getFpState(FpState)
 
 	var round1 Round1
 
 	// Store all received alphas and betas
 	for i := 0; i < len(results); i++ {
-		if i == *id {
+		if i == s.id {
 			continue
 		}
 		err := proto.Unmarshal(results[i].Data, &round1)
 		if err != nil {
-			log.Fatalf("Failed to unmarshal Round1.\n")
+			return lib.NewError(lib.DecodeError, i, "Failed to unmarshal Round1: %v", err)
 		}
 
 		s.AlphasBetas[i] = new(AlphaBetaStruct)
-		s.AlphasBetas[i].alphas =
-			pb.ByteSliceToBigIntSlice(round1.Alphas)
-		s.AlphasBetas[i].betas =
-			pb.ByteSliceToBigIntSlice(round1.Betas)
+		if s.AlphasBetas[i].alphas, err = decodeElements(s.group, i, round1.Alphas); err != nil {
+			return err
+		}
+		if s.AlphasBetas[i].betas, err = decodeElements(s.group, i, round1.Betas); err != nil {
+			return err
+		}
 	}
+
+	return nil
 }
 
-func receiveRound2(FpState interface{}, results []*pb.OuterStruct) {
+func receiveRound2(FpState interface{}, results []*pb.OuterStruct) error {
 	s := getFpState(FpState)
 
 	var round2 Round2
 
 	// Store all received alphas and betas
 	for a := 0; a < len(results); a++ {
-		if a == *id {
+		if a == s.id {
 			continue
 		}
 		err := proto.Unmarshal(results[a].Data, &round2)
 		if err != nil {
-			log.Fatalf("Failed to unmarshal Round2.\n")
+			return lib.NewError(lib.DecodeError, a, "Failed to unmarshal Round2: %v", err)
 		}
 
 		s.GammasDeltasAfterExponentiation[a] = make([]*GammaDeltaStruct, len(s.keys))
 
 		for i := 0; i < len(s.keys); i++ { // len(s.keys) == len(results)
-			s.GammasDeltasAfterExponentiation[a][i] = new(GammaDeltaStruct)
-			s.GammasDeltasAfterExponentiation[a][i].gammas =
-				pb.ByteSliceToBigIntSlice(round2.DoubleGammas[i].Gammas)
-			s.GammasDeltasAfterExponentiation[a][i].deltas =
-				pb.ByteSliceToBigIntSlice(round2.DoubleDeltas[i].Deltas)
+			gd := new(GammaDeltaStruct)
+			if gd.gammas, err = decodeElements(s.group, a, round2.DoubleGammas[i].Gammas); err != nil {
+				return err
+			}
+			if gd.deltas, err = decodeElements(s.group, a, round2.DoubleDeltas[i].Deltas); err != nil {
+				return err
+			}
+			s.GammasDeltasAfterExponentiation[a][i] = gd
 		}
 
-		log.Printf("[Round 2] Receiving ID %v: %v\n", a, s.GammasDeltasAfterExponentiation[a])
+		log.Printf("[Round 2] Receiving ID %v\n", a)
 	}
+
+	return nil
 }
 
-func receiveRound3(FpState interface{}, results []*pb.OuterStruct) {
+func receiveRound3(FpState interface{}, results []*pb.OuterStruct) error {
 	s := getFpState(FpState)
 
 	var round3 Round3
 
 	// Store all received alphas and betas
-	log.Printf("results round 3", len(results))
+	log.Printf("results round 3: %v", len(results))
 	for a := 0; a < len(results); a++ {
-		if a == *id {
+		if a == s.id {
 			continue
 		}
 
@@ -371,23 +525,24 @@ func receiveRound3(FpState interface{}, results []*pb.OuterStruct) {
 
 		err := proto.Unmarshal(results[a].Data, &round3)
 		if err != nil {
-			log.Fatalf("Failed to unmarshal Round3.\n", results[a])
+			return lib.NewError(lib.DecodeError, a, "Failed to unmarshal Round3: %v", err)
 		}
 
-		s.PhisAfterExponentiation[a] = make([][]big.Int, len(s.keys))
+		s.PhisAfterExponentiation[a] = make([][]zkp.Element, len(s.keys))
 
 		for i := 0; i < len(s.keys); i++ { // len(s.keys) == len(results)
-			s.PhisAfterExponentiation[a][i] =
-				pb.ByteSliceToBigIntSlice(round3.DoublePhis[i].Phis)
+			if s.PhisAfterExponentiation[a][i], err = decodeElements(s.group, a, round3.DoublePhis[i].Phis); err != nil {
+				return err
+			}
 		}
 
-		log.Printf("[Round 3] Receiving ID %v: %v\n", a, s.PhisAfterExponentiation[a])
+		log.Printf("[Round 3] Receiving ID %v\n", a)
 	}
 
-	epilogue(s)
+	return epilogue(s)
 }
 
-func sellerReceiveRound3(FpState interface{}, results []*pb.OuterStruct) {
+func sellerReceiveRound3(FpState interface{}, results []*pb.OuterStruct) error {
 	s := getFpState(FpState)
 
 	var round3 Round3
@@ -395,123 +550,177 @@ func sellerReceiveRound3(FpState interface{}, results []*pb.OuterStruct) {
 	// Store all received alphas and betas
 	log.Printf("Results Size: %v", len(results))
 	for a := 0; a < len(results); a++ {
-		if a == *id {
+		if a == s.id {
 			continue
 		}
 		err := proto.Unmarshal(results[a].Data, &round3)
 		if err != nil {
-			log.Fatalf("Seller failed to unmarshal Round3.\n")
+			return lib.NewError(lib.DecodeError, a, "Seller failed to unmarshal Round3: %v", err)
 		}
 
-		s.PhisAfterExponentiation[a] = make([][]big.Int, len(s.keys))
+		s.PhisAfterExponentiation[a] = make([][]zkp.Element, len(s.keys))
 
 		for i := 0; i < len(s.keys); i++ { // len(s.keys) == len(results)
-			s.PhisAfterExponentiation[a][i] =
-				pb.ByteSliceToBigIntSlice(round3.DoublePhis[i].Phis)
+			if s.PhisAfterExponentiation[a][i], err = decodeElements(s.group, a, round3.DoublePhis[i].Phis); err != nil {
+				return err
+			}
 		}
 
-		log.Printf("[Round 3] Receiving ID %v: %v\n", a, s.PhisAfterExponentiation[a])
-		log.Printf("Publishing Clientid", results[a].Clientid)
-		log.Printf("Publishing Stepid", results[a].Stepid)
-		log.Printf("Round 3", results[a].Data)
+		log.Printf("[Round 3] Receiving ID %v\n", a)
+		log.Printf("Publishing Clientid %v, Stepid %v", results[a].Clientid, results[a].Stepid)
 
-		lib.PublishAll(results[a])
+		if err := s.session.PublishAll(results[a]); err != nil {
+			return err
+		}
 	}
 
-	r, _ := proto.Marshal(&s.sellerRound3)
+	r, err := proto.Marshal(&s.sellerRound3)
+	if err != nil {
+		return err
+	}
 
 	out := &pb.OuterStruct{
-		Clientid: int32(*id),
-		Stepid:   4,
+		Clientid: int32(s.id),
+		Stepid:   stepRound3,
 		Data:     r,
 	}
 
-	lib.PublishAll(out)
+	if err := s.session.PublishAll(out); err != nil {
+		return err
+	}
 
-	epilogue(s)
+	return epilogue(s)
 }
 
-func computePrologue(FpState interface{}) (proto.Message, bool) {
+// decodeElements decodes elements received from client.
+func decodeElements(group zkp.Group, client int, encs [][]byte) ([]zkp.Element, error) {
+	elems, err := zkp.DecodeElements(group, encs)
+	if err != nil {
+		return nil, lib.NewError(lib.DecodeError, client, "Failed to decode elements: %v", err)
+	}
+	return elems, nil
+}
+
+func computePrologue(FpState interface{}) (proto.Message, bool, error) {
 	s := getFpState(FpState)
 
-	// Generate private key
-	s.myPrivateKey.Rand(zkp.RandGen, new(big.Int).Sub(zkp.Q, zkp.One))
-	s.myPrivateKey.Add(&s.myPrivateKey, zkp.One)
+	// Generate private key in [1, q)
+	s.myPrivateKey = zkp.RandomExponent(s.group.Order())
 	// Calculate public key
-	s.myPublicKey.Exp(zkp.G, &s.myPrivateKey, zkp.P)
+	s.myPublicKey = s.group.Exp(s.group.Generator(), s.myPrivateKey)
 
 	// Generate zkp of private key
-	t, r := zkp.DiscreteLogKnowledge(s.myPrivateKey, *zkp.G, *zkp.P, *zkp.Q)
+	t, r := zkp.GroupDiscreteLogKnowledge(s.transcript(stepPrologue, s.id), s.group, s.myPrivateKey, s.group.Generator())
+
+	prologue := &Prologue{
+		Key: &pb.Key{
+			Key:   s.group.Encode(s.myPublicKey),
+			Proof: pb.CreateGroupDiscreteLogKnowledge(s.group, t, r),
+		},
+	}
+
+	if s.tieBreak == lib.TieBreakRandom {
+		nonce, err := newTieBreakNonce()
+		if err != nil {
+			return nil, false, err
+		}
+		s.tieBreakNonce = nonce
+		prologue.TieBreakCommitment = s.tieBreakCommitment(s.id, nonce)
+	}
 
-	return &pb.Key{
-		Key:   s.myPublicKey.Bytes(),
-		Proof: pb.CreateDiscreteLogKnowledge(t, r),
-	}, false
+	return prologue, false, nil
 }
 
-func computeRound1(FpState interface{}) (proto.Message, bool) {
+func computeRound1(FpState interface{}) (proto.Message, bool, error) {
 	s := getFpState(FpState)
 	s.AlphasBetas = make([]*AlphaBetaStruct, len(s.keys))
-	s.AlphasBetas[*id] = new(AlphaBetaStruct)
+	s.AlphasBetas[s.id] = new(AlphaBetaStruct)
 
 	log.Printf("Len: %v\n", len(s.keys))
 
-	var alphasInts, betasInts []big.Int
+	var alphas, betas []zkp.Element
 	var proofs []*pb.EqualsOneOfTwo
 	var sumR big.Int
-	sumR.Set(zkp.Zero)
-	var j uint
-	for j = 0; j < K; j++ {
-		var alphaJ, betaJ, rJ, m big.Int
+	tr := s.transcript(stepRound1, s.id)
 
-		rJ.Rand(zkp.RandGen, zkp.Q)
-		sumR.Add(&sumR, &rJ)
+	for j := 0; j < s.numPrices(); j++ {
+		var m zkp.Element
 
-		alphaJ.Exp(&s.publicKey, &rJ, zkp.P)
+		rJ := zkp.RandomExponent(s.group.Order())
+		sumR.Add(&sumR, rJ)
 
-		if j == *bid {
-			m.Set(zkp.Y_Mill)
-			alphaJ.Mul(&alphaJ, zkp.Y_Mill)
-			alphaJ.Mod(&alphaJ, zkp.P)
+		if j == int(s.bid) {
+			m = s.group.SecondGenerator()
 		} else {
-			m.Set(zkp.One)
+			m = s.group.Identity()
 		}
 
-		// calculate beta_j
-		betaJ.Exp(zkp.G, &rJ, zkp.P)
+		// (alpha_j, beta_j) = (m*y^r_j, g^r_j)
+		alphaJ, betaJ := zkp.GroupEncryptElGamal(s.group, m, rJ, s.publicKey)
 
-		alphasInts = append(alphasInts, alphaJ)
-		betasInts = append(betasInts, betaJ)
+		alphas = append(alphas, alphaJ)
+		betas = append(betas, betaJ)
 
 		a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2 :=
-			zkp.EncryptedValueIsOneOfTwo(m, s.publicKey, rJ, *zkp.G,
-				*zkp.Y_Mill, *zkp.P, *zkp.Q)
+			zkp.GroupEncryptedValueIsOneOfTwo(tr.Fork("bid", j), s.group, m, s.publicKey, rJ,
+				s.group.Generator(), s.group.SecondGenerator())
 
-		proofs = append(proofs, pb.CreateIsOneOfTwo(a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2))
+		proofs = append(proofs, pb.CreateGroupIsOneOfTwo(s.group, a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2))
 	}
 
-	log.Printf("Id: %v\n", *id)
-	s.AlphasBetas[*id].alphas = alphasInts
-	s.AlphasBetas[*id].betas = betasInts
+	log.Printf("Id: %v\n", s.id)
+	s.AlphasBetas[s.id].alphas = alphas
+	s.AlphasBetas[s.id].betas = betas
 
-	var pMinusOne big.Int
-	pMinusOne.Sub(zkp.P, zkp.One)
-	sumR.Mod(&sumR, &pMinusOne)
+	sumR.Mod(&sumR, s.group.Order())
 
-	gs := []big.Int{s.publicKey, *zkp.G}
+	gs := []zkp.Element{s.publicKey, s.group.Generator()}
 
-	ts, r := zkp.DiscreteLogEquality(sumR, gs, *zkp.P, *zkp.Q)
+	ts, r := zkp.GroupDiscreteLogEquality(tr.Fork("sum"), s.group, &sumR, gs)
 
 	// create the proto Round1 structure
 	return &Round1{
 		Proofs: proofs,
-		Proof:  pb.CreateDiscreteLogEquality(ts, r),
-		Alphas: pb.BigIntSliceToByteSlice(alphasInts),
-		Betas:  pb.BigIntSliceToByteSlice(betasInts),
-	}, false
+		Proof:  pb.CreateGroupDiscreteLogEquality(s.group, ts, r),
+		Alphas: zkp.EncodeElements(s.group, alphas),
+		Betas:  zkp.EncodeElements(s.group, betas),
+	}, false, nil
+}
+
+// computeGammasDeltas computes the gammas and deltas of round 2 before
+// exponentiation, which are the same for everyone.
+func (s *FpState) computeGammasDeltas() {
+	n := len(s.keys)
+
+	s.GammasDeltasBeforeExponentiation = make([]*GammaDeltaStruct, n)
+	for i := 0; i < n; i++ {
+		s.GammasDeltasBeforeExponentiation[i] = new(GammaDeltaStruct)
+	}
+
+	getNumAlphas := func(x, y int) zkp.Element {
+		return s.AlphasBetas[x].alphas[y]
+	}
+	getNumBetas := func(x, y int) zkp.Element {
+		return s.AlphasBetas[x].betas[y]
+	}
+
+	for j := 0; j < s.numPrices(); j++ {
+		log.Printf("[Round 2] %v-th outer loop\n", j)
+		cachedValGamma := Round2ComputeInitialValue(s.group, n, s.numPrices(), j, getNumAlphas)
+		cachedValDelta := Round2ComputeInitialValue(s.group, n, s.numPrices(), j, getNumBetas)
+		for i := 0; i < n; i++ {
+			gamma := Round2ComputeOutcome(s.group, i, j, s.tieBreakAhead[i], cachedValGamma, getNumAlphas)
+			delta := Round2ComputeOutcome(s.group, i, j, s.tieBreakAhead[i], cachedValDelta, getNumBetas)
+
+			s.GammasDeltasBeforeExponentiation[i].gammas =
+				append(s.GammasDeltasBeforeExponentiation[i].gammas, gamma)
+			s.GammasDeltasBeforeExponentiation[i].deltas =
+				append(s.GammasDeltasBeforeExponentiation[i].deltas, delta)
+		}
+	}
 }
 
-func computeRound2(FpState interface{}) (proto.Message, bool) {
+func computeRound2(FpState interface{}) (proto.Message, bool, error) {
 	s := getFpState(FpState)
 	n := len(s.keys)
 
@@ -519,134 +728,109 @@ func computeRound2(FpState interface{}) (proto.Message, bool) {
 	gammas := make([]*Gammas, n)
 	deltas := make([]*Deltas, n)
 
-	s.GammasDeltasBeforeExponentiation = make([]*GammaDeltaStruct, n)
 	s.GammasDeltasAfterExponentiation = make([][]*GammaDeltaStruct, n)
-	s.GammasDeltasAfterExponentiation[*id] = make([]*GammaDeltaStruct, n)
+	s.GammasDeltasAfterExponentiation[s.id] = make([]*GammaDeltaStruct, n)
 
-	getNumAlphas := func(x, y int) *big.Int {
-		log.Printf("[Round 2] AlphasBetas[%v].alphas[%v] = %v\n", x, y, s.AlphasBetas[x].alphas[y])
-		return &s.AlphasBetas[x].alphas[y]
-	}
-	getNumBetas := func(x, y int) *big.Int {
-		log.Printf("[Round 2] AlphasBetas[%v].betas[%v] = %v\n", x, y, s.AlphasBetas[x].betas[y])
-		return &s.AlphasBetas[x].betas[y]
-	}
+	tr := s.transcript(stepRound2, s.id)
 
 	// calculate all gammas and deltas before exponentiation
 	// these are the same for everyone!
 	// then calculate exponentiated values, one for each i and j.
 	// Every person will send as i*j different exponentiated gammas
 	// and i*j different exponentiated deltas!!!
-	for j := 0; j < int(K); j++ {
-		log.Printf("[Round 2] %v-th outer loop\n", j)
-		cachedValGamma := Round2ComputeInitialValue(n, int(K), j, zkp.P, getNumAlphas)
-		cachedValDelta := Round2ComputeInitialValue(n, int(K), j, zkp.P, getNumBetas)
-		log.Printf("[Round 2] Cached val gamma: %v, Cached val delta: %v", cachedValGamma, cachedValDelta)
+	s.computeGammasDeltas()
+
+	for j := 0; j < s.numPrices(); j++ {
 		for i := 0; i < n; i++ {
 			// initialize if necessary
 			if j == 0 {
-				s.GammasDeltasBeforeExponentiation[i] = new(GammaDeltaStruct)
-				s.GammasDeltasAfterExponentiation[*id][i] = new(GammaDeltaStruct)
+				s.GammasDeltasAfterExponentiation[s.id][i] = new(GammaDeltaStruct)
 				proofs[i] = new(DiscreteLogEqualityProofs)
 				gammas[i] = new(Gammas)
 				deltas[i] = new(Deltas)
 			}
 
-			// compute unexponentiated gammas/deltas
-			log.Printf("[Round 2] %v-th inner loop\n", i)
-			gamma := Round2ComputeOutcome(i, j, zkp.P, &cachedValGamma, getNumAlphas)
-			delta := Round2ComputeOutcome(i, j, zkp.P, &cachedValDelta, getNumBetas)
-			log.Printf("Finished computing non-cached value\n")
-
-			s.GammasDeltasBeforeExponentiation[i].gammas =
-				append(s.GammasDeltasBeforeExponentiation[i].gammas, gamma)
-			s.GammasDeltasBeforeExponentiation[i].deltas =
-				append(s.GammasDeltasBeforeExponentiation[i].deltas, delta)
+			gamma := s.GammasDeltasBeforeExponentiation[i].gammas[j]
+			delta := s.GammasDeltasBeforeExponentiation[i].deltas[j]
 
 			// now exponentiate to find the value we will publish to all!
-			var mIJ big.Int
-			mIJ.Rand(zkp.RandGen, zkp.Q)
+			mIJ := zkp.RandomExponent(s.group.Order())
 
-			var gammaExp, deltaExp big.Int
-			gammaExp.Exp(&gamma, &mIJ, zkp.P)
-			deltaExp.Exp(&delta, &mIJ, zkp.P)
+			gammaExp := s.group.Exp(gamma, mIJ)
+			deltaExp := s.group.Exp(delta, mIJ)
 
 			// add exponentiated value to our exponentiated Gammas/Deltas struct
-			s.GammasDeltasAfterExponentiation[*id][i].gammas =
-				append(s.GammasDeltasAfterExponentiation[*id][i].gammas, gammaExp)
-			s.GammasDeltasAfterExponentiation[*id][i].deltas =
-				append(s.GammasDeltasAfterExponentiation[*id][i].deltas, deltaExp)
+			s.GammasDeltasAfterExponentiation[s.id][i].gammas =
+				append(s.GammasDeltasAfterExponentiation[s.id][i].gammas, gammaExp)
+			s.GammasDeltasAfterExponentiation[s.id][i].deltas =
+				append(s.GammasDeltasAfterExponentiation[s.id][i].deltas, deltaExp)
 
 			// must prove that our exponentiated values have same exponent
-			gs := []big.Int{gamma, delta}
+			gs := []zkp.Element{gamma, delta}
 
 			// now generate proof!
-			ts, r := zkp.DiscreteLogEquality(mIJ, gs, *zkp.P, *zkp.Q)
+			ts, r := zkp.GroupDiscreteLogEquality(tr.Fork("gammadelta", i, j), s.group, mIJ, gs)
 
 			// and add to the list of proofs!
-			proofs[i].Proofs = append(proofs[i].Proofs, pb.CreateDiscreteLogEquality(ts, r))
+			proofs[i].Proofs = append(proofs[i].Proofs, pb.CreateGroupDiscreteLogEquality(s.group, ts, r))
 			// add the number manually
-			gammas[i].Gammas = append(gammas[i].Gammas, gammaExp.Bytes())
-			deltas[i].Deltas = append(deltas[i].Deltas, deltaExp.Bytes())
-
-			log.Printf("Created gammaExp/deltaExp %v/%v with proof values %v, %v, and bases %v",
-				gammaExp, deltaExp, ts, r, gs)
+			gammas[i].Gammas = append(gammas[i].Gammas, s.group.Encode(gammaExp))
+			deltas[i].Deltas = append(deltas[i].Deltas, s.group.Encode(deltaExp))
 		}
 	}
 
-	log.Printf("[Round 2] Sending ID %v: %v\n", *id, s.GammasDeltasAfterExponentiation[*id])
+	log.Printf("[Round 2] Sending ID %v\n", s.id)
 
 	return &Round2{
 		DoubleProofs: proofs,
 		DoubleGammas: gammas,
 		DoubleDeltas: deltas,
-	}, false
+	}, false, nil
 }
 
-func computeRound3(FpState interface{}) (proto.Message, bool) {
+func computeRound3(FpState interface{}) (proto.Message, bool, error) {
 	s := getFpState(FpState)
 	n := len(s.keys)
 
 	var doublePhis []*Phis
 	var proofs []*DiscreteLogEqualityProofs
 
-	s.PhisAfterExponentiation = make([][][]big.Int, n)
+	s.PhisAfterExponentiation = make([][][]zkp.Element, n)
+
+	tr := s.transcript(stepRound3, s.id)
 
 	for i := 0; i < n; i++ {
 		s.PhisBeforeExponentiation =
 			append(s.PhisBeforeExponentiation, nil)
-		s.PhisAfterExponentiation[*id] =
-			append(s.PhisAfterExponentiation[*id], nil)
+		s.PhisAfterExponentiation[s.id] =
+			append(s.PhisAfterExponentiation[s.id], nil)
 
 		proofs = append(proofs, &DiscreteLogEqualityProofs{})
 
-		for j := 0; j < int(K); j++ {
-			phi := Multiply(0, n, zkp.P, func(h int) *big.Int {
-				return &s.GammasDeltasAfterExponentiation[h][i].deltas[j]
-			})
+		for j := 0; j < s.numPrices(); j++ {
+			phi := s.phi(i, j)
 
-			var phiExp big.Int
-			phiExp.Exp(phi, &s.myPrivateKey, zkp.P)
+			phiExp := s.group.Exp(phi, s.myPrivateKey)
 
 			s.PhisBeforeExponentiation[i] =
-				append(s.PhisBeforeExponentiation[i], *phi)
+				append(s.PhisBeforeExponentiation[i], phi)
 
-			s.PhisAfterExponentiation[*id][i] =
-				append(s.PhisAfterExponentiation[*id][i], phiExp)
+			s.PhisAfterExponentiation[s.id][i] =
+				append(s.PhisAfterExponentiation[s.id][i], phiExp)
 
 			// must prove that our exponentiated phi has same exponent as our public key portion
-			gs := []big.Int{*phi, *zkp.G}
+			gs := []zkp.Element{phi, s.group.Generator()}
 
 			// now generate proof!
-			ts, r := zkp.DiscreteLogEquality(s.myPrivateKey, gs, *zkp.P, *zkp.Q)
+			ts, r := zkp.GroupDiscreteLogEquality(tr.Fork("phi", i, j), s.group, s.myPrivateKey, gs)
 
-			proofs[i].Proofs = append(proofs[i].Proofs, pb.CreateDiscreteLogEquality(ts, r))
+			proofs[i].Proofs = append(proofs[i].Proofs, pb.CreateGroupDiscreteLogEquality(s.group, ts, r))
 		}
 
-		log.Printf("Round 3: %v %v\n", i, len(s.PhisAfterExponentiation[*id][i]))
+		log.Printf("Round 3: %v %v\n", i, len(s.PhisAfterExponentiation[s.id][i]))
 
 		doublePhis = append(doublePhis, &Phis{
-			Phis: pb.BigIntSliceToByteSlice(s.PhisAfterExponentiation[*id][i]),
+			Phis: zkp.EncodeElements(s.group, s.PhisAfterExponentiation[s.id][i]),
 		})
 	}
 
@@ -655,44 +839,55 @@ func computeRound3(FpState interface{}) (proto.Message, bool) {
 		DoublePhis:   doublePhis,
 		DoubleProofs: proofs,
 	}
-	if *id == 0 {
+	if s.id == 0 {
 		s.sellerRound3 = round3
 	}
-	return &round3, true
+	return &round3, true, nil
+}
+
+// phi returns the product of the exponentiated deltas of bidder i and
+// price j of all parties, before every party exponentiates it with its
+// private key in round 3.
+func (s *FpState) phi(i, j int) zkp.Element {
+	return Multiply(s.group, 0, len(s.keys), func(h int) zkp.Element {
+		return s.GammasDeltasAfterExponentiation[h][i].deltas[j]
+	})
 }
 
-func epilogue(s *FpState) {
+// epilogue finds the winner and the selling price, and stores them in s.
+// It fails unless the outcome of exactly one bidder decrypts to the
+// identity, at exactly one price.
+func epilogue(s *FpState) error {
 	n := len(s.keys)
+	var winners, prices []int
 	for a := 0; a < n; a++ {
-		for j := 0; j < int(K); j++ {
-			numerator := Multiply(0, n, zkp.P, func(i int) *big.Int {
-				return &s.GammasDeltasAfterExponentiation[i][a].gammas[j]
+		for j := 0; j < s.numPrices(); j++ {
+			numerator := Multiply(s.group, 0, n, func(i int) zkp.Element {
+				return s.GammasDeltasAfterExponentiation[i][a].gammas[j]
 			})
 
-			denominator := Multiply(0, n, zkp.P, func(i int) *big.Int {
-				return &s.PhisAfterExponentiation[i][a][j]
+			denominator := Multiply(s.group, 0, n, func(i int) zkp.Element {
+				return s.PhisAfterExponentiation[i][a][j]
 			})
 
-			denominator.ModInverse(denominator, zkp.P)
-
-			var vAJ big.Int
-			vAJ.Mul(numerator, denominator)
-			vAJ.Mod(&vAJ, zkp.P)
+			vAJ := zkp.Divide(s.group, numerator, denominator)
 
-			if vAJ.Cmp(zkp.One) == 0 {
-				if a == *id {
-					log.Printf("I won at selling price %v!", j)
-				} else {
-					log.Printf("I did not win. ID %v won at selling price %v.", a, j)
-				}
+			if s.group.Equal(vAJ, s.group.Identity()) {
+				winners = append(winners, a)
+				prices = append(prices, j)
 			}
 		}
 	}
 
-	lib.DisplayData()
-	for true {
-
+	if len(winners) != 1 {
+		return fmt.Errorf("the outcomes name %v winners %v at prices %v, not one", len(winners), winners, prices)
 	}
 
-	log.Fatalf("Done")
+	s.winner, s.price = winners[0], uint(prices[0])
+	if s.winner == s.id {
+		log.Printf("I won at selling price %v!", s.prices.Price(prices[0]))
+	} else {
+		log.Printf("I did not win. ID %v won at selling price %v.", s.hostID(s.winner), s.prices.Price(prices[0]))
+	}
+	return nil
 }
@@ -16,6 +16,8 @@ It has these top-level messages:
 	DiscreteLogEqualityProofs
 	Round3
 	Phis
+	Prologue
+	TieBreak
 */
 package main
 
@@ -160,6 +162,34 @@ func (m *Phis) String() string            { return proto.CompactTextString(m) }
 func (*Phis) ProtoMessage()               {}
 func (*Phis) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
 
+type Prologue struct {
+	Key *common_pb.Key `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	// Commitment to the tie-breaking nonce, if ties are broken randomly
+	TieBreakCommitment []byte `protobuf:"bytes,2,opt,name=tieBreakCommitment,proto3" json:"tieBreakCommitment,omitempty"`
+}
+
+func (m *Prologue) Reset()                    { *m = Prologue{} }
+func (m *Prologue) String() string            { return proto.CompactTextString(m) }
+func (*Prologue) ProtoMessage()               {}
+func (*Prologue) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *Prologue) GetKey() *common_pb.Key {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+// Opens the tie-breaking commitment of the prologue, once all bids are in
+type TieBreak struct {
+	Nonce []byte `protobuf:"bytes,1,opt,name=nonce,proto3" json:"nonce,omitempty"`
+}
+
+func (m *TieBreak) Reset()                    { *m = TieBreak{} }
+func (m *TieBreak) String() string            { return proto.CompactTextString(m) }
+func (*TieBreak) ProtoMessage()               {}
+func (*TieBreak) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
 func init() {
 	proto.RegisterType((*Round1)(nil), "main.Round1")
 	proto.RegisterType((*Round2)(nil), "main.Round2")
@@ -168,6 +198,8 @@ func init() {
 	proto.RegisterType((*DiscreteLogEqualityProofs)(nil), "main.DiscreteLogEqualityProofs")
 	proto.RegisterType((*Round3)(nil), "main.Round3")
 	proto.RegisterType((*Phis)(nil), "main.Phis")
+	proto.RegisterType((*Prologue)(nil), "main.Prologue")
+	proto.RegisterType((*TieBreak)(nil), "main.TieBreak")
 }
 
 func init() {
@@ -175,28 +207,33 @@ func init() {
 }
 
 var fileDescriptor0 = []byte{
-	// 360 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x9c, 0x52, 0x31, 0x6f, 0xf2, 0x30,
-	0x10, 0x15, 0x10, 0x3c, 0x1c, 0x99, 0xac, 0x4f, 0x28, 0x30, 0x7c, 0x8d, 0x32, 0xa1, 0x0e, 0xa1,
-	0x84, 0xaa, 0x43, 0xd7, 0x52, 0x75, 0xa9, 0x44, 0xe5, 0x76, 0x47, 0x4e, 0x30, 0xc4, 0x52, 0x12,
-	0xa7, 0xb1, 0x23, 0xc4, 0xaf, 0xe9, 0x6f, 0xe8, 0x3f, 0xac, 0xb0, 0x1d, 0x08, 0x42, 0x6d, 0xa5,
-	0x6e, 0x77, 0xe7, 0xf7, 0xee, 0xde, 0x7b, 0x32, 0xdc, 0x6f, 0xb9, 0x4a, 0xeb, 0x38, 0x4c, 0x44,
-	0x3e, 0xa5, 0x32, 0xdd, 0xf1, 0x42, 0x56, 0x53, 0x5a, 0x27, 0x8a, 0x8b, 0x42, 0x4e, 0x37, 0xbc,
-	0x92, 0x6a, 0x55, 0x56, 0x3c, 0x61, 0xed, 0x3a, 0x2c, 0x2b, 0xa1, 0x04, 0x76, 0x72, 0xca, 0x8b,
-	0xf1, 0xfc, 0xc7, 0x0d, 0x89, 0xc8, 0x73, 0x51, 0xac, 0xca, 0xd8, 0x56, 0x86, 0x1a, 0x7c, 0x74,
-	0x00, 0x11, 0x51, 0x17, 0xeb, 0x19, 0x1e, 0x02, 0xa2, 0x59, 0x99, 0x52, 0xe9, 0x75, 0xfc, 0xde,
-	0xc4, 0x25, 0xb6, 0xc3, 0xff, 0xa0, 0x1f, 0x33, 0x45, 0xa5, 0xd7, 0xd5, 0x63, 0xd3, 0xe0, 0x19,
-	0xa0, 0xb2, 0x12, 0x62, 0x23, 0xbd, 0x9e, 0xdf, 0x9b, 0x0c, 0xa2, 0x51, 0x78, 0xbc, 0x10, 0x3e,
-	0xbe, 0xd7, 0x34, 0x93, 0xcb, 0x82, 0x2d, 0x37, 0x6f, 0x3b, 0x41, 0x2c, 0x10, 0xdf, 0x42, 0x5f,
-	0x57, 0x9e, 0xe3, 0x77, 0x26, 0x83, 0xe8, 0x7f, 0x8b, 0xb1, 0xe0, 0x32, 0xa9, 0x98, 0x62, 0xcf,
-	0x62, 0xab, 0xc9, 0x5c, 0xed, 0x89, 0x01, 0x07, 0x9f, 0x8d, 0xc2, 0x08, 0xdf, 0x80, 0xbb, 0x16,
-	0x75, 0x9c, 0xb1, 0x27, 0x9a, 0xe7, 0x56, 0xe7, 0x20, 0x72, 0xc3, 0x83, 0xfd, 0xd0, 0xcc, 0xc8,
-	0x19, 0xe2, 0xc4, 0x58, 0xb0, 0xac, 0xb1, 0x70, 0x64, 0x98, 0x19, 0x39, 0x43, 0xe0, 0x87, 0x86,
-	0xf1, 0xd2, 0x76, 0x77, 0x65, 0x19, 0x97, 0x32, 0x0d, 0x8c, 0x9c, 0x91, 0x02, 0x1f, 0x90, 0x15,
-	0x30, 0x04, 0xb4, 0x3d, 0x89, 0x75, 0x89, 0xed, 0x0e, 0x08, 0x7b, 0x70, 0x08, 0x68, 0x6d, 0xc4,
-	0x59, 0x84, 0xe9, 0x82, 0x57, 0x18, 0x7d, 0x7b, 0x0e, 0xdf, 0x1d, 0xd3, 0x37, 0x19, 0xfc, 0x96,
-	0xa5, 0x45, 0x07, 0x7b, 0x9b, 0xe5, 0x1c, 0x5f, 0x03, 0x58, 0xc9, 0x29, 0x6f, 0xb6, 0x80, 0x71,
-	0x79, 0x98, 0x90, 0xd6, 0xeb, 0x45, 0x26, 0xdd, 0xbf, 0x64, 0x32, 0x06, 0x47, 0x2f, 0xc3, 0xe0,
-	0x94, 0xcd, 0x49, 0x97, 0xe8, 0x3a, 0x46, 0xfa, 0x33, 0xce, 0xbf, 0x02, 0x00, 0x00, 0xff, 0xff,
-	0x48, 0x1a, 0xe5, 0x77, 0x05, 0x03, 0x00, 0x00,
+	// 442 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x53, 0x4d, 0x6b, 0xdb, 0x40,
+	0x10, 0x45, 0xb6, 0x2c, 0xcc, 0x58, 0x2d, 0x65, 0x29, 0x46, 0xc9, 0xa1, 0x15, 0x3a, 0x99, 0x1e,
+	0xe4, 0xc6, 0x2e, 0x3d, 0xf4, 0xd8, 0xa4, 0x14, 0xda, 0xd2, 0x84, 0x6d, 0x8e, 0x85, 0xb0, 0x92,
+	0xd7, 0xf6, 0x12, 0xed, 0x8e, 0xaa, 0x5d, 0x11, 0xf4, 0xb3, 0x0a, 0xfd, 0x81, 0x45, 0xda, 0x95,
+	0x23, 0x93, 0x7e, 0x40, 0x6e, 0x33, 0xb3, 0xef, 0xcd, 0xbc, 0x79, 0x1a, 0xc1, 0xbb, 0x9d, 0x30,
+	0xfb, 0x3a, 0x4b, 0x73, 0x94, 0x4b, 0xa6, 0xf7, 0x77, 0x42, 0xe9, 0x6a, 0xc9, 0xea, 0xdc, 0x08,
+	0x54, 0x7a, 0xb9, 0x15, 0x95, 0x36, 0x37, 0x65, 0x25, 0x72, 0x3e, 0x8c, 0xd3, 0xb2, 0x42, 0x83,
+	0xc4, 0x97, 0x4c, 0xa8, 0xd3, 0xf5, 0x3f, 0x3b, 0xe4, 0x28, 0x25, 0xaa, 0x9b, 0x32, 0x73, 0x91,
+	0xa5, 0x26, 0xbf, 0x3c, 0x08, 0x28, 0xd6, 0x6a, 0x73, 0x46, 0xe6, 0x10, 0xb0, 0xa2, 0xdc, 0x33,
+	0x1d, 0x79, 0xf1, 0x78, 0x11, 0x52, 0x97, 0x91, 0xe7, 0x30, 0xc9, 0xb8, 0x61, 0x3a, 0x1a, 0x75,
+	0x65, 0x9b, 0x90, 0x33, 0x08, 0xca, 0x0a, 0x71, 0xab, 0xa3, 0x71, 0x3c, 0x5e, 0xcc, 0x56, 0x27,
+	0xe9, 0x61, 0x42, 0xfa, 0xe1, 0x47, 0xcd, 0x0a, 0x7d, 0xa9, 0xf8, 0xe5, 0xf6, 0xfa, 0x0e, 0xa9,
+	0x03, 0x92, 0x37, 0x30, 0xe9, 0xa2, 0xc8, 0x8f, 0xbd, 0xc5, 0x6c, 0xf5, 0x62, 0xc0, 0xb8, 0x10,
+	0x3a, 0xaf, 0xb8, 0xe1, 0x5f, 0x70, 0xd7, 0x91, 0x85, 0x69, 0xa8, 0x05, 0x7f, 0xf2, 0xa7, 0x93,
+	0x67, 0x01, 0x7d, 0x62, 0x04, 0x7f, 0x5f, 0x71, 0x76, 0xfb, 0x15, 0x55, 0xce, 0x93, 0x9f, 0xbd,
+	0xec, 0x15, 0x79, 0x0d, 0xe1, 0x06, 0xeb, 0xac, 0xe0, 0x1f, 0x99, 0x94, 0x4e, 0xfc, 0x6c, 0x15,
+	0xa6, 0xad, 0x27, 0xa9, 0xad, 0xd1, 0x23, 0xc4, 0x3d, 0xe3, 0x82, 0x17, 0xfd, 0x5e, 0x07, 0x86,
+	0xad, 0xd1, 0x23, 0x04, 0x39, 0xef, 0x19, 0x57, 0xc3, 0x95, 0x5f, 0x3a, 0xc6, 0x43, 0xed, 0x16,
+	0x46, 0x8f, 0x48, 0x49, 0x0c, 0x81, 0x13, 0x30, 0x87, 0x60, 0x77, 0x2f, 0x36, 0xa4, 0x2e, 0x6b,
+	0x11, 0x6e, 0xe0, 0x1c, 0x82, 0x8d, 0x15, 0xe7, 0x10, 0x36, 0x4b, 0xbe, 0xc1, 0xc9, 0x5f, 0xc7,
+	0x91, 0xb7, 0x87, 0x4f, 0x62, 0x3d, 0xf8, 0x9f, 0xc1, 0x0e, 0x9d, 0x34, 0xce, 0xcb, 0x35, 0x79,
+	0x05, 0xe0, 0x24, 0xef, 0x45, 0xdf, 0x05, 0xec, 0x96, 0x6d, 0x85, 0x0e, 0x5e, 0x1f, 0x78, 0x32,
+	0x7a, 0x8c, 0x27, 0xa7, 0xe0, 0x77, 0xcd, 0x08, 0xf8, 0x65, 0x3f, 0x32, 0xa4, 0x5d, 0x9c, 0x7c,
+	0x87, 0xe9, 0x55, 0x85, 0x05, 0xee, 0x6a, 0x4e, 0x62, 0x18, 0xdf, 0xf2, 0x26, 0xf2, 0xba, 0xc3,
+	0x79, 0x3a, 0xd8, 0xeb, 0x33, 0x6f, 0x68, 0xfb, 0x44, 0x52, 0x20, 0xfd, 0x89, 0x9c, 0xa3, 0x94,
+	0xc2, 0x48, 0xae, 0x4c, 0x34, 0x8a, 0xbd, 0x45, 0x48, 0xff, 0xf0, 0x92, 0xc4, 0x30, 0xbd, 0x76,
+	0xd5, 0xf6, 0xc2, 0x55, 0x7b, 0x56, 0x5d, 0xff, 0x90, 0xda, 0x24, 0x0b, 0xba, 0x3f, 0x64, 0xfd,
+	0x7b, 0x00, 0x15, 0xa3, 0x3c, 0xf4, 0x9a, 0x03, 0x00, 0x00,
 }
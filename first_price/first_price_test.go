@@ -0,0 +1,641 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+	"github.com/ashwinsr/auctions/lib"
+	"github.com/ashwinsr/auctions/zkp"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// The bid domain of the tests, which keeps the auctions fast
+const testK = 8
+
+// tamperFn changes the message a cheating party computed for its round.
+type tamperFn func(group zkp.Group, msg proto.Message)
+
+// runAuction runs a first price auction among len(bids) parties over a
+// memory network, with echoes if echo is set, in which cheater, unless it is
+// lib.NoClient, tampers with its message of the given step. It returns the
+// state and the error of every party.
+func runAuction(test *testing.T, tieBreak lib.TieBreak, echo bool, bids []uint, cheater int, step int, tamper tamperFn) ([]*FpState, []error) {
+	return runRecordedAuction(test, tieBreak, echo, bids, cheater, step, tamper, nil)
+}
+
+// runRecordedAuction is runAuction, in which every party records its
+// transcript to transcripts, unless it is nil.
+func runRecordedAuction(test *testing.T, tieBreak lib.TieBreak, echo bool, bids []uint, cheater int, step int, tamper tamperFn, transcripts []io.Writer) ([]*FpState, []error) {
+	prices, err := lib.MaxPriceLadder(testK - 1)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	group, err := zkp.NamedGroup(zkp.ToyGroupName)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	n := len(bids)
+	hosts := make([]string, n)
+	network := lib.NewMemoryNetwork(n)
+	states := make([]*FpState, n)
+	errs := make([]error, n)
+
+	// Once a party fails, the others may wait forever for its messages
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		config := &lib.AuctionConfig{Hosts: hosts, MyID: i, Group: group, AuctionID: "test", TieBreak: tieBreak, Prices: prices, Echo: echo}
+		transport := network.Transport(i)
+		if transcripts != nil {
+			transport = lib.RecordTranscript(config, transport, transcripts[i])
+		}
+		session := lib.NewSession(config, transport)
+		states[i] = newFpState(session, config, bids[i])
+
+		rounds := fpRounds(config)
+		if i == cheater {
+			rounds = tamperedRounds(group, rounds, step, tamper)
+		}
+
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if errs[i] = session.Connect(ctx); errs[i] == nil {
+				errs[i] = session.Run(ctx, rounds, states[i])
+			}
+			if errs[i] != nil {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return states, errs
+}
+
+// tamperedRounds returns rounds, with tamper applied to the message computed
+// in step.
+func tamperedRounds(group zkp.Group, rounds []lib.Round, step int, tamper tamperFn) []lib.Round {
+	tampered := append([]lib.Round(nil), rounds...)
+	compute := rounds[step-1].Compute
+	tampered[step-1].Compute = func(state interface{}) (proto.Message, bool, error) {
+		msg, sendToSeller, err := compute(state)
+		if err == nil {
+			tamper(group, msg)
+		}
+		return msg, sendToSeller, err
+	}
+	return tampered
+}
+
+// tamperElement returns the encoding of the element enc times the generator.
+func tamperElement(group zkp.Group, enc []byte) []byte {
+	elem, err := group.Decode(enc)
+	if err != nil {
+		panic(err)
+	}
+	return group.Encode(group.Mul(elem, group.Generator()))
+}
+
+func TestFirstPrice(test *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		bids   []uint
+		winner int
+		price  uint
+	}{
+		{"two parties", []uint{0, 3}, 1, 3},
+		{"seller bids highest", []uint{5, 2, 4}, 0, 5},
+		{"lowest and highest bids", []uint{0, testK - 1, 0}, 1, testK - 1},
+		{"all bid zero", []uint{0, 0, 0}, 0, 0},
+		{"tie", []uint{2, 6, 6, 1}, 1, 6},
+		{"tie below the highest bid", []uint{2, 2, 5, 1}, 2, 5},
+		{"five parties", []uint{4, 1, 7, 3, 2}, 2, 7},
+		{"ten parties", []uint{3, 1, 4, 1, 5, 0, 2, 6, 5, 6}, 7, 6},
+	} {
+		states, errs := runAuction(test, lib.TieBreakLowestID, false, tc.bids, lib.NoClient, 0, nil)
+
+		for i, s := range states {
+			if errs[i] != nil {
+				test.Errorf("%v: party %v failed: %v", tc.name, i, errs[i])
+				continue
+			}
+			if s.winner != tc.winner || s.price != tc.price {
+				test.Errorf("%v: party %v found winner %v at price %v, want %v at price %v",
+					tc.name, i, s.winner, s.price, tc.winner, tc.price)
+			}
+
+			// The seller forwards the messages of round 3, so everyone
+			// has seen the same messages
+			result := s.Result()
+			if !reflect.DeepEqual(result.Winners, []int{tc.winner}) || result.Price != fmt.Sprint(tc.price) {
+				test.Errorf("%v: party %v has result %+v, want winner %v at price %v",
+					tc.name, i, result, tc.winner, tc.price)
+			}
+			if want := states[0].Result().TranscriptHash; result.TranscriptHash != want {
+				test.Errorf("%v: party %v got transcript hash %v, the seller got %v",
+					tc.name, i, result.TranscriptHash, want)
+			}
+		}
+	}
+}
+
+func TestEpilogueNeedsOneWinner(test *testing.T) {
+	states, errs := runAuction(test, lib.TieBreakLowestID, false, []uint{4, 1, 7}, lib.NoClient, 0, nil)
+
+	for i, s := range states {
+		if errs[i] != nil {
+			test.Fatalf("Party %v failed: %v", i, errs[i])
+		}
+
+		// Give party 0 the outcomes of the winner, party 2, so that two
+		// bidders win
+		for h := range s.keys {
+			s.GammasDeltasAfterExponentiation[h][0] = s.GammasDeltasAfterExponentiation[h][2]
+			s.PhisAfterExponentiation[h][0] = s.PhisAfterExponentiation[h][2]
+		}
+		if err := epilogue(s); err == nil {
+			test.Errorf("Party %v found a single winner %v among two", i, s.winner)
+		}
+
+		// And then none
+		for h := range s.keys {
+			s.GammasDeltasAfterExponentiation[h][0] = s.GammasDeltasAfterExponentiation[h][1]
+			s.PhisAfterExponentiation[h][0] = s.PhisAfterExponentiation[h][1]
+			s.GammasDeltasAfterExponentiation[h][2] = s.GammasDeltasAfterExponentiation[h][1]
+			s.PhisAfterExponentiation[h][2] = s.PhisAfterExponentiation[h][1]
+		}
+		if err := epilogue(s); err == nil {
+			test.Errorf("Party %v found winner %v among none", i, s.winner)
+		}
+	}
+}
+
+func TestFirstPriceEcho(test *testing.T) {
+	// The echoes of round 3 include the messages the seller forwards
+	states, errs := runAuction(test, lib.TieBreakLowestID, true, []uint{4, 1, 7, 3}, lib.NoClient, 0, nil)
+
+	for i, s := range states {
+		if errs[i] != nil {
+			test.Errorf("Party %v failed: %v", i, errs[i])
+			continue
+		}
+		if s.winner != 2 || s.price != 7 {
+			test.Errorf("Party %v found winner %v at price %v, want 2 at price 7", i, s.winner, s.price)
+		}
+	}
+}
+
+func TestFirstPriceRandomTieBreak(test *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		bids  []uint
+		tied  []int
+		price uint
+	}{
+		{"no tie", []uint{4, 1, 7, 3, 2}, []int{2}, 7},
+		{"two tied", []uint{2, 6, 6, 1}, []int{1, 2}, 6},
+		{"all tied", []uint{3, 3, 3, 3, 3}, []int{0, 1, 2, 3, 4}, 3},
+	} {
+		states, errs := runAuction(test, lib.TieBreakRandom, false, tc.bids, lib.NoClient, 0, nil)
+
+		for i, s := range states {
+			if errs[i] != nil {
+				test.Errorf("%v: party %v failed: %v", tc.name, i, errs[i])
+				continue
+			}
+			if s.winner != states[0].winner {
+				test.Errorf("%v: party %v found winner %v, but party 0 found %v", tc.name, i, s.winner, states[0].winner)
+			}
+			tied := false
+			for _, t := range tc.tied {
+				tied = tied || s.winner == t
+			}
+			if !tied || s.price != tc.price {
+				test.Errorf("%v: party %v found winner %v at price %v, want one of %v at price %v",
+					tc.name, i, s.winner, s.price, tc.tied, tc.price)
+			}
+		}
+	}
+}
+
+func TestFirstPriceVerify(test *testing.T) {
+	bids := []uint{4, 1, 7, 7}
+	n := len(bids)
+
+	dir := test.TempDir()
+	names := make([]string, n)
+	transcripts := make([]io.Writer, n)
+	for i := range transcripts {
+		names[i] = filepath.Join(dir, fmt.Sprintf("party%v.transcript", i))
+		f, err := os.Create(names[i])
+		if err != nil {
+			test.Fatal(err)
+		}
+		defer f.Close()
+		transcripts[i] = f
+	}
+
+	states, errs := runRecordedAuction(test, lib.TieBreakRandom, false, bids, lib.NoClient, 0, nil, transcripts)
+
+	prices, err := lib.MaxPriceLadder(testK - 1)
+	if err != nil {
+		test.Fatal(err)
+	}
+	group, err := zkp.NamedGroup(zkp.ToyGroupName)
+	if err != nil {
+		test.Fatal(err)
+	}
+	config := &lib.AuctionConfig{Hosts: make([]string, n), Group: group, AuctionID: "test", TieBreak: lib.TieBreakRandom, Prices: prices}
+
+	for i, s := range states {
+		if errs[i] != nil {
+			test.Fatalf("Party %v failed: %v", i, errs[i])
+		}
+
+		// The seller forwards the messages of round 3, so every transcript
+		// has all messages
+		want := s.Result()
+		result, err := lib.Verify(config, names[i], verify)
+		if err != nil {
+			test.Errorf("Transcript of party %v does not verify: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(result.Winners, want.Winners) || result.Price != want.Price ||
+			result.TranscriptHash != want.TranscriptHash {
+			test.Errorf("Transcript of party %v gives %+v, want %+v", i, result, want)
+		}
+	}
+}
+
+func TestFirstPriceResume(test *testing.T) {
+	bids := []uint{4, 1, 7, 7}
+	n := len(bids)
+
+	prices, err := lib.MaxPriceLadder(testK - 1)
+	if err != nil {
+		test.Fatal(err)
+	}
+	group, err := zkp.NamedGroup(zkp.ToyGroupName)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		crasher int
+		step    int
+	}{
+		{"bidder after the prologue", 2, stepPrologue},
+		{"bidder after round 2", 1, stepRound2},
+		{"seller in round 3", 0, stepRound3},
+	} {
+		dir := test.TempDir()
+		network := lib.NewMemoryNetwork(n)
+		states := make([]*FpState, n)
+		errs := make([]error, n)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+
+		// run runs party i, resuming from its checkpoint if resume is set
+		run := func(i int, resume bool, crash bool) error {
+			config := &lib.AuctionConfig{Hosts: make([]string, n), MyID: i, Group: group, AuctionID: "test",
+				TieBreak: lib.TieBreakRandom, Prices: prices,
+				CheckpointFile: filepath.Join(dir, fmt.Sprintf("party%v.checkpoint", i)), Resume: resume}
+			session := lib.NewSession(config, network.Transport(i))
+			states[i] = newFpState(session, config, bids[i])
+
+			// The crasher dies once it has everyone's messages of the step
+			rounds := fpRounds(config)
+			if crash {
+				rounds[tc.step-1].Receive = func(interface{}, []*pb.OuterStruct) error {
+					return fmt.Errorf("crashed")
+				}
+			}
+
+			if err := session.Connect(ctx); err != nil {
+				return err
+			}
+			defer session.Close()
+			return session.Run(ctx, rounds, states[i])
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			i := i
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if i == tc.crasher {
+					if err := run(i, false, true); err == nil {
+						errs[i] = fmt.Errorf("did not crash")
+						return
+					}
+					network.Disconnect(i)
+				}
+				errs[i] = run(i, i == tc.crasher, false)
+			}()
+		}
+		wg.Wait()
+		cancel()
+
+		for i, s := range states {
+			if errs[i] != nil {
+				test.Errorf("%v: party %v failed: %v", tc.name, i, errs[i])
+				continue
+			}
+			if s.winner != states[0].winner || (s.winner != 2 && s.winner != 3) || s.price != 7 {
+				test.Errorf("%v: party %v found winner %v at price %v, want the winner of the seller, 2 or 3, at price 7",
+					tc.name, i, s.winner, s.price)
+			}
+			if want := states[0].Result().TranscriptHash; s.Result().TranscriptHash != want {
+				test.Errorf("%v: party %v got transcript hash %v, the seller got %v",
+					tc.name, i, s.Result().TranscriptHash, want)
+			}
+		}
+	}
+}
+
+func TestFirstPriceDetectsCheating(test *testing.T) {
+	const cheater = 1
+
+	for _, tc := range []struct {
+		name   string
+		step   int
+		tamper tamperFn
+		kind   lib.ErrorKind
+	}{
+		{"prologue key without its secret", stepPrologue, func(group zkp.Group, msg proto.Message) {
+			key := msg.(*Prologue).Key
+			key.Key = tamperElement(group, key.Key)
+		}, lib.ProofError},
+		{"prologue missing key", stepPrologue, func(group zkp.Group, msg proto.Message) {
+			msg.(*Prologue).Key = nil
+		}, lib.DecodeError},
+		{"round 1 missing bids", stepRound1, func(group zkp.Group, msg proto.Message) {
+			in := msg.(*Round1)
+			in.Alphas = in.Alphas[1:]
+		}, lib.DecodeError},
+		{"round 1 invalid bid", stepRound1, func(group zkp.Group, msg proto.Message) {
+			in := msg.(*Round1)
+			in.Alphas[0] = tamperElement(group, in.Alphas[0])
+		}, lib.ProofError},
+		{"round 1 missing proof", stepRound1, func(group zkp.Group, msg proto.Message) {
+			msg.(*Round1).Proof = nil
+		}, lib.DecodeError},
+		{"round 2 missing gammas", stepRound2, func(group zkp.Group, msg proto.Message) {
+			in := msg.(*Round2)
+			in.DoubleGammas = in.DoubleGammas[1:]
+		}, lib.DecodeError},
+		{"round 2 wrong exponent", stepRound2, func(group zkp.Group, msg proto.Message) {
+			in := msg.(*Round2)
+			in.DoubleGammas[0].Gammas[0] = tamperElement(group, in.DoubleGammas[0].Gammas[0])
+		}, lib.ProofError},
+		{"round 3 missing phis", stepRound3, func(group zkp.Group, msg proto.Message) {
+			in := msg.(*Round3)
+			in.DoublePhis[0].Phis = in.DoublePhis[0].Phis[1:]
+		}, lib.DecodeError},
+		{"round 3 wrong key", stepRound3, func(group zkp.Group, msg proto.Message) {
+			in := msg.(*Round3)
+			in.DoublePhis[2].Phis[3] = tamperElement(group, in.DoublePhis[2].Phis[3])
+		}, lib.ProofError},
+	} {
+		_, errs := runAuction(test, lib.TieBreakLowestID, false, []uint{2, 5, 3}, cheater, tc.step, tc.tamper)
+		checkCheaterDetected(test, tc.name, errs, cheater, tc.step, tc.kind)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		step   int
+		tamper tamperFn
+		kind   lib.ErrorKind
+	}{
+		{"prologue missing commitment", stepPrologue, func(group zkp.Group, msg proto.Message) {
+			msg.(*Prologue).TieBreakCommitment = nil
+		}, lib.DecodeError},
+		{"tie-break other nonce", stepTieBreak, func(group zkp.Group, msg proto.Message) {
+			in := msg.(*TieBreak)
+			in.Nonce = append([]byte(nil), in.Nonce...)
+			in.Nonce[0] ^= 1
+		}, lib.ProofError},
+		{"tie-break missing nonce", stepTieBreak, func(group zkp.Group, msg proto.Message) {
+			msg.(*TieBreak).Nonce = nil
+		}, lib.DecodeError},
+	} {
+		_, errs := runAuction(test, lib.TieBreakRandom, false, []uint{2, 5, 5}, cheater, tc.step, tc.tamper)
+		checkCheaterDetected(test, tc.name, errs, cheater, tc.step, tc.kind)
+	}
+}
+
+// runWithCheater runs an auction of the given bids where party cheater
+// tampers with its first round, in which every party keeps its evidence and
+// transcript in a folder of dir named after its id. It returns the hosts,
+// and the states and errors of the parties.
+func runWithCheater(test *testing.T, cheater int, bids []uint, dir string) ([]string, []interface{}, []error) {
+	n := len(bids)
+
+	prices, err := lib.MaxPriceLadder(testK - 1)
+	if err != nil {
+		test.Fatal(err)
+	}
+	group, err := zkp.NamedGroup(zkp.ToyGroupName)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	// Every run gets a network of its own
+	var networksLock sync.Mutex
+	networks := make(map[int]*lib.MemoryNetwork)
+	newTransport := func(config *lib.AuctionConfig) lib.Transport {
+		networksLock.Lock()
+		defer networksLock.Unlock()
+		n := len(config.Hosts)
+		if networks[n] == nil {
+			networks[n] = lib.NewMemoryNetwork(n)
+		}
+		return networks[n].Transport(config.MyID)
+	}
+
+	hosts := make([]string, n)
+	for i := range hosts {
+		hosts[i] = fmt.Sprintf("host%v", i)
+	}
+	states := make([]interface{}, n)
+	errs := make([]error, n)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// The cheater waits for the others until they are done
+	var wg, honestWg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		i := i
+		if err := os.MkdirAll(filepath.Join(dir, fmt.Sprint(i)), 0700); err != nil {
+			test.Fatal(err)
+		}
+		config := &lib.AuctionConfig{Hosts: hosts, MyID: i, Group: group, AuctionID: "test", Prices: prices,
+			EvidenceDir:    filepath.Join(dir, fmt.Sprint(i)),
+			TranscriptFile: filepath.Join(dir, fmt.Sprint(i), "transcript"),
+		}
+		protocol := lib.Protocol{
+			NewTransport: newTransport,
+			Start: func(config *lib.AuctionConfig, session *lib.Session) ([]lib.Round, interface{}, error) {
+				rounds := fpRounds(config)
+				if i == cheater {
+					rounds = tamperedRounds(group, rounds, stepRound1, func(group zkp.Group, msg proto.Message) {
+						in := msg.(*Round1)
+						in.Alphas[0] = tamperElement(group, in.Alphas[0])
+					})
+				}
+				return rounds, newFpState(session, config, bids[i]), nil
+			},
+		}
+
+		group := &honestWg
+		if i == cheater {
+			group = &wg
+		}
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			_, states[i], errs[i] = lib.RunAuction(ctx, config, protocol)
+		}()
+	}
+	honestWg.Wait()
+	cancel()
+	wg.Wait()
+
+	return hosts, states, errs
+}
+
+func TestFirstPriceExcludesCheater(test *testing.T) {
+	const cheater = 2
+	dir := test.TempDir()
+	hosts, states, errs := runWithCheater(test, cheater, []uint{3, 6, 7, 2}, dir)
+
+	for i := range states {
+		if i == cheater {
+			continue
+		}
+		if errs[i] != nil {
+			test.Errorf("Party %v failed: %v", i, errs[i])
+			continue
+		}
+
+		// Party 3 is party 2 without the cheater, and the highest bidder
+		// left is party 1
+		s := states[i].(*FpState)
+		if s.winner != 1 || s.price != 6 {
+			test.Errorf("Party %v found winner %v at price %v, want 1 at price 6", i, s.winner, s.price)
+		}
+		if s.auctionID != "test-excluding-2" {
+			test.Errorf("Party %v ran auction %v, want test-excluding-2", i, s.auctionID)
+		}
+
+		name := filepath.Join(dir, fmt.Sprint(i), fmt.Sprintf("test-round%v-party%v.json", stepRound1, cheater))
+		f, err := os.Open(name)
+		if err != nil {
+			test.Errorf("Party %v wrote no evidence: %v", i, err)
+			continue
+		}
+		var evidence lib.Evidence
+		err = json.NewDecoder(f).Decode(&evidence)
+		f.Close()
+		if err != nil {
+			test.Errorf("Party %v wrote invalid evidence: %v", i, err)
+			continue
+		}
+		if evidence.Accused != cheater || evidence.Round != stepRound1 || evidence.Message == nil ||
+			evidence.Message.Clientid != cheater || evidence.Hosts[cheater] != hosts[cheater] {
+			test.Errorf("Party %v wrote evidence %+v", i, evidence)
+		}
+	}
+}
+
+func TestFirstPriceReportsHostIDs(test *testing.T) {
+	const cheater = 1
+	dir := test.TempDir()
+	hosts, states, errs := runWithCheater(test, cheater, []uint{3, 6, 2, 7}, dir)
+
+	for i := range states {
+		if i == cheater {
+			continue
+		}
+		if errs[i] != nil {
+			test.Errorf("Party %v failed: %v", i, errs[i])
+			continue
+		}
+
+		// The winner is party 2 of the run without the cheater, but party
+		// 3 of the hosts file, and so of the result
+		s := states[i].(*FpState)
+		if r := s.Result(); s.winner != 2 || !reflect.DeepEqual(r.Winners, []int{3}) || r.Price != "7" {
+			test.Errorf("Party %v found winner %v, reported as %v at price %v, want 2, reported as [3] at price 7",
+				i, s.winner, r.Winners, r.Price)
+		}
+
+		// The transcript is of the run without the cheater, but replaying
+		// it with the hosts file reports the same
+		prices, _ := lib.MaxPriceLadder(testK - 1)
+		group, _ := zkp.NamedGroup(zkp.ToyGroupName)
+		config := &lib.AuctionConfig{Hosts: hosts, MyID: i, Group: group, AuctionID: "test", Prices: prices}
+		r, err := lib.Verify(config, filepath.Join(dir, fmt.Sprint(i), "transcript"), verify)
+		if err != nil {
+			test.Errorf("Party %v failed to verify its transcript: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(r.Winners, []int{3}) || r.Price != "7" {
+			test.Errorf("Party %v verified winners %v at price %v, want [3] at price 7", i, r.Winners, r.Price)
+		}
+	}
+}
+
+// checkCheaterDetected checks that some honest party blamed cheater for an
+// error of the given kind in step, and that no party blamed an honest one.
+func checkCheaterDetected(test *testing.T, name string, errs []error, cheater int, step int, kind lib.ErrorKind) {
+	detected := false
+	for i, err := range errs {
+		if i == cheater {
+			continue
+		}
+		if err == nil {
+			test.Errorf("%v: party %v did not notice the cheating", name, i)
+			continue
+		}
+
+		e, ok := err.(*lib.RoundError)
+		if !ok {
+			test.Errorf("%v: party %v failed with %v, want a *lib.RoundError", name, i, err)
+			continue
+		}
+		switch e.Clientid {
+		case cheater:
+			if e.Round != step || e.Kind != kind {
+				test.Errorf("%v: party %v failed with %v, want a %v in round %v", name, i, e, kind, step)
+			}
+			detected = true
+		case lib.NoClient:
+			// Gave up once another party detected the cheating
+		default:
+			test.Errorf("%v: party %v blamed honest party %v: %v", name, i, e.Clientid, e)
+		}
+	}
+
+	if !detected {
+		test.Errorf("%v: no party blamed the cheater", name)
+	}
+}
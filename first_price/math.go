@@ -1,60 +1,47 @@
 package main
 
 import (
-	"math/big"
-
 	"github.com/ashwinsr/auctions/zkp"
 )
 
-type GetIntFunc func(i int) *big.Int
+type GetElementFunc func(i int) zkp.Element
 
-// multiplies getter(start) * getter(start + 1) * ... * getter(end) mod P
-func Multiply(start, end int, p *big.Int, getter GetIntFunc) *big.Int {
-	var result big.Int
-	result.Set(zkp.One)
+// multiplies getter(start) * getter(start + 1) * ... * getter(end - 1)
+func Multiply(group zkp.Group, start, end int, getter GetElementFunc) zkp.Element {
+	result := group.Identity()
 	for i := start; i < end; i++ {
-		result.Mul(&result, getter(i))
-		result.Mod(&result, p)
+		result = group.Mul(result, getter(i))
 	}
-	return &result
+	return result
 }
 
-type GetNumFunc func(x, y int) *big.Int
+type GetNumFunc func(x, y int) zkp.Element
 
-func Round2ComputeInitialValue(n, k, j int, p *big.Int, getNum GetNumFunc) (cachedVal big.Int) {
-	cachedVal.Set(zkp.One)
-	firstResult := Multiply(0, n, p, func(h int) *big.Int {
-		return Multiply(j+1, k, p, func(d int) *big.Int {
+func Round2ComputeInitialValue(group zkp.Group, n, k, j int, getNum GetNumFunc) zkp.Element {
+	return Multiply(group, 0, n, func(h int) zkp.Element {
+		return Multiply(group, j+1, k, func(d int) zkp.Element {
 			return getNum(h, d)
 		})
 	})
-
-	cachedVal.Mul(&cachedVal, firstResult)
-	cachedVal.Mod(&cachedVal, p)
-	return
 }
 
 // Ensre that you use the right indices
 // returns (result_(id))^m, [](result_(id))
-func Round2ComputeOutcome(i, j int, p, cachedVal *big.Int, getNum GetNumFunc) big.Int {
-	var result big.Int
-	result.Set(cachedVal)
-
+// ahead are the bidders that win a tie against bidder i
+func Round2ComputeOutcome(group zkp.Group, i, j int, ahead []int, cachedVal zkp.Element, getNum GetNumFunc) zkp.Element {
 	// upper limit is j and this multiply function is NON-INCLUSIVE
-	secondResult := Multiply(0, j, p, func(d int) *big.Int {
+	secondResult := Multiply(group, 0, j, func(d int) zkp.Element {
 		return getNum(i, d)
 	})
 
-	result.Mul(&result, secondResult)
-	result.Mod(&result, p)
+	result := group.Mul(cachedVal, secondResult)
 
 	// This part is for TIEBREAKING
-	// thirdResult := Multiply(0, i, p, func(h int) *big.Int {
-	// 	return getNum(h, j)
-	// })
+	thirdResult := Multiply(group, 0, len(ahead), func(h int) zkp.Element {
+		return getNum(ahead[h], j)
+	})
 
-	// result.Mul(&result, thirdResult)
-	// result.Mod(&result, p)
+	result = group.Mul(result, thirdResult)
 
 	return result
 }
@@ -0,0 +1,173 @@
+package main
+
+import (
+	pb "github.com/ashwinsr/auctions/common_pb"
+	"github.com/ashwinsr/auctions/lib"
+	"github.com/ashwinsr/auctions/zkp"
+	"github.com/golang/protobuf/proto"
+)
+
+// A transcript has the messages of every party, but none of their secrets.
+// So to replay an auction as a party, every round restores what the party
+// computed from its message in the transcript instead of computing it, and
+// then checks and receives the messages of the others as in the auction.
+
+// restoreFn restores the state s of its party from msg, its message of a
+// round.
+type restoreFn func(s *FpState, msg *pb.OuterStruct) error
+
+// replayRounds returns the rounds of party config.MyID that replay the
+// transcript in replay.
+func replayRounds(config *lib.AuctionConfig, replay *lib.Replay) []lib.Round {
+	rounds := fpRounds(config)
+
+	for step, restore := range map[int]restoreFn{
+		stepPrologue: restorePrologue,
+		stepRound1:   restoreRound1,
+		stepTieBreak: restoreTieBreak,
+		stepRound2:   restoreRound2,
+		stepRound3:   restoreRound3,
+	} {
+		step, restore := step, restore
+		rounds[step-1].Compute = func(state interface{}) (proto.Message, bool, error) {
+			s := getFpState(state)
+			return nil, false, restore(s, replay.Message(step, s.id))
+		}
+	}
+
+	// The seller already forwarded the messages of round 3
+	rounds[stepRound3-1].Receive = receiveRound3
+
+	return rounds
+}
+
+// verify replays the transcript in replay as party config.MyID, for
+// lib.Verify.
+func verify(config *lib.AuctionConfig, replay *lib.Replay) (*lib.Result, error) {
+	s := newFpState(nil, config, 0)
+
+	result, err := replay.Run(config, replayRounds(config, replay), s)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Winners = []int{s.hostID(s.winner)}
+	result.Price = s.prices.Price(int(s.price))
+	return result, nil
+}
+
+func restorePrologue(s *FpState, msg *pb.OuterStruct) error {
+	var prologue Prologue
+	if err := proto.Unmarshal(msg.Data, &prologue); err != nil {
+		return lib.NewError(lib.DecodeError, s.id, "Failed to unmarshal Prologue: %v", err)
+	}
+	if prologue.Key == nil {
+		return lib.NewError(lib.DecodeError, s.id, "Missing key")
+	}
+
+	var err error
+	if s.myPublicKey, err = zkp.DecodeKey(s.group, prologue.Key.Key); err != nil {
+		return lib.NewError(lib.DecodeError, s.id, "Failed to decode key: %v", err)
+	}
+	return nil
+}
+
+func restoreRound1(s *FpState, msg *pb.OuterStruct) error {
+	var in Round1
+	if err := proto.Unmarshal(msg.Data, &in); err != nil {
+		return lib.NewError(lib.DecodeError, s.id, "Failed to unmarshal Round1: %v", err)
+	}
+	if len(in.Alphas) != s.numPrices() || len(in.Betas) != s.numPrices() {
+		return lib.NewError(lib.DecodeError, s.id, "Incorrect number of alpha/betas in round 1")
+	}
+
+	n := len(s.keys)
+	s.AlphasBetas = make([]*AlphaBetaStruct, n)
+	s.AlphasBetas[s.id] = new(AlphaBetaStruct)
+
+	var err error
+	if s.AlphasBetas[s.id].alphas, err = decodeElements(s.group, s.id, in.Alphas); err != nil {
+		return err
+	}
+	if s.AlphasBetas[s.id].betas, err = decodeElements(s.group, s.id, in.Betas); err != nil {
+		return err
+	}
+	return nil
+}
+
+func restoreTieBreak(s *FpState, msg *pb.OuterStruct) error {
+	var in TieBreak
+	if err := proto.Unmarshal(msg.Data, &in); err != nil {
+		return lib.NewError(lib.DecodeError, s.id, "Failed to unmarshal TieBreak: %v", err)
+	}
+
+	s.tieBreakNonce = in.Nonce
+	s.tieBreakNonces = make([][]byte, len(s.keys))
+	s.tieBreakNonces[s.id] = s.tieBreakNonce
+	return nil
+}
+
+func restoreRound2(s *FpState, msg *pb.OuterStruct) error {
+	var in Round2
+	if err := proto.Unmarshal(msg.Data, &in); err != nil {
+		return lib.NewError(lib.DecodeError, s.id, "Failed to unmarshal Round2: %v", err)
+	}
+
+	n := len(s.keys)
+	if len(in.DoubleGammas) != n || len(in.DoubleDeltas) != n {
+		return lib.NewError(lib.DecodeError, s.id, "Incorrect number of double gamma/deltas")
+	}
+
+	s.computeGammasDeltas()
+
+	s.GammasDeltasAfterExponentiation = make([][]*GammaDeltaStruct, n)
+	s.GammasDeltasAfterExponentiation[s.id] = make([]*GammaDeltaStruct, n)
+	for i := 0; i < n; i++ {
+		if in.DoubleGammas[i] == nil || in.DoubleDeltas[i] == nil ||
+			len(in.DoubleGammas[i].Gammas) != s.numPrices() || len(in.DoubleDeltas[i].Deltas) != s.numPrices() {
+			return lib.NewError(lib.DecodeError, s.id, "Incorrect number of gammas/deltas in round 2")
+		}
+
+		var err error
+		gd := new(GammaDeltaStruct)
+		if gd.gammas, err = decodeElements(s.group, s.id, in.DoubleGammas[i].Gammas); err != nil {
+			return err
+		}
+		if gd.deltas, err = decodeElements(s.group, s.id, in.DoubleDeltas[i].Deltas); err != nil {
+			return err
+		}
+		s.GammasDeltasAfterExponentiation[s.id][i] = gd
+	}
+	return nil
+}
+
+func restoreRound3(s *FpState, msg *pb.OuterStruct) error {
+	var in Round3
+	if err := proto.Unmarshal(msg.Data, &in); err != nil {
+		return lib.NewError(lib.DecodeError, s.id, "Failed to unmarshal Round3: %v", err)
+	}
+
+	n := len(s.keys)
+	if len(in.DoublePhis) != n {
+		return lib.NewError(lib.DecodeError, s.id, "Incorrect number of double phis in round 3")
+	}
+
+	s.PhisBeforeExponentiation = make([][]zkp.Element, n)
+	s.PhisAfterExponentiation = make([][][]zkp.Element, n)
+	s.PhisAfterExponentiation[s.id] = make([][]zkp.Element, n)
+	for i := 0; i < n; i++ {
+		if in.DoublePhis[i] == nil || len(in.DoublePhis[i].Phis) != s.numPrices() {
+			return lib.NewError(lib.DecodeError, s.id, "Incorrect number of phis in round 3")
+		}
+
+		for j := 0; j < s.numPrices(); j++ {
+			s.PhisBeforeExponentiation[i] = append(s.PhisBeforeExponentiation[i], s.phi(i, j))
+		}
+
+		var err error
+		if s.PhisAfterExponentiation[s.id][i], err = decodeElements(s.group, s.id, in.DoublePhis[i].Phis); err != nil {
+			return err
+		}
+	}
+	return nil
+}
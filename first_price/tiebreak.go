@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"math/big"
+	"sort"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+	"github.com/ashwinsr/auctions/lib"
+	"github.com/ashwinsr/auctions/zkp"
+	"github.com/golang/protobuf/proto"
+)
+
+// The length of the tie-breaking nonces, in bytes
+const tieBreakNonceLength = 32
+
+// A random order of the bidders is only random if nobody can choose their
+// part of it after seeing the others' parts. So every party commits to a
+// random nonce in the prologue, and the order is derived from all the
+// nonces together. Nobody may learn the order before bidding either, or a
+// party that waits for the others' messages of round 1 before sending its
+// own could bid knowing whom it wins ties against. So the commitments are
+// opened in a round of their own, once all bids are in, right before round
+// 2 needs the order. With the lowest id rule the order is known anyway,
+// and the round only carries empty nonces.
+
+func computeTieBreak(state interface{}) (proto.Message, bool, error) {
+	s := getFpState(state)
+	s.tieBreakNonces = make([][]byte, len(s.keys))
+	s.tieBreakNonces[s.id] = s.tieBreakNonce
+
+	return &TieBreak{Nonce: s.tieBreakNonce}, false, nil
+}
+
+func checkTieBreak(state interface{}, result *pb.OuterStruct) error {
+	s := getFpState(state)
+	client := int(result.Clientid)
+	var in TieBreak
+
+	if err := proto.Unmarshal(result.Data, &in); err != nil {
+		return lib.NewError(lib.DecodeError, client, "Failed to unmarshal TieBreak: %v", err)
+	}
+	if s.tieBreak == lib.TieBreakRandom {
+		return s.checkTieBreakNonce(client, in.Nonce)
+	}
+	return nil
+}
+
+func receiveTieBreak(state interface{}, results []*pb.OuterStruct) error {
+	s := getFpState(state)
+	var in TieBreak
+
+	for i := 0; i < len(results); i++ {
+		if i == s.id {
+			continue
+		}
+		if err := proto.Unmarshal(results[i].Data, &in); err != nil {
+			return lib.NewError(lib.DecodeError, i, "Failed to unmarshal TieBreak: %v", err)
+		}
+		s.tieBreakNonces[i] = in.Nonce
+	}
+
+	// Bidder i loses a tie against everyone before it in the order
+	order := s.tieBreakOrder()
+	s.tieBreakAhead = make([][]int, len(results))
+	for k, i := range order {
+		s.tieBreakAhead[i] = order[:k]
+	}
+	log.Printf("Tie-breaking order: %v", order)
+
+	return nil
+}
+
+// newTieBreakNonce returns a fresh nonce.
+func newTieBreakNonce() ([]byte, error) {
+	nonce := make([]byte, tieBreakNonceLength)
+	if _, err := io.ReadFull(zkp.RandomSource, nonce); err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
+
+// tieBreakCommitment returns the commitment of client to nonce.
+func (s *FpState) tieBreakCommitment(client int, nonce []byte) []byte {
+	t := s.transcript(stepPrologue, client)
+	t.AppendBytes("tie-break nonce", nonce)
+	return t.Challenge("tie-break commitment", new(big.Int).Lsh(zkp.One, 256)).Bytes()
+}
+
+// checkTieBreakNonce checks that nonce opens the commitment of client.
+func (s *FpState) checkTieBreakNonce(client int, nonce []byte) error {
+	if len(nonce) != tieBreakNonceLength {
+		return lib.NewError(lib.DecodeError, client, "Tie-breaking nonce has %v bytes", len(nonce))
+	}
+	if !bytes.Equal(s.tieBreakCommitment(client, nonce), s.tieBreakCommitments[client]) {
+		return lib.NewError(lib.ProofError, client, "Tie-breaking nonce does not open its commitment")
+	}
+	return nil
+}
+
+// tieBreakOrder returns the bidders in the order in which they win ties.
+func (s *FpState) tieBreakOrder() []int {
+	n := len(s.keys)
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+
+	if s.tieBreak != lib.TieBreakRandom {
+		return order
+	}
+
+	seed := zkp.NewTranscript(protocolLabel + "/tie-break order")
+	seed.AppendString("auction", s.auctionID)
+	for i := 0; i < n; i++ {
+		seed.AppendBytes("nonce", s.tieBreakNonces[i])
+	}
+
+	ranks := make([]*big.Int, n)
+	for i := range ranks {
+		ranks[i] = seed.Fork("rank", i).Challenge("rank", new(big.Int).Lsh(zkp.One, 256))
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return ranks[order[a]].Cmp(ranks[order[b]]) < 0
+	})
+
+	return order
+}
@@ -0,0 +1,145 @@
+package lib
+
+import (
+	"fmt"
+	"log"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// The step id of accusations, which belong to no round
+const accusationStep = -1
+
+// A party that finds a message of another party that does not check
+// accuses it to all others, with the message as evidence. They check the
+// evidence themselves, and fail with the same error as the accuser, or
+// blame the accuser if the evidence checks. So all honest parties agree on
+// whom to exclude, even if the accused party sent a bad message to only
+// some of them. As the evidence carries the signature of the accused, an
+// accuser cannot make it up.
+
+// accuse sends an accusation to all other parties but the accused, if e
+// blames another party for a message that does not check. It returns e.
+func (s *Session) accuse(ctx context.Context, e *RoundError) error {
+	if e.Kind != DecodeError && e.Kind != ProofError {
+		return e
+	}
+	if e.Clientid < 0 || e.Clientid >= s.NumParties() || e.Clientid == s.id {
+		return e
+	}
+	if e.Evidence != nil && (e.Accuser != s.id || e.Evidence.Stepid == accusationStep) {
+		// Somebody else's accusation, which they sent to everyone, or
+		// an accusation that everyone checks for themselves
+		return e
+	}
+
+	if e.Evidence == nil {
+		s.dataLock.Lock()
+		e.Evidence = s.data[e.Clientid]
+		s.dataLock.Unlock()
+		if e.Evidence == nil || int(e.Evidence.Stepid) != e.Round {
+			e.Evidence = nil
+			return e
+		}
+		e.Accuser = s.id
+	}
+
+	data, err := proto.Marshal(&pb.Accusation{
+		Accused:  int32(e.Clientid),
+		Round:    int32(e.Round),
+		Reason:   e.Err.Error(),
+		Evidence: e.Evidence,
+	})
+	if err != nil {
+		log.Printf("Failed to marshal the accusation of party %v: %v", e.Clientid, err)
+		return e
+	}
+	out := &pb.OuterStruct{
+		Clientid: int32(s.id),
+		Stepid:   accusationStep,
+		Data:     data,
+	}
+	if err := s.sign(out); err != nil {
+		log.Printf("Failed to sign the accusation of party %v: %v", e.Clientid, err)
+		return e
+	}
+
+	log.Printf("Accusing party %v of cheating in round %v: %v", e.Clientid, e.Round, e.Err)
+	for i := 0; i < s.NumParties(); i++ {
+		if i == s.id || i == e.Clientid {
+			continue
+		}
+		if err := s.transport.Send(ctx, i, out); err != nil {
+			log.Printf("Failed to send the accusation of party %v to party %v: %v", e.Clientid, i, err)
+		}
+	}
+
+	return e
+}
+
+// checkAccusation checks the evidence of an accusation received from
+// another party. It returns the error the accuser found, or an error
+// blaming the accuser if the accusation is false.
+func (s *Session) checkAccusation(state interface{}, in *pb.OuterStruct) error {
+	accuser := int(in.Clientid)
+
+	// The evidence against a false accuser is its accusation
+	falseAccusation := func(kind ErrorKind, format string, args ...interface{}) error {
+		return &RoundError{
+			Round:    int(s.numRound),
+			Clientid: accuser,
+			Kind:     kind,
+			Err:      fmt.Errorf(format, args...),
+			Evidence: in,
+			Accuser:  s.id,
+		}
+	}
+
+	if err := s.verify(in); err != nil {
+		e := err.(*RoundError)
+		e.Round = int(s.numRound)
+		return e
+	}
+
+	var accusation pb.Accusation
+	if err := proto.Unmarshal(in.Data, &accusation); err != nil {
+		return falseAccusation(DecodeError, "Failed to unmarshal Accusation: %v", err)
+	}
+
+	accused := int(accusation.Accused)
+	round := int(accusation.Round)
+	evidence := accusation.Evidence
+	if accused < 0 || accused >= s.NumParties() || accused == accuser ||
+		round < 1 || round > int(s.numRound) || evidence == nil || int(evidence.Clientid) != accused ||
+		(int(evidence.Stepid) != round && evidence.Stepid != echoStep(round)) {
+		return falseAccusation(DecodeError, "Invalid accusation of party %v in round %v", accused, round)
+	}
+
+	check := s.rounds[round-1].Check
+	if evidence.Stepid == echoStep(round) {
+		check = s.checkEchoSignatures
+	}
+
+	if err := s.verify(evidence); err != nil {
+		return falseAccusation(ProofError, "Evidence against party %v in round %v is forged: %v", accused, round, err)
+	}
+
+	log.Printf("Party %v accuses party %v of cheating in round %v: %v", accuser, accused, round, accusation.Reason)
+
+	err := check(state, evidence)
+	if err == nil {
+		return falseAccusation(ProofError, "False accusation of party %v in round %v", accused, round)
+	}
+
+	e, ok := err.(*RoundError)
+	if !ok {
+		e = &RoundError{Kind: ProofError, Err: err}
+	}
+	e.Round = round
+	e.Clientid = accused
+	e.Evidence, e.Accuser = evidence, accuser
+	e.Err = fmt.Errorf("accused by party %v: %v", accuser, e.Err)
+	return e
+}
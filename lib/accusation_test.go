@@ -0,0 +1,177 @@
+package lib
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// tamperingTransport sends a wrong number in the messages to victim, and
+// signs them, as a cheater would.
+type tamperingTransport struct {
+	Transport
+	victim int
+}
+
+func (t *tamperingTransport) Send(ctx context.Context, to int, msg *pb.OuterStruct) error {
+	if to == t.victim && msg.Stepid > 0 {
+		data, _ := proto.Marshal(&pb.OuterStruct{Clientid: 42})
+		msg = &pb.OuterStruct{Clientid: msg.Clientid, Stepid: msg.Stepid, Data: data}
+		if err := signMessage(t.Key(), msg.Auctionid, msg); err != nil {
+			return err
+		}
+	}
+	return t.Transport.Send(ctx, to, msg)
+}
+
+// accuseAll makes party liar of network accuse party accused with the
+// evidence, signed by signer, before the round.
+func accuseAll(network *MemoryNetwork, n, liar, accused int, evidence *pb.OuterStruct, signer int) func(int, Transport) {
+	return func(i int, t Transport) {
+		if i != liar {
+			return
+		}
+		signMessage(network.Transport(signer).Key(), "", evidence)
+		data, _ := proto.Marshal(&pb.Accusation{Accused: int32(accused), Round: 1, Reason: "lies", Evidence: evidence})
+		accusation := &pb.OuterStruct{Clientid: int32(liar), Stepid: accusationStep, Data: data}
+		signMessage(t.Key(), "", accusation)
+		for j := 0; j < n; j++ {
+			if j != liar {
+				t.Send(context.Background(), j, accusation)
+			}
+		}
+	}
+}
+
+// runSum runs a round of the sum protocol among n parties, with echoes if
+// echo is set, where transport(i) returns the transport of party i and
+// before runs before party i runs the round. It returns the errors of the
+// parties in honest.
+func runSum(n int, echo bool, honest []int, transport func(i int) Transport, before func(i int, t Transport)) []error {
+	return runSumRounds(n, 1, echo, honest, transport, before)
+}
+
+// runSumRounds is runSum with the given number of rounds.
+func runSumRounds(n, numRounds int, echo bool, honest []int, transport func(i int) Transport, before func(i int, t Transport)) []error {
+	hosts := make([]string, n)
+	errs := make([]error, n)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// The cheater cannot tell that the others are done, so it is stopped
+	// once they are
+	var wg, honestWg sync.WaitGroup
+	isHonest := make(map[int]bool)
+	for _, i := range honest {
+		isHonest[i] = true
+	}
+	for i := 0; i < n; i++ {
+		i := i
+		t := transport(i)
+		session := NewSession(&AuctionConfig{Hosts: hosts, MyID: i, Echo: echo}, t)
+
+		group := &wg
+		if isHonest[i] {
+			group = &honestWg
+		}
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			if errs[i] = session.Connect(ctx); errs[i] != nil {
+				return
+			}
+			before(i, t)
+			rounds := make([]Round, numRounds)
+			for j := range rounds {
+				rounds[j] = sumRound()
+			}
+			errs[i] = session.Run(ctx, rounds, &sumState{id: i})
+		}()
+	}
+	honestWg.Wait()
+	cancel()
+	wg.Wait()
+
+	var honestErrs []error
+	for _, i := range honest {
+		honestErrs = append(honestErrs, errs[i])
+	}
+	return honestErrs
+}
+
+func TestAccusation(test *testing.T) {
+	const n, cheater, victim = 4, 2, 1
+
+	network := NewMemoryNetwork(n)
+	errs := runSum(n, false, []int{0, 1, 3}, func(i int) Transport {
+		if i == cheater {
+			return &tamperingTransport{network.Transport(i), victim}
+		}
+		return network.Transport(i)
+	}, func(int, Transport) {})
+
+	// Only the victim got the bad message, but everyone learns about it
+	for i, err := range errs {
+		e, ok := err.(*RoundError)
+		if !ok {
+			test.Errorf("Party %v failed with %v, want a *RoundError", i, err)
+			continue
+		}
+		if e.Clientid != cheater || e.Round != 1 || e.Kind != ProofError || e.Accuser != victim ||
+			e.Evidence == nil || int(e.Evidence.Clientid) != cheater {
+			test.Errorf("Party %v failed with %v, accused by %v with evidence %v, want a %v blaming %v in round 1, accused by %v",
+				i, e, e.Accuser, e.Evidence, ProofError, cheater, victim)
+		}
+	}
+}
+
+func TestFalseAccusation(test *testing.T) {
+	const n, liar, accused = 4, 3, 2
+
+	// A message of the accused that checks
+	data, _ := proto.Marshal(&pb.OuterStruct{Clientid: accused + 1})
+	evidence := &pb.OuterStruct{Clientid: accused, Stepid: 1, Data: data}
+
+	network := NewMemoryNetwork(n)
+	errs := runSum(n, false, []int{0, 1, 2}, network.Transport, accuseAll(network, n, liar, accused, evidence, accused))
+
+	for i, err := range errs {
+		e, ok := err.(*RoundError)
+		if !ok {
+			test.Errorf("Party %v failed with %v, want a *RoundError", i, err)
+			continue
+		}
+		if e.Clientid != liar || e.Kind != ProofError || e.Evidence == nil || e.Evidence.Stepid != accusationStep {
+			test.Errorf("Party %v failed with %v, with evidence %v, want a %v blaming %v for its accusation",
+				i, e, e.Evidence, ProofError, liar)
+		}
+	}
+}
+
+func TestForgedEvidence(test *testing.T) {
+	const n, liar, accused = 4, 3, 2
+
+	// A message that does not check, which the accused never signed
+	data, _ := proto.Marshal(&pb.OuterStruct{Clientid: 42})
+	evidence := &pb.OuterStruct{Clientid: accused, Stepid: 1, Data: data}
+
+	network := NewMemoryNetwork(n)
+	errs := runSum(n, false, []int{0, 1, 2}, network.Transport, accuseAll(network, n, liar, accused, evidence, liar))
+
+	for i, err := range errs {
+		e, ok := err.(*RoundError)
+		if !ok {
+			test.Errorf("Party %v failed with %v, want a *RoundError", i, err)
+			continue
+		}
+		if e.Clientid != liar || e.Kind != ProofError || e.Evidence == nil || e.Evidence.Stepid != accusationStep {
+			test.Errorf("Party %v failed with %v, with evidence %v, want a %v blaming %v for its accusation",
+				i, e, e.Evidence, ProofError, liar)
+		}
+	}
+}
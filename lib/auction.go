@@ -0,0 +1,100 @@
+package lib
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Protocol is how to run one party's side of an auction, so that
+// RunAuction can start it again among fewer parties.
+type Protocol struct {
+	// NewTransport returns the transport of party config.MyID.
+	NewTransport func(config *AuctionConfig) Transport
+
+	// Start returns the rounds and the initial state of party
+	// config.MyID in session.
+	Start func(config *AuctionConfig, session *Session) ([]Round, interface{}, error)
+}
+
+// RunAuction connects to the other parties and runs protocol, closing the
+// connections when done, and failing once config.Deadline, if any, has
+// passed. If config.TranscriptFile is set, it records the transcript of the
+// run there. If a party is caught cheating, RunAuction writes the evidence
+// to config.EvidenceDir. If it is a bidder, all others then
+// run the auction again without it, as often as needed. It returns the
+// session and the state of the last run, whose transcript replaces those
+// of the runs before.
+//
+// The seller cannot be excluded, nor can the auction go on with a single
+// party, so then RunAuction fails.
+func RunAuction(ctx context.Context, config *AuctionConfig, protocol Protocol) (*Session, interface{}, error) {
+	if !config.Deadline.IsZero() {
+		if !time.Now().Before(config.Deadline) {
+			return nil, nil, fmt.Errorf("the deadline of the auction, %v, has passed", config.Deadline)
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, config.Deadline)
+		defer cancel()
+	}
+
+	for {
+		transport := protocol.NewTransport(config)
+		var transcript *os.File
+		if config.TranscriptFile != "" {
+			var err error
+			if transcript, err = os.Create(config.TranscriptFile); err != nil {
+				return nil, nil, err
+			}
+			transport = RecordTranscript(config, transport, transcript)
+		}
+
+		session := NewSession(config, transport)
+		rounds, state, err := protocol.Start(config, session)
+		if err != nil {
+			if transcript != nil {
+				transcript.Close()
+			}
+			return nil, nil, err
+		}
+
+		if err = session.Connect(ctx); err == nil {
+			err = session.Run(ctx, rounds, state)
+		}
+		if closeErr := session.Close(); closeErr != nil {
+			log.Printf("Failed to disconnect from the other parties: %v", closeErr)
+		}
+		if transcript != nil {
+			if closeErr := transcript.Close(); closeErr != nil {
+				log.Printf("Failed to write the transcript: %v", closeErr)
+			}
+		}
+		if err == nil {
+			return session, state, nil
+		}
+
+		e, ok := err.(*RoundError)
+		if !ok || e.Evidence == nil || e.Clientid == config.MyID {
+			return session, state, err
+		}
+
+		if config.EvidenceDir != "" {
+			name, werr := WriteEvidence(config.EvidenceDir, config, e)
+			if werr != nil {
+				log.Printf("Failed to write the evidence against party %v: %v", e.Clientid, werr)
+			} else {
+				log.Printf("Wrote the evidence against party %v to %v", e.Clientid, name)
+			}
+		}
+
+		if e.Clientid == 0 || len(config.Hosts) <= 2 {
+			return session, state, err
+		}
+
+		log.Printf("Excluding party %v (%v) and starting over: %v", e.Clientid, config.Hosts[e.Clientid], e)
+		config = config.Excluding(e.Clientid)
+	}
+}
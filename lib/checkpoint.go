@@ -0,0 +1,285 @@
+package lib
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+)
+
+// A party that crashes loses the secrets of its state, and cannot compute
+// its messages again without sending different ones than before, which
+// looks like cheating. So with checkpoints, a Session writes its state to
+// the checkpoint file once it has computed its message of a round, before
+// sending it, and adds every message it receives to the file before the
+// transport acknowledges it. A restarted party resumes from the round of
+// the checkpoint: it sends the same message again, which the parties that
+// already have it ignore, and goes on with the messages in the file.
+//
+// The file is encrypted with AES-GCM, under a key derived from the private
+// key of our certificate, as it holds our secrets.
+
+// Checkpointer is implemented by the states of protocols that can be
+// checkpointed.
+type Checkpointer interface {
+	// Checkpoint returns the state, as Restore reads it.
+	Checkpoint() ([]byte, error)
+	// Restore sets the state to one returned by Checkpoint. It is called
+	// on the initial state of the protocol.
+	Restore(data []byte) error
+}
+
+// checkpoint is the content of a checkpoint file.
+type checkpoint struct {
+	AuctionID string `json:"auctionID"`
+	MyID      int    `json:"myID"`
+
+	// Step is the round of the checkpoint, zero before the first. Out is
+	// our message of the round, and State the state of the protocol once
+	// it computed Out.
+	Step         int32             `json:"step"`
+	Out          *pb.OuterStruct   `json:"out"`
+	SendToSeller bool              `json:"sendToSeller"`
+	State        []byte            `json:"state"`
+	Transcript   []byte            `json:"transcript"` // the transcript hash of the rounds before
+	Durations    []time.Duration   `json:"roundDurations"`
+	BytesSent    int64             `json:"bytesSent"`
+	BytesRecvd   int64             `json:"bytesReceived"`
+	Received     []*pb.OuterStruct `json:"received"` // of Step and later rounds
+}
+
+// The label of the key of checkpoint files
+const checkpointLabel = "auctions/checkpoint"
+
+// messageStep returns the round msg belongs to.
+func messageStep(msg *pb.OuterStruct) int32 {
+	if msg.Stepid < accusationStep {
+		return echoedStep(msg.Stepid)
+	}
+	return msg.Stepid
+}
+
+// checkpointMessage adds in, a message we received, to the checkpoint.
+// Accusations are left out, as we check the messages they are about
+// ourselves.
+func (s *Session) checkpointMessage(in *pb.OuterStruct) error {
+	s.checkpointLock.Lock()
+	defer s.checkpointLock.Unlock()
+
+	if s.checkpoint == nil || in.Stepid == accusationStep || messageStep(in) < s.checkpoint.Step {
+		return nil
+	}
+	s.checkpoint.Received = append(s.checkpoint.Received, in)
+	return s.writeCheckpoint()
+}
+
+// saveCheckpoint checkpoints the given round, in which we computed out
+// with the given state.
+func (s *Session) saveCheckpoint(step int, out *pb.OuterStruct, sendToSeller bool, state interface{}) error {
+	if s.checkpoint == nil {
+		return nil
+	}
+
+	data, err := state.(Checkpointer).Checkpoint()
+	if err != nil {
+		return fmt.Errorf("failed to checkpoint the state: %v", err)
+	}
+	transcript, err := s.transcript.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	s.checkpointLock.Lock()
+	defer s.checkpointLock.Unlock()
+
+	cp := s.checkpoint
+	cp.Step, cp.Out, cp.SendToSeller, cp.State, cp.Transcript = int32(step), out, sendToSeller, data, transcript
+	cp.Durations = append([]time.Duration(nil), s.roundDurations...)
+	cp.BytesSent = atomic.LoadInt64(&s.bytesSent)
+	cp.BytesRecvd = atomic.LoadInt64(&s.bytesReceived)
+
+	var received []*pb.OuterStruct
+	for _, in := range cp.Received {
+		if messageStep(in) >= cp.Step {
+			received = append(received, in)
+		}
+	}
+	cp.Received = received
+
+	return s.writeCheckpoint()
+}
+
+// writeCheckpoint replaces the checkpoint file with s.checkpoint, once we
+// have the key to encrypt it with. The caller must hold checkpointLock.
+func (s *Session) writeCheckpoint() error {
+	key := s.transport.Key()
+	if key == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(s.checkpoint)
+	if err != nil {
+		return err
+	}
+	sealed, err := sealCheckpoint(key, data)
+	if err != nil {
+		return err
+	}
+
+	// Replace the file at once, so that a crash leaves the old one
+	name := s.config.CheckpointFile
+	if err := os.WriteFile(name+".tmp", sealed, 0600); err != nil {
+		return fmt.Errorf("failed to write the checkpoint: %v", err)
+	}
+	if err := os.Rename(name+".tmp", name); err != nil {
+		return fmt.Errorf("failed to write the checkpoint: %v", err)
+	}
+	return nil
+}
+
+// loadCheckpoint reads the checkpoint file to resume from, and hands the
+// messages in it to their rounds.
+func (s *Session) loadCheckpoint() error {
+	name := s.config.CheckpointFile
+	sealed, err := os.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("failed to read the checkpoint: %v", err)
+	}
+	data, err := openCheckpoint(s.transport.Key(), sealed)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt the checkpoint %v: %v", name, err)
+	}
+
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return fmt.Errorf("invalid checkpoint %v: %v", name, err)
+	}
+	if cp.AuctionID != s.config.AuctionID || cp.MyID != s.id {
+		return fmt.Errorf("checkpoint %v is of party %v in auction %q, not of party %v in %q",
+			name, cp.MyID, cp.AuctionID, s.id, s.config.AuctionID)
+	}
+	if cp.Step > 0 && cp.Out == nil {
+		return fmt.Errorf("invalid checkpoint %v: no message of round %v", name, cp.Step)
+	}
+	saved := cp.Received
+
+	// Keep what arrived while we connected
+	s.checkpointLock.Lock()
+	for _, in := range s.checkpoint.Received {
+		if messageStep(in) >= cp.Step {
+			cp.Received = append(cp.Received, in)
+		}
+	}
+	s.checkpoint = &cp
+	err = s.writeCheckpoint()
+	s.checkpointLock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Loaded the checkpoint of round %v with %v messages", cp.Step, len(saved))
+	for _, in := range saved {
+		if in.Clientid < 0 || int(in.Clientid) >= len(s.data) {
+			return fmt.Errorf("invalid checkpoint %v: message of unknown client id %v", name, in.Clientid)
+		}
+		s.dispatch(in)
+	}
+	return nil
+}
+
+// resumed returns the checkpoint loaded to resume from, unless it is from
+// before the first round.
+func (s *Session) resumed() *checkpoint {
+	s.checkpointLock.Lock()
+	defer s.checkpointLock.Unlock()
+
+	if !s.config.Resume || s.checkpoint == nil || s.checkpoint.Step == 0 {
+		return nil
+	}
+	cp := *s.checkpoint
+	return &cp
+}
+
+// restoreCheckpoint sets state and the statistics of the session to those
+// of cp.
+func (s *Session) restoreCheckpoint(cp *checkpoint, state interface{}) error {
+	if err := state.(Checkpointer).Restore(cp.State); err != nil {
+		return fmt.Errorf("failed to restore the state: %v", err)
+	}
+	if err := s.transcript.(encoding.BinaryUnmarshaler).UnmarshalBinary(cp.Transcript); err != nil {
+		return fmt.Errorf("failed to restore the transcript hash: %v", err)
+	}
+	s.roundDurations = cp.Durations
+	atomic.StoreInt64(&s.bytesSent, cp.BytesSent)
+	atomic.StoreInt64(&s.bytesReceived, cp.BytesRecvd)
+	return nil
+}
+
+// removeCheckpoint stops checkpointing, and removes the checkpoint file.
+func (s *Session) removeCheckpoint() {
+	s.checkpointLock.Lock()
+	defer s.checkpointLock.Unlock()
+
+	if s.checkpoint == nil {
+		return
+	}
+	s.checkpoint = nil
+	if err := os.Remove(s.config.CheckpointFile); err != nil {
+		log.Printf("Failed to remove the checkpoint: %v", err)
+	}
+}
+
+// checkpointCipher returns the cipher of the checkpoints of the party
+// whose certificate has the private key key.
+func checkpointCipher(key crypto.Signer) (cipher.AEAD, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("cannot derive a key from a %T: %v", key, err)
+	}
+	k := sha256.Sum256(append([]byte(checkpointLabel), der...))
+
+	block, err := aes.NewCipher(k[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealCheckpoint encrypts data, prefixed with a random nonce.
+func sealCheckpoint(key crypto.Signer, data []byte) ([]byte, error) {
+	aead, err := checkpointCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// The nonce must never repeat, even in the seeded test mode
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, data, nil), nil
+}
+
+// openCheckpoint decrypts data sealed by sealCheckpoint.
+func openCheckpoint(key crypto.Signer, sealed []byte) ([]byte, error) {
+	aead, err := checkpointCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("too short")
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+}
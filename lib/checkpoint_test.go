@@ -0,0 +1,110 @@
+package lib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+	"golang.org/x/net/context"
+)
+
+func (s *sumState) Checkpoint() ([]byte, error) {
+	return json.Marshal(s.sum)
+}
+
+func (s *sumState) Restore(data []byte) error {
+	return json.Unmarshal(data, &s.sum)
+}
+
+func TestCheckpoint(test *testing.T) {
+	const n, numRounds, crasher, crashRound = 3, 3, 1, 2
+
+	dir := test.TempDir()
+	hosts := make([]string, n)
+	network := NewMemoryNetwork(n)
+	states := make([]*sumState, n)
+	errs := make([]error, n)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	checkpointFile := func(i int) string {
+		return filepath.Join(dir, fmt.Sprintf("%v.checkpoint", i))
+	}
+	run := func(i int, resume bool, rounds []Round, state *sumState) error {
+		config := &AuctionConfig{Hosts: hosts, MyID: i, AuctionID: "test", CheckpointFile: checkpointFile(i), Resume: resume}
+		session := NewSession(config, network.Transport(i))
+		if err := session.Connect(ctx); err != nil {
+			return err
+		}
+		defer session.Close()
+		return session.Run(ctx, rounds, state)
+	}
+
+	var rounds []Round
+	for i := 0; i < numRounds; i++ {
+		rounds = append(rounds, sumRound())
+	}
+
+	// The crasher dies once it has everyone's messages of crashRound
+	crashing := append([]Round(nil), rounds...)
+	crashing[crashRound-1].Receive = func(interface{}, []*pb.OuterStruct) error {
+		return errors.New("crashed")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		i := i
+		states[i] = &sumState{id: i}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i != crasher {
+				errs[i] = run(i, false, rounds, states[i])
+				return
+			}
+
+			if err := run(i, false, crashing, &sumState{id: i}); err == nil {
+				errs[i] = errors.New("did not crash")
+				return
+			}
+			network.Disconnect(i)
+			errs[i] = run(i, true, rounds, states[i])
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		if errs[i] != nil {
+			test.Fatalf("Party %v failed: %v", i, errs[i])
+		}
+		if want := int32(numRounds * n * (n + 1) / 2); states[i].sum != want {
+			test.Errorf("Party %v got sum %v, want %v", i, states[i].sum, want)
+		}
+		if _, err := os.Stat(checkpointFile(i)); !os.IsNotExist(err) {
+			test.Errorf("Checkpoint of party %v was not removed: %v", i, err)
+		}
+	}
+}
+
+func TestCheckpointWrongKey(test *testing.T) {
+	network := NewMemoryNetwork(2)
+	data, err := sealCheckpoint(network.Transport(0).Key(), []byte("secrets"))
+	if err != nil {
+		test.Fatalf("Failed to seal: %v", err)
+	}
+
+	if opened, err := openCheckpoint(network.Transport(0).Key(), data); err != nil || string(opened) != "secrets" {
+		test.Errorf("Opened %q, %v, want %q", opened, err, "secrets")
+	}
+	if _, err := openCheckpoint(network.Transport(1).Key(), data); err == nil {
+		test.Errorf("Opened the checkpoint with the key of another party")
+	}
+}
@@ -1,223 +1,186 @@
 package lib
 
 import (
-	"encoding/json"
-	"flag"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"fmt"
-	"io/ioutil"
+	"hash"
 	"log"
-	"net"
-	"os"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
-	"unsafe"
-
-
-	"crypto/tls"
-	"crypto/x509"
-
-	"google.golang.org/grpc"
 
 	pb "github.com/ashwinsr/auctions/common_pb"
-	lib_pb "github.com/ashwinsr/auctions/lib/pb"
 	"github.com/golang/protobuf/proto"
-	google_protobuf "github.com/golang/protobuf/ptypes/empty"
 	"golang.org/x/net/context"
-	"google.golang.org/grpc/credentials"
 
 	// "net/http"
 	_ "net/http/pprof"
 )
 
-var (
-	id            int
-	numRound      int32 = 0
-	bytesSent	  int64 = 0
-	bytesReceived int64 = 0
-	data         []*pb.OuterStruct
-	dataLock     sync.Mutex
-	clientsReady sync.Once
-)
-
-/*
- * These are here so that protobuf data, if received before we have moved
- * onto the next round, just wait in the channel until we are ready.
- */
-var (
-	clients []lib_pb.ZKPAuctionClient
-	// Publish
-	receivedIdChan          chan int32    = make(chan int32)
-	isReady                 chan struct{} = make(chan struct{}, 1)
-	seller                  lib_pb.ZKPAuctionClient
+// Session is one party's view of one run of a protocol. A process may run
+// any number of sessions at the same time.
+type Session struct {
+	config *AuctionConfig
+	id     int
+	ctx    context.Context // of the current round
+
+	numRound      int32
+	bytesSent     int64
+	bytesReceived int64
+	data          []*pb.OuterStruct
+	dataLock      sync.Mutex
+	transport     Transport
+
+	roundDurations []time.Duration
+	transcript     hash.Hash // of the messages of all rounds so far
+
+	rounds      []Round // of the current Run, for checking accusations
+	accusations chan *pb.OuterStruct
+
+	echoes           []*pb.OuterStruct // of the current round
+	receivedEchoChan chan int32
+
+	checkpoint     *checkpoint // nil without checkpoints
+	checkpointLock sync.Mutex
+
+	/*
+	 * These are here so that protobuf data, if received before we have moved
+	 * onto the next round, just wait in the channel until we are ready.
+	 */
+	receivedIdChan          chan int32
 	readyToReceiveNextRound *sync.Cond
 	numRoundLock            sync.Mutex
-)
-
-var (
-	hostsFileName = flag.String("hosts", "../hosts.auc", "JSON file with lists of hosts to communicate with")
-)
-
-// server is used to implement lib_pb.ZKPAuctionServer
-type server struct{}
-
-func (s *server) Publish(ctx context.Context, in *pb.OuterStruct) (*google_protobuf.Empty, error) {
-	go func() {
-		clientsReady.Do(func() {
-			<-isReady
-		})
-		numRoundLock.Lock()
-
-		for {
-			if in.Stepid == numRound {
-				break
-			}
-			readyToReceiveNextRound.Wait()
-		}
-
-		dataLock.Lock()
-		data[in.Clientid] = in
-		dataLock.Unlock()
-		log.Printf("RECEIVED DATA FOR ROUND ***************************** %v, Client id: %v", in.Stepid, in.Clientid)
-
-		numRoundLock.Unlock()
-		
-		// r := reflect.ValueOf(in)
-		// inSize := int64(binary.Size(r))
-
-		inSize := int64(unsafe.Sizeof(*in))
-		fmt.Println("SIZE: %v", inSize)
-		_ = atomic.AddInt64(&bytesReceived, inSize) 
-
-		receivedIdChan <- in.Clientid
-	}()
-
-	return &google_protobuf.Empty{}, nil
 }
 
-// Listens for connections; meant to be run in a goroutine
-func RunServer(localHost string) {
-	lis, err := net.Listen("tcp", localHost)
-	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
-	}
-
-	// Get options
-	var opts []grpc.ServerOption
-	cert := getServerCertificate()
-	opts = []grpc.ServerOption{grpc.Creds(cert)}
-
-	s := grpc.NewServer(opts...)
-	lib_pb.RegisterZKPAuctionServer(s, &server{})
-	if err := s.Serve(lis); err != nil {
-		log.Fatalf("failed to serve: %v", err)
+// NewSession returns the session of party config.MyID in the auction
+// described by config, which talks to the other parties over transport.
+func NewSession(config *AuctionConfig, transport Transport) *Session {
+	s := &Session{
+		config:         config,
+		id:             config.MyID,
+		data:           make([]*pb.OuterStruct, len(config.Hosts)),
+		transport:      transport,
+		transcript:     sha256.New(),
+		accusations:    make(chan *pb.OuterStruct),
+		receivedIdChan: make(chan int32),
+
+		echoes:           make([]*pb.OuterStruct, len(config.Hosts)),
+		receivedEchoChan: make(chan int32),
 	}
+	s.readyToReceiveNextRound = sync.NewCond(&s.numRoundLock)
+	return s
 }
 
-func GetHostsAndID() ([]string, int) {
-	hostsFile, err := os.Open(*hostsFileName)
-	if err != nil {
-		log.Fatalf("Error opening hosts file: %v", err)
-	}
-
-	var hosts struct {
-		Hosts []string `json:"hosts"`
-		MyID  int      `json:"myID"`
-	}
-
-	if err = json.NewDecoder(hostsFile).Decode(&hosts); err != nil {
-		log.Fatalf("Error opening hosts file: %v", err)
-	}
-
-	return hosts.Hosts, hosts.MyID
+// ID returns the id of this party.
+func (s *Session) ID() int {
+	return s.id
 }
 
-func getRootCertificate() []byte {
-	cert, err := ioutil.ReadFile("../certs/ca.cert")
-	if err != nil {
-		log.Fatalf("Could not load root CA certificate.")
-	}
-
-	return cert
+// NumParties returns the number of parties in the auction.
+func (s *Session) NumParties() int {
+	return len(s.config.Hosts)
 }
 
-func getClientCertificate() credentials.TransportCredentials {
-	// Create CA cert pool
-	caCert := getRootCertificate()
-	caPool := x509.NewCertPool()
-	caPool.AppendCertsFromPEM(caCert)
-
-	certFileName := fmt.Sprintf("../certs/%v.cert", id)
-	keyFileName := fmt.Sprintf("../certs/%v.key", id)
-	myCert, err := tls.LoadX509KeyPair(certFileName, keyFileName)
-	if err != nil {
-		log.Fatalf("Could not load client TLS certificate: %v", err)
+// deliver stores a message received from another party, once we have
+// reached its round. It is the DeliverFn given to the transport. With
+// checkpoints, the message is in the checkpoint by the time deliver returns.
+func (s *Session) deliver(in *pb.OuterStruct) error {
+	if in.Clientid < 0 || int(in.Clientid) >= len(s.data) {
+		return fmt.Errorf("unknown client id %v", in.Clientid)
 	}
 
-	return credentials.NewTLS(&tls.Config{
-		Certificates: []tls.Certificate{myCert},
-		RootCAs:      caPool,
-	})
+	if err := s.checkpointMessage(in); err != nil {
+		return err
+	}
+	s.dispatch(in)
+	return nil
 }
 
-func getServerCertificate() credentials.TransportCredentials {
-	// Create CA cert pool
-	caCert := getRootCertificate()
-	caPool := x509.NewCertPool()
-	caPool.AppendCertsFromPEM(caCert)
-
-	certFile := fmt.Sprintf("../certs/%v.cert", id)
-	keyFile := fmt.Sprintf("../certs/%v.key", id)
-	myCert, err := tls.LoadX509KeyPair(certFile, keyFile)
-	if err != nil {
-		log.Fatalf("Could not load server TLS certificate: %v", err)
+// dispatch hands in to the round it belongs to. A message of a round we
+// already have a message of the same party of is a copy sent again by a
+// resumed party, and ignored.
+func (s *Session) dispatch(in *pb.OuterStruct) {
+	if in.Stepid == accusationStep {
+		go func() {
+			s.accusations <- in
+		}()
+		return
 	}
 
-	return credentials.NewTLS(&tls.Config{
-		Certificates: []tls.Certificate{myCert},
-		ClientCAs:    caPool,
-		ClientAuth:   tls.RequireAndVerifyClientCert,
-	})
-}
+	// Echoes belong to the round they echo
+	step, data, received := in.Stepid, s.data, s.receivedIdChan
+	if in.Stepid < accusationStep {
+		step, data, received = echoedStep(in.Stepid), s.echoes, s.receivedEchoChan
+	}
 
-func InitClients(hosts []string, myAddr string) {
-	fmt.Println("Initializing clients!")
-	// generate clients sequentially, not so bad
-	for i, host := range hosts {
+	go func() {
+		s.numRoundLock.Lock()
 
-		if host == myAddr {
-			continue
+		for {
+			if step == s.numRound {
+				break
+			}
+			s.readyToReceiveNextRound.Wait()
 		}
 
-		// Get certificate
-		cert := getClientCertificate()
+		s.dataLock.Lock()
+		if prev := data[in.Clientid]; prev != nil && prev.Stepid == in.Stepid {
+			s.dataLock.Unlock()
+			s.numRoundLock.Unlock()
+			return
+		}
+		data[in.Clientid] = in
+		s.dataLock.Unlock()
+		log.Printf("RECEIVED DATA FOR ROUND ***************************** %v, Client id: %v", in.Stepid, in.Clientid)
 
-		// Configure options to Dial
-		var opts []grpc.DialOption
-		opts = append(opts, grpc.WithTransportCredentials(cert))
-		opts = append(opts, grpc.WithBackoffMaxDelay(1*time.Second))
-		opts = append(opts, grpc.WithBlock())
+		s.numRoundLock.Unlock()
 
-		// Set up a connection to the server.
-		conn, err := grpc.Dial(host, opts...)
-		if err != nil {
-			log.Fatalf("Did not connect (to host %v): %v", host, err)
-		}
+		inSize := int64(proto.Size(in))
+		log.Printf("SIZE: %v", inSize)
+		_ = atomic.AddInt64(&s.bytesReceived, inSize)
 
-		c := lib_pb.NewZKPAuctionClient(conn)
+		received <- in.Clientid
+	}()
+}
 
-		clients = append(clients, c)
+// Connect connects to all the other parties over the session's transport,
+// within the ConnectTimeout of the configuration. With a CheckpointFile in
+// the configuration, it then writes the first checkpoint, or with Resume,
+// loads the checkpoint to resume from.
+func (s *Session) Connect(ctx context.Context) error {
+	if s.config.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.ConnectTimeout)
+		defer cancel()
+	}
 
-		if i == 0 {
-			seller = c
-		}
+	// Messages may arrive as soon as we connect
+	if s.config.CheckpointFile != "" {
+		s.checkpoint = &checkpoint{AuctionID: s.config.AuctionID, MyID: s.id}
 	}
 
-	data = make([]*pb.OuterStruct, len(clients)+1)
+	if err := s.transport.Connect(ctx, s.deliver); err != nil {
+		return err
+	}
 
-	readyToReceiveNextRound = sync.NewCond(&numRoundLock)
+	switch {
+	case s.checkpoint == nil:
+		return nil
+	case s.config.Resume:
+		return s.loadCheckpoint()
+	default:
+		s.checkpointLock.Lock()
+		defer s.checkpointLock.Unlock()
+		return s.writeCheckpoint()
+	}
+}
 
-	isReady <- struct{}{}
+// Close disconnects from the other parties.
+func (s *Session) Close() error {
+	return s.transport.Close()
 }
 
 type Round struct {
@@ -226,42 +189,85 @@ type Round struct {
 	Receive ReceiveFn
 }
 
-type ComputeFn func(interface{}) (proto.Message, bool)
+// The callbacks of a round may return errors made with NewError to blame
+// another party; any other error is taken to be a LocalError, except that
+// errors returned by a CheckFn blame the party whose message was checked.
+type ComputeFn func(interface{}) (proto.Message, bool, error)
 type CheckFn func(interface{}, *pb.OuterStruct) error
-type ReceiveFn func(interface{}, []*pb.OuterStruct)
+type ReceiveFn func(interface{}, []*pb.OuterStruct) error
 
-func marshalData(result proto.Message) (r []byte) {
-	r, err := proto.Marshal(result)
+func marshalData(result proto.Message) (r []byte, err error) {
+	r, err = proto.Marshal(result)
 	if err != nil {
-		log.Fatalf("Could not marshal data %v", result)
+		err = fmt.Errorf("Could not marshal data %v: %v", result, err)
 	}
 	return
 }
 
-func PublishAll(out *pb.OuterStruct) {
-	// Publish data to all clients
-	for _, client := range clients {
-		client := client
+// PublishAll sends out to all other parties, and returns once they have all
+// received it. It is meant to be called by the callbacks of the rounds. Our
+// own messages are signed; those of other parties, which the seller relays,
+// keep the signatures of their senders.
+func (s *Session) PublishAll(out *pb.OuterStruct) error {
+	if int(out.Clientid) == s.id {
+		if err := s.sign(out); err != nil {
+			return err
+		}
+	}
+	return s.publishAll(s.ctx, out)
+}
+
+func (s *Session) publishAll(ctx context.Context, out *pb.OuterStruct) error {
+	var wg sync.WaitGroup
+	errs := make([]error, s.NumParties())
+
+	// Publish data to all clients in parallel
+	for i := 0; i < s.NumParties(); i++ {
+		if i == s.id {
+			continue
+		}
+
+		i := i
+		wg.Add(1)
 		go func() {
+			defer wg.Done()
 
-			// Needs to be a goroutine because otherwise we block waiting for a response
-			log.Printf("ID:%v Publishing to clientid:%v for Round:%v", id, out.Clientid, out.Stepid)
-			_, err := client.Publish(context.Background(), out)
+			log.Printf("ID:%v Publishing to clientid:%v for Round:%v", s.id, out.Clientid, out.Stepid)
+			err := s.transport.Send(ctx, i, out)
 			if err != nil {
-				log.Fatalf("Error on sending data: %v", err)
+				errs[i] = &RoundError{
+					Round:    int(out.Stepid),
+					Clientid: i,
+					Kind:     TransportError,
+					Err:      fmt.Errorf("Error on sending data: %v", err),
+				}
 			}
 		}()
 	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func checkAll(state interface{}, check CheckFn) {
+// checkAll checks the messages of all other parties for the current round,
+// as they arrive in data with their ids on received, and returns the first
+// error.
+func (s *Session) checkAll(ctx context.Context, state interface{}, check CheckFn, received chan int32, data []*pb.OuterStruct) error {
 	var wg sync.WaitGroup
-	wg.Add(len(clients))
+
+	var errLock sync.Mutex
+	var firstErr error
 
 	clientsReceiving := make(map[int32]bool)
 
-	for i := 0; i <= len(clients); i++ {
-		if i == id {
+	for i := 0; i < s.NumParties(); i++ {
+		if i == s.id {
 			continue
 		}
 		clientsReceiving[int32(i)] = true
@@ -270,86 +276,328 @@ func checkAll(state interface{}, check CheckFn) {
 	log.Printf("Preparing to Receive from %v", len(clientsReceiving))
 	for len(clientsReceiving) != 0 {
 
-		idx := <-receivedIdChan
+		var idx int32
+		select {
+		case idx = <-received:
+		case accusation := <-s.accusations:
+			wg.Wait()
+			return s.checkAccusation(state, accusation)
+		case <-ctx.Done():
+			wg.Wait()
+			if firstErr != nil {
+				return firstErr
+			}
+			return abortError(ctx, clientsReceiving)
+		}
 
-		if int32(id) == idx {
+		if !clientsReceiving[idx] {
 			continue
 		}
+		delete(clientsReceiving, idx)
 
-		dataLock.Lock()
+		s.dataLock.Lock()
 		result := data[idx]
-		dataLock.Unlock()
+		s.dataLock.Unlock()
 
 		log.Printf("Checking client id %v", idx)
 
+		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			err := check(state, result)
-			if err != nil {
-				log.Fatalf("Error!!!")
+
+			// A message that is not signed by its sender proves nothing
+			// against it, so it is not evidence
+			var e *RoundError
+			if err := s.verify(result); err != nil {
+				e = err.(*RoundError)
+			} else if err := check(state, result); err != nil {
+				var ok bool
+				e, ok = err.(*RoundError)
+				if !ok {
+					e = &RoundError{Clientid: int(result.Clientid), Kind: ProofError, Err: err}
+				}
+				if e.Clientid == int(result.Clientid) && e.Evidence == nil {
+					e.Evidence, e.Accuser = result, s.id
+				}
+			} else {
+				return
+			}
+
+			errLock.Lock()
+			if firstErr == nil {
+				firstErr = e
 			}
+			errLock.Unlock()
 		}()
 
-		_, ok := clientsReceiving[idx]
-		if ok {
-			delete(clientsReceiving, idx)
-		}
 		log.Printf("Remaining to receive from %v clients", len(clientsReceiving))
 	}
 
 	wg.Wait()
+
+	return firstErr
+}
+
+// abortError returns the error of a round that ended with ctx before the
+// messages of the parties in missing arrived.
+func abortError(ctx context.Context, missing map[int32]bool) error {
+	if ctx.Err() != context.DeadlineExceeded {
+		return ctx.Err()
+	}
+
+	var ids []int
+	for id := range missing {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+
+	e := &RoundError{
+		Clientid: NoClient,
+		Kind:     TimeoutError,
+		Missing:  ids,
+		Err:      fmt.Errorf("no message from parties %v before the deadline", ids),
+	}
+	if len(ids) == 1 {
+		e.Clientid = ids[0]
+	}
+	return e
 }
 
-func Register(rounds []Round, state interface{}) {
-	for _, round := range rounds {
-		result, sendToSeller := round.Compute(state)
+// Run runs the rounds of a protocol with the other parties, which must have
+// been connected to with Connect. It returns a *RoundError if a round
+// fails, including when ctx is done first. Every round must end within the
+// RoundTimeout of the configuration, and the whole run within its Timeout;
+// a round that does not fails with a TimeoutError naming the parties whose
+// messages are missing.
+//
+// With Echo in the configuration, every round ends with an exchange of
+// what each party received in it, which fails the round with an
+// EquivocationError if another party sent different messages to different
+// parties.
+//
+// After the last round, every party acknowledges to all others that it has
+// everything it needs, so once Run returns successfully, nobody will send
+// us anything anymore and the session may be closed.
+//
+// With a CheckpointFile in the configuration, the state must be a
+// Checkpointer, and Run checkpoints every round once it has computed our
+// message of it. With Resume, Run continues from the round of the
+// checkpoint loaded by Connect, sending our message of it again. Once Run
+// returns successfully, the checkpoint is removed.
+func (s *Session) Run(ctx context.Context, rounds []Round, state interface{}) error {
+	if s.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.Timeout)
+		defer cancel()
+	}
+	s.rounds = append(append([]Round(nil), rounds...), ackRound())
+
+	if s.checkpoint != nil {
+		if _, ok := state.(Checkpointer); !ok {
+			return &RoundError{Round: 1, Clientid: NoClient, Kind: LocalError,
+				Err: fmt.Errorf("cannot checkpoint a %T", state)}
+		}
+	}
+
+	// Resume from the round of the checkpoint, if any
+	first, resumed := 1, s.resumed()
+	if resumed != nil {
+		first = int(resumed.Step)
+	}
+
+	for step := first; step <= len(s.rounds); step++ {
+		start := time.Now()
 
-		var mData []byte
-		if result == nil {
-			mData = []byte{}
+		var out *pb.OuterStruct
+		var err error
+		if step == first && resumed != nil {
+			out, err = s.resumeRound(ctx, s.rounds[step-1], state, resumed)
 		} else {
-			mData = marshalData(result)
+			out, err = s.runRound(ctx, s.rounds[step-1], state)
 		}
-		out := &pb.OuterStruct{
-			Clientid: int32(id),
-			Stepid:   numRound + 1,
-			Data:     mData,
+		if err != nil {
+			return err
 		}
 
-		// Now that we've computed and marshalled
-		// tell everyone we can receive stuff from the next round
-		numRoundLock.Lock()
-		numRound++
-		readyToReceiveNextRound.Broadcast()
-		numRoundLock.Unlock()
-			
-		outSize := int64(unsafe.Sizeof(*out))
-
-		if sendToSeller {
-			if id != 0 {
-				log.Printf("Sending to Seller")
-				_, err := seller.Publish(context.Background(), out)
-				if err != nil {
-					log.Fatalf("Error on sending data to seller: %v", err)
-				}
+		// The acknowledgements are not part of the auction
+		if step <= len(rounds) {
+			s.hashRound(out)
+			s.roundDurations = append(s.roundDurations, time.Since(start))
+		}
+	}
+
+	s.removeCheckpoint()
+	return nil
+}
+
+// ackRound returns the round of acknowledgements that ends every run.
+func ackRound() Round {
+	return Round{
+		Compute: func(state interface{}) (proto.Message, bool, error) {
+			return nil, false, nil
+		},
+		Check: func(state interface{}, result *pb.OuterStruct) error {
+			if len(result.Data) != 0 {
+				return NewError(DecodeError, int(result.Clientid), "Acknowledgement is not empty")
 			}
-		} else {
-			log.Printf("Publishing %v", round, id)
-			outSize = outSize * int64(len(clients))
-			PublishAll(out)
+			return nil
+		},
+		Receive: func(state interface{}, results []*pb.OuterStruct) error {
+			log.Printf("Everyone is done")
+			return nil
+		},
+	}
+}
+
+// runRound runs the next round, and returns our message of it.
+func (s *Session) runRound(ctx context.Context, round Round, state interface{}) (*pb.OuterStruct, error) {
+	step := int(s.numRound + 1)
+
+	if s.config.RoundTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.RoundTimeout)
+		defer cancel()
+	}
+	s.ctx = ctx
+
+	result, sendToSeller, err := round.Compute(state)
+	if err != nil {
+		return nil, roundError(step, err)
+	}
+
+	var mData []byte
+	if result == nil {
+		mData = []byte{}
+	} else {
+		mData, err = marshalData(result)
+		if err != nil {
+			return nil, roundError(step, err)
 		}
+	}
+	out := &pb.OuterStruct{
+		Clientid: int32(s.id),
+		Stepid:   int32(step),
+		Data:     mData,
+	}
+	if err := s.sign(out); err != nil {
+		return nil, roundError(step, err)
+	}
 
-		_ = atomic.AddInt64(&bytesSent, outSize)
-		checkAll(state, round.Check)
-		round.Receive(state, data)
+	// Once out may have been sent, we must not compute another one
+	if err := s.saveCheckpoint(step, out, sendToSeller, state); err != nil {
+		return nil, roundError(step, err)
 	}
+
+	return s.finishRound(ctx, step, round, state, out, sendToSeller)
 }
 
-func DisplayData() {
-	fmt.Println("Bytes Sent: %v", bytesSent)
-	fmt.Println("Bytes Received: %v", bytesReceived)
+// resumeRound runs the round of the checkpoint cp again, from the state in
+// which we computed our message of it, and returns that message.
+func (s *Session) resumeRound(ctx context.Context, round Round, state interface{}, cp *checkpoint) (*pb.OuterStruct, error) {
+	step := int(cp.Step)
+
+	if s.config.RoundTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.RoundTimeout)
+		defer cancel()
+	}
+	s.ctx = ctx
+
+	if err := s.restoreCheckpoint(cp, state); err != nil {
+		return nil, roundError(step, err)
+	}
+	log.Printf("Resuming round %v as %v", step, s.id)
+
+	s.numRoundLock.Lock()
+	s.numRound = int32(step - 1)
+	s.numRoundLock.Unlock()
+
+	return s.finishRound(ctx, step, round, state, cp.Out, cp.SendToSeller)
 }
 
-func Init(id_ int) {
-	id = id_
+// finishRound sends out, our message of the round, and then checks and
+// receives the messages of the others. It returns out.
+func (s *Session) finishRound(ctx context.Context, step int, round Round, state interface{}, out *pb.OuterStruct, sendToSeller bool) (*pb.OuterStruct, error) {
+	// Now that we've computed and marshalled
+	// tell everyone we can receive stuff from the next round
+	s.numRoundLock.Lock()
+	s.numRound++
+	s.readyToReceiveNextRound.Broadcast()
+	s.numRoundLock.Unlock()
+
+	outSize := int64(proto.Size(out))
+
+	if sendToSeller {
+		if s.id != 0 {
+			log.Printf("Sending to Seller")
+			err := s.transport.Send(ctx, 0, out)
+			if err != nil {
+				return nil, &RoundError{Round: step, Clientid: 0, Kind: TransportError,
+					Err: fmt.Errorf("Error on sending data to seller: %v", err)}
+			}
+		}
+	} else {
+		log.Printf("Publishing round %v as %v", step, s.id)
+		outSize = outSize * int64(s.NumParties()-1)
+		if err := s.publishAll(ctx, out); err != nil {
+			return nil, err
+		}
+	}
+
+	_ = atomic.AddInt64(&s.bytesSent, outSize)
+	if err := s.checkAll(ctx, state, round.Check, s.receivedIdChan, s.data); err != nil {
+		return nil, s.accuse(ctx, roundError(step, err))
+	}
+	if err := round.Receive(state, s.data); err != nil {
+		return nil, s.accuse(ctx, roundError(step, err))
+	}
+
+	// The acknowledgements of the last round need no echo
+	if s.config.Echo && step < len(s.rounds) {
+		if err := s.echo(ctx, step, out, state); err != nil {
+			return nil, s.accuse(ctx, roundError(step, err))
+		}
+	}
+
+	return out, nil
+}
+
+// hashRound adds the messages of the round just finished to the transcript
+// hash, with out as our own message.
+func (s *Session) hashRound(out *pb.OuterStruct) {
+	s.dataLock.Lock()
+	defer s.dataLock.Unlock()
+
+	msgs := append([]*pb.OuterStruct(nil), s.data...)
+	msgs[s.id] = out
+	hashMessages(s.transcript, msgs)
+}
+
+// hashMessages adds the messages of a round, by client id, to the
+// transcript hash h.
+func hashMessages(h hash.Hash, msgs []*pb.OuterStruct) {
+	for i, msg := range msgs {
+		if msg == nil {
+			continue
+		}
+
+		var header [12]byte
+		binary.BigEndian.PutUint32(header[0:], uint32(msg.Stepid))
+		binary.BigEndian.PutUint32(header[4:], uint32(i))
+		binary.BigEndian.PutUint32(header[8:], uint32(len(msg.Data)))
+		h.Write(header[:])
+		h.Write(msg.Data)
+	}
+}
+
+// Result returns the statistics of the rounds run so far, for the protocol
+// to fill in the winners and the price.
+func (s *Session) Result() *Result {
+	return &Result{
+		AuctionID:      s.config.AuctionID,
+		RoundDurations: append([]time.Duration(nil), s.roundDurations...),
+		BytesSent:      atomic.LoadInt64(&s.bytesSent),
+		BytesReceived:  atomic.LoadInt64(&s.bytesReceived),
+		TranscriptHash: hex.EncodeToString(s.transcript.Sum(nil)),
+	}
 }
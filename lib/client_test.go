@@ -0,0 +1,167 @@
+package lib
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// sumState is the state of a toy protocol, where every party publishes a
+// number each round and adds up everyone's numbers.
+type sumState struct {
+	id  int
+	sum int32
+}
+
+func sumRound() Round {
+	return Round{
+		Compute: func(state interface{}) (proto.Message, bool, error) {
+			s := state.(*sumState)
+			return &pb.OuterStruct{Clientid: int32(s.id + 1)}, false, nil
+		},
+		Check: func(state interface{}, result *pb.OuterStruct) error {
+			var msg pb.OuterStruct
+			if err := proto.Unmarshal(result.Data, &msg); err != nil {
+				return err
+			}
+			if msg.Clientid != result.Clientid+1 {
+				return fmt.Errorf("wrong number %v", msg.Clientid)
+			}
+			return nil
+		},
+		Receive: func(state interface{}, results []*pb.OuterStruct) error {
+			s := state.(*sumState)
+			s.sum += int32(s.id + 1)
+			for i, result := range results {
+				if i == s.id {
+					continue
+				}
+				var msg pb.OuterStruct
+				if err := proto.Unmarshal(result.Data, &msg); err != nil {
+					return err
+				}
+				s.sum += msg.Clientid
+			}
+			return nil
+		},
+	}
+}
+
+func TestMemoryTransport(test *testing.T) {
+	const n, numRounds = 5, 3
+
+	hosts := make([]string, n)
+	network := NewMemoryNetwork(n)
+	states := make([]*sumState, n)
+	sessions := make([]*Session, n)
+	errs := make([]error, n)
+
+	var rounds []Round
+	for i := 0; i < numRounds; i++ {
+		rounds = append(rounds, sumRound())
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		i := i
+		states[i] = &sumState{id: i}
+		config := &AuctionConfig{Hosts: hosts, MyID: i}
+		session := NewSession(config, network.Transport(i))
+		sessions[i] = session
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := context.Background()
+			if errs[i] = session.Connect(ctx); errs[i] != nil {
+				return
+			}
+			defer session.Close()
+			errs[i] = session.Run(ctx, rounds, states[i])
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		if errs[i] != nil {
+			test.Fatalf("Party %v failed: %v", i, errs[i])
+		}
+		if want := int32(numRounds * n * (n + 1) / 2); states[i].sum != want {
+			test.Errorf("Party %v got sum %v, want %v", i, states[i].sum, want)
+		}
+
+		result := sessions[i].Result()
+		if len(result.RoundDurations) != numRounds {
+			test.Errorf("Party %v timed %v rounds, want %v", i, len(result.RoundDurations), numRounds)
+		}
+		if want := sessions[0].Result().TranscriptHash; result.TranscriptHash != want {
+			test.Errorf("Party %v got transcript hash %v, party 0 got %v", i, result.TranscriptHash, want)
+		}
+	}
+}
+
+// runSilent runs a round among n parties, of which the silent ones connect
+// but never send anything, and returns the errors of the others.
+func runSilent(n int, silent map[int]bool, roundTimeout, timeout time.Duration) []error {
+	hosts := make([]string, n)
+	network := NewMemoryNetwork(n)
+	errs := make([]error, n)
+	rounds := []Round{sumRound()}
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		i := i
+		config := &AuctionConfig{Hosts: hosts, MyID: i, RoundTimeout: roundTimeout, Timeout: timeout}
+		session := NewSession(config, network.Transport(i))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := context.Background()
+			if errs[i] = session.Connect(ctx); errs[i] != nil || silent[i] {
+				return
+			}
+			errs[i] = session.Run(ctx, rounds, &sumState{id: i})
+		}()
+	}
+	wg.Wait()
+
+	return errs
+}
+
+func TestTimeouts(test *testing.T) {
+	for _, tc := range []struct {
+		name                  string
+		silent                map[int]bool
+		roundTimeout, timeout time.Duration
+		client                int
+		missing               []int
+	}{
+		{"round timeout", map[int]bool{3: true}, 100 * time.Millisecond, 0, 3, []int{3}},
+		{"timeout", map[int]bool{1: true, 3: true}, 0, 100 * time.Millisecond, NoClient, []int{1, 3}},
+		{"both", map[int]bool{2: true}, time.Hour, 100 * time.Millisecond, 2, []int{2}},
+	} {
+		errs := runSilent(4, tc.silent, tc.roundTimeout, tc.timeout)
+
+		for i, err := range errs {
+			if tc.silent[i] {
+				continue
+			}
+			e, ok := err.(*RoundError)
+			if !ok {
+				test.Errorf("%v: party %v failed with %v, want a *RoundError", tc.name, i, err)
+				continue
+			}
+			if e.Kind != TimeoutError || e.Round != 1 || e.Clientid != tc.client || !reflect.DeepEqual(e.Missing, tc.missing) {
+				test.Errorf("%v: party %v failed with %v missing %v, want a %v blaming %v missing %v",
+					tc.name, i, e, e.Missing, TimeoutError, tc.client, tc.missing)
+			}
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package lib
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ashwinsr/auctions/zkp"
+)
+
+var (
+	hostsFileName  = flag.String("hosts", "../hosts.auc", "JSON file with lists of hosts to communicate with")
+	evidenceDir    = flag.String("evidence", "evidence", "Directory to write the evidence against cheating parties to")
+	transcript     = flag.String("transcript", "", "File to write the transcript of the auction to")
+	checkpointFile = flag.String("checkpoint", "", "File to checkpoint the auction to after every round, encrypted with the key of our certificate")
+	resume         = flag.Bool("resume", false, "Resume the auction from the file given by -checkpoint")
+
+	caCertFlag         = flag.String("ca", "", "PEM file with the certificate of the certificate authority (or $"+EnvCACert+")")
+	certFlag           = flag.String("cert", "", "PEM file with our certificate (or $"+EnvCert+")")
+	keyFlag            = flag.String("key", "", "PEM file with the key of our certificate (or $"+EnvKey+")")
+	pkcs12Flag         = flag.String("pkcs12", "", "PKCS#12 file with our certificate and key, instead of -cert and -key (or $"+EnvPKCS12+")")
+	pkcs12PasswordFlag = flag.String("pkcs12-password", "", "Password of the -pkcs12 file (or $"+EnvPKCS12Password+")")
+)
+
+// AuctionConfig is the auction configuration shared by every party,
+// as read from the hosts file.
+type AuctionConfig struct {
+	Hosts  []string
+	MyID   int
+	Seller string
+
+	// hostIDs are the ids of the hosts in the hosts file, if they changed
+	// as parties were excluded.
+	hostIDs []int
+
+	// AuctionID names this run of the auction. Every zero-knowledge proof
+	// is bound to it, so proofs cannot be replayed in another auction.
+	AuctionID string
+
+	// Protocol is the protocol the auction runs, such as "first_price".
+	Protocol string
+
+	// Group is the group every ElGamal encryption and proof of the
+	// auction is computed in.
+	Group zkp.Group
+
+	// Units is the number of identical units sold in a multi-unit
+	// auction.
+	Units int
+
+	// TieBreak says who wins a first price auction among the highest
+	// bidders.
+	TieBreak TieBreak
+
+	// Prices are the prices bidders may bid. The protocols only see the
+	// index of a bid in Prices.
+	Prices *PriceLadder
+
+	// ConnectTimeout bounds the time to connect to all other parties,
+	// RoundTimeout the time of every round, and Timeout the time of a whole
+	// run. Zero means no limit.
+	ConnectTimeout time.Duration
+	RoundTimeout   time.Duration
+	Timeout        time.Duration
+
+	// Fingerprints are the SHA-256 hashes of the certificates of the
+	// parties, by id, if the hosts file gives them.
+	Fingerprints [][]byte
+
+	// Deadline is the time by which the auction must be over, if not zero.
+	Deadline time.Time
+
+	// Credentials locate our TLS material, which the gRPC transport
+	// connects with.
+	Credentials Credentials
+
+	// LegacyIdentity accepts certificates that name no party, issued before
+	// the registrar named parties, for their host name or IP address alone.
+	LegacyIdentity bool
+
+	// Echo makes every round end with an exchange of what each party
+	// received, so that parties that send different messages to different
+	// parties are caught.
+	Echo bool
+
+	// EvidenceDir is the directory RunAuction writes the evidence against
+	// excluded parties to. If it is empty, the evidence is only logged.
+	EvidenceDir string
+
+	// TranscriptFile is the file RunAuction writes the transcript of the
+	// last run to. If it is empty, no transcript is written.
+	TranscriptFile string
+
+	// CheckpointFile is the file a Session checkpoints its run to, so that
+	// it can be resumed after a crash. If it is empty, there are no
+	// checkpoints. With Resume, the Session continues the run of the
+	// checkpoint instead of starting a new one.
+	CheckpointFile string
+	Resume         bool
+}
+
+// The timeouts of hosts files that do not set them
+const (
+	DefaultConnectTimeout = 2 * time.Minute
+	DefaultRoundTimeout   = 10 * time.Minute
+	DefaultTimeout        = 0
+)
+
+// TieBreak is a way to choose the winner among tied bidders.
+type TieBreak string
+
+const (
+	// TieBreakLowestID lets the tied bidder with the lowest id win.
+	TieBreakLowestID TieBreak = "lowest"
+	// TieBreakRandom lets the parties jointly choose a random order of
+	// the bidders, in which the first tied bidder wins.
+	TieBreakRandom TieBreak = "random"
+)
+
+// GetAuctionConfig reads the configuration of an auction of the given
+// protocol from the hosts file, as described at DescriptorVersion, the
+// flags and the environment.
+func GetAuctionConfig(protocol string) *AuctionConfig {
+	hostsFile, err := os.Open(*hostsFileName)
+	if err != nil {
+		log.Fatalf("Error opening hosts file: %v", err)
+	}
+	defer hostsFile.Close()
+
+	config, err := ReadAuctionConfig(hostsFile, protocol)
+	if err != nil {
+		log.Fatalf("Error in hosts file %v: %v", *hostsFileName, err)
+	}
+
+	// The flags come first, then the environment, then the hosts file,
+	// whose files are relative to it, as are the certificates the
+	// registrar issued in certs/ next to it
+	dir := filepath.Dir(*hostsFileName)
+	config.Credentials = mergeCredentials(
+		Credentials{
+			CACert:         *caCertFlag,
+			Cert:           *certFlag,
+			Key:            *keyFlag,
+			PKCS12:         *pkcs12Flag,
+			PKCS12Password: *pkcs12PasswordFlag,
+		},
+		envCredentials(),
+		config.Credentials.relativeTo(dir),
+		defaultCredentials(filepath.Join(dir, "certs"), config.MyID),
+	)
+
+	config.EvidenceDir = *evidenceDir
+	config.TranscriptFile = *transcript
+	config.CheckpointFile = *checkpointFile
+	config.Resume = *resume
+	return config
+}
+
+// HostID returns the id in the hosts file of party i of the auction, which
+// is larger than i once parties before it were excluded. Results report
+// the parties by these ids.
+func (c *AuctionConfig) HostID(i int) int {
+	if c.hostIDs == nil {
+		return i
+	}
+	return c.hostIDs[i]
+}
+
+// Excluding returns the configuration of the auction run again without
+// party id. The parties after id move down by one, and the auction gets a
+// new id.
+func (c *AuctionConfig) Excluding(id int) *AuctionConfig {
+	e := *c
+	e.Hosts = nil
+	e.hostIDs = nil
+	e.Fingerprints = nil
+	for i, host := range c.Hosts {
+		if i != id {
+			e.Hosts = append(e.Hosts, host)
+			e.hostIDs = append(e.hostIDs, c.HostID(i))
+			if c.Fingerprints != nil {
+				e.Fingerprints = append(e.Fingerprints, c.Fingerprints[i])
+			}
+		}
+	}
+	if c.MyID > id {
+		e.MyID--
+	}
+	e.AuctionID = fmt.Sprintf("%v-excluding-%v", c.AuctionID, c.HostID(id))
+	e.Resume = false
+	return &e
+}
+
+// parseTimeout parses a duration like "90s" or "5m", where "0" means no
+// limit. An empty string selects initial.
+func parseTimeout(s string, initial time.Duration) (time.Duration, error) {
+	if s == "" {
+		return initial, nil
+	}
+	timeout, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if timeout < 0 {
+		return 0, fmt.Errorf("%v is negative", s)
+	}
+	return timeout, nil
+}
+
+// defaultAuctionID derives an auction id from the parts of the configuration
+// that all parties share. Two auctions among the same hosts get the same
+// id, so hosts files should set "auctionID" to something unique.
+func defaultAuctionID(hosts []string, seller string, group zkp.Group) string {
+	t := zkp.NewTranscript("auctions/auction-id")
+	for _, host := range hosts {
+		t.AppendString("host", host)
+	}
+	t.AppendString("seller", seller)
+	t.AppendGroup(group)
+
+	id := t.Challenge("id", new(big.Int).Lsh(zkp.One, 128))
+	return fmt.Sprintf("%032x", id)
+}
+
+// checkGroup checks that protocol can run in group. The verifiable shuffle
+// of the millionaire protocol only exists for mod p groups.
+func checkGroup(protocol string, group zkp.Group) error {
+	if _, ok := group.(*zkp.GroupParams); !ok && protocol == "millionaire" {
+		return fmt.Errorf("the millionaire protocol needs a mod p group, not %v", group.Name())
+	}
+	return nil
+}
+
+// parseGroup accepts either the name of a group, e.g. "modp2048" or
+// "p256", or an object {"p": ..., "q": ..., "g": ..., "y": ...} of
+// hexadecimal numbers describing a mod p group. Missing group parameters
+// select zkp.DefaultGroupName.
+func parseGroup(raw json.RawMessage) (zkp.Group, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return zkp.NamedGroup(zkp.DefaultGroupName)
+	}
+
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		return zkp.NamedGroup(name)
+	}
+
+	var params struct {
+		P, Q, G, Y string
+	}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+
+	var ints [4]*big.Int
+	for i, field := range []struct {
+		name  string
+		value string
+	}{
+		{"p", params.P}, {"q", params.Q}, {"g", params.G}, {"y", params.Y},
+	} {
+		n, ok := new(big.Int).SetString(field.value, 16)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a hexadecimal number: %q", field.name, field.value)
+		}
+		ints[i] = n
+	}
+
+	return zkp.NewGroupParams(ints[0], ints[1], ints[2], ints[3])
+}
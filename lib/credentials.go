@@ -0,0 +1,215 @@
+package lib
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// Credentials locate the TLS material of a party: the certificate of the
+// authority that issued the certificates of all parties, and the party's
+// own certificate and key, either as PEM or bundled in a PKCS#12 file.
+// Every PEM entry is either the name of a file or the PEM itself.
+type Credentials struct {
+	CACert string
+	Cert   string
+	Key    string
+
+	// PKCS12 is the name of a PKCS#12 file with the certificate and the key
+	// of the party, and possibly the certificate of the authority, which
+	// CACert then need not give. It replaces Cert and Key.
+	PKCS12         string
+	PKCS12Password string
+}
+
+// The environment variables that locate the TLS material of a party, if
+// the flags do not.
+const (
+	EnvCACert         = "AUCTION_CA_CERT"
+	EnvCert           = "AUCTION_CERT"
+	EnvKey            = "AUCTION_KEY"
+	EnvPKCS12         = "AUCTION_PKCS12"
+	EnvPKCS12Password = "AUCTION_PKCS12_PASSWORD"
+)
+
+// envCredentials returns the credentials given by the environment.
+func envCredentials() Credentials {
+	return Credentials{
+		CACert:         os.Getenv(EnvCACert),
+		Cert:           os.Getenv(EnvCert),
+		Key:            os.Getenv(EnvKey),
+		PKCS12:         os.Getenv(EnvPKCS12),
+		PKCS12Password: os.Getenv(EnvPKCS12Password),
+	}
+}
+
+// defaultCredentials returns the credentials of party id in dir, as the
+// registrar names them: ca.cert, <id>.cert and <id>.key.
+func defaultCredentials(dir string, id int) Credentials {
+	return Credentials{
+		CACert: filepath.Join(dir, "ca.cert"),
+		Cert:   filepath.Join(dir, fmt.Sprintf("%v.cert", id)),
+		Key:    filepath.Join(dir, fmt.Sprintf("%v.key", id)),
+	}
+}
+
+// mergeCredentials returns the entries of the first of sources that gives
+// them. The certificate and the key come from the same source, which is
+// the first to give either or a PKCS#12 file, so that a bundle given first
+// is not mixed with PEM files given later.
+func mergeCredentials(sources ...Credentials) Credentials {
+	var c Credentials
+	for _, s := range sources {
+		if c.CACert == "" {
+			c.CACert = s.CACert
+		}
+		if c.PKCS12Password == "" {
+			c.PKCS12Password = s.PKCS12Password
+		}
+	}
+	for _, s := range sources {
+		if s.Cert != "" || s.Key != "" || s.PKCS12 != "" {
+			c.Cert, c.Key, c.PKCS12 = s.Cert, s.Key, s.PKCS12
+			break
+		}
+	}
+	return c
+}
+
+// relativeTo returns c with the names of relative files taken as relative
+// to dir.
+func (c Credentials) relativeTo(dir string) Credentials {
+	for _, name := range []*string{&c.CACert, &c.Cert, &c.Key, &c.PKCS12} {
+		if *name != "" && !isPEM(*name) && !filepath.IsAbs(*name) {
+			*name = filepath.Join(dir, *name)
+		}
+	}
+	return c
+}
+
+// isPEM reports whether source is PEM rather than the name of a file.
+func isPEM(source string) bool {
+	return strings.HasPrefix(strings.TrimSpace(source), "-----BEGIN")
+}
+
+// readPEM returns the PEM of source, reading it from the file it names
+// unless it is PEM itself.
+func readPEM(source string) ([]byte, error) {
+	if isPEM(source) {
+		return []byte(source), nil
+	}
+	return os.ReadFile(source)
+}
+
+// describe names source in errors, without printing PEM.
+func describe(source string) string {
+	if isPEM(source) {
+		return "PEM"
+	}
+	return source
+}
+
+// certSource names where our certificate comes from in errors.
+func (c *Credentials) certSource() string {
+	if c.PKCS12 != "" {
+		return c.PKCS12
+	}
+	return describe(c.Cert)
+}
+
+// load loads our certificate and the pool of the certificate authority.
+func (c *Credentials) load() (tls.Certificate, *x509.CertPool, error) {
+	var cert tls.Certificate
+	pool := x509.NewCertPool()
+
+	if c.PKCS12 != "" {
+		data, err := os.ReadFile(c.PKCS12)
+		if err != nil {
+			return cert, nil, fmt.Errorf("Could not load TLS certificate: %v", err)
+		}
+		key, leaf, caCerts, err := pkcs12.DecodeChain(data, c.PKCS12Password)
+		if err != nil {
+			return cert, nil, fmt.Errorf("Could not load TLS certificate from %v: %v", c.PKCS12, err)
+		}
+		cert = tls.Certificate{Certificate: [][]byte{leaf.Raw}, PrivateKey: key, Leaf: leaf}
+		for _, ca := range caCerts {
+			pool.AddCert(ca)
+		}
+		if c.CACert == "" {
+			if len(caCerts) == 0 {
+				return cert, nil, fmt.Errorf("No root CA certificate in %v, nor given otherwise", c.PKCS12)
+			}
+			return cert, pool, nil
+		}
+	} else {
+		certPEM, err := readPEM(c.Cert)
+		if err != nil {
+			return cert, nil, fmt.Errorf("Could not load TLS certificate: %v", err)
+		}
+		keyPEM, err := readPEM(c.Key)
+		if err != nil {
+			return cert, nil, fmt.Errorf("Could not load TLS key: %v", err)
+		}
+		if cert, err = tls.X509KeyPair(certPEM, keyPEM); err != nil {
+			return cert, nil, fmt.Errorf("Could not load TLS certificate %v with key %v: %v", describe(c.Cert), describe(c.Key), err)
+		}
+	}
+
+	caCert, err := readPEM(c.CACert)
+	if err != nil {
+		return cert, nil, fmt.Errorf("Could not load root CA certificate: %v", err)
+	}
+	if !pool.AppendCertsFromPEM(caCert) {
+		return cert, nil, fmt.Errorf("No root CA certificate in %v", describe(c.CACert))
+	}
+	return cert, pool, nil
+}
+
+// PartyURI returns the URI that names the party serving on host in its
+// certificate, which ties the certificate to the host id of the party
+// rather than only to its IP address or host name.
+func PartyURI(host string) *url.URL {
+	return &url.URL{Scheme: "auction", Host: host}
+}
+
+// checkIdentity checks that cert, presented by the party we dialed as host
+// id, is for that host: for its IP address or host name, and for the party
+// serving on its port, which the certificate must name as the registrar
+// does. Certificates that name no party are for any port of the host, so
+// they are only accepted with c.LegacyIdentity.
+func (c *AuctionConfig) checkIdentity(id int, cert *x509.Certificate) error {
+	host := c.Hosts[id]
+	name, _, err := net.SplitHostPort(host)
+	if err != nil {
+		return err
+	}
+	if err := cert.VerifyHostname(name); err != nil {
+		return err
+	}
+
+	var parties []string
+	for _, uri := range cert.URIs {
+		if uri.Scheme == "auction" {
+			parties = append(parties, uri.Host)
+		}
+	}
+	if len(parties) == 0 {
+		if c.LegacyIdentity {
+			return nil
+		}
+		return fmt.Errorf("the certificate names no party, which only \"legacyIdentity\" hosts files accept")
+	}
+	for _, party := range parties {
+		if party == host {
+			return nil
+		}
+	}
+	return fmt.Errorf("the certificate is for party %v, not %v", strings.Join(parties, ", "), host)
+}
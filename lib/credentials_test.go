@@ -0,0 +1,223 @@
+package lib
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// newTestCertificate issues a certificate for the given IP address and
+// party URIs with parent and parentKey, or a self-signed CA certificate if
+// parent is nil.
+func newTestCertificate(test *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, ip string, uris ...*url.URL) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		test.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: ip},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		URIs:         uris,
+	}
+	if parent == nil {
+		template.Subject.CommonName = "Test CA"
+		template.KeyUsage = x509.KeyUsageCertSign
+		template.BasicConstraintsValid, template.IsCA = true, true
+		parent, parentKey = template, key
+	} else {
+		template.IPAddresses = []net.IP{net.ParseIP(ip)}
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		test.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		test.Fatal(err)
+	}
+	return cert, key
+}
+
+func certPEM(cert *x509.Certificate) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+}
+
+func keyPEM(test *testing.T, key *ecdsa.PrivateKey) string {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		test.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+}
+
+func TestCredentials(test *testing.T) {
+	ca, caKey := newTestCertificate(test, nil, nil, "")
+	cert, key := newTestCertificate(test, ca, caKey, "127.0.0.1")
+
+	dir := test.TempDir()
+	write := func(name string, data []byte) string {
+		name = filepath.Join(dir, name)
+		if err := os.WriteFile(name, data, 0600); err != nil {
+			test.Fatal(err)
+		}
+		return name
+	}
+	caFile := write("ca.cert", []byte(certPEM(ca)))
+	certFile := write("1.cert", []byte(certPEM(cert)))
+	keyFile := write("1.key", []byte(keyPEM(test, key)))
+
+	bundle, err := pkcs12.Modern.Encode(key, cert, []*x509.Certificate{ca}, "secret")
+	if err != nil {
+		test.Fatal(err)
+	}
+	bundleFile := write("1.p12", bundle)
+	bare, err := pkcs12.Modern.Encode(key, cert, nil, "secret")
+	if err != nil {
+		test.Fatal(err)
+	}
+	bareFile := write("bare.p12", bare)
+
+	for _, tc := range []struct {
+		name string
+		c    Credentials
+		err  string
+	}{
+		{"PEM files", Credentials{CACert: caFile, Cert: certFile, Key: keyFile}, ""},
+		{"in-memory PEM", Credentials{CACert: certPEM(ca), Cert: certPEM(cert), Key: keyPEM(test, key)}, ""},
+		{"a PKCS#12 bundle", Credentials{PKCS12: bundleFile, PKCS12Password: "secret"}, ""},
+		{"a PKCS#12 bundle without the CA", Credentials{CACert: caFile, PKCS12: bareFile, PKCS12Password: "secret"}, ""},
+
+		{"a missing CA", Credentials{PKCS12: bareFile, PKCS12Password: "secret"}, "No root CA certificate in " + bareFile},
+		{"a wrong password", Credentials{PKCS12: bundleFile, PKCS12Password: "guess"}, "Could not load TLS certificate from " + bundleFile},
+		{"a missing key", Credentials{CACert: caFile, Cert: certFile, Key: filepath.Join(dir, "0.key")}, "Could not load TLS key"},
+		{"a key for another certificate", Credentials{CACert: caFile, Cert: certPEM(cert), Key: keyPEM(test, caKey)}, "Could not load TLS certificate PEM with key PEM"},
+		{"no CA certificate", Credentials{CACert: keyFile, Cert: certFile, Key: keyFile}, "No root CA certificate in " + keyFile},
+	} {
+		got, pool, err := tc.c.load()
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				test.Errorf("Loading %v failed with %v, want %q", tc.name, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			test.Errorf("Failed to load %v: %v", tc.name, err)
+			continue
+		}
+		leaf, err := x509.ParseCertificate(got.Certificate[0])
+		if err != nil || !leaf.Equal(cert) {
+			test.Errorf("Loading %v got another certificate", tc.name)
+			continue
+		}
+		if _, err := leaf.Verify(x509.VerifyOptions{Roots: pool}); err != nil {
+			test.Errorf("Loading %v got a pool that does not verify the certificate: %v", tc.name, err)
+		}
+	}
+}
+
+func TestMergeCredentials(test *testing.T) {
+	flags := Credentials{Cert: "flag.cert", Key: "flag.key"}
+	env := Credentials{CACert: "env-ca.cert", PKCS12: "env.p12", PKCS12Password: "secret"}
+	hosts := Credentials{CACert: "hosts-ca.cert", Cert: "hosts.cert", Key: "hosts.key"}.relativeTo("/auctions")
+	defaults := defaultCredentials("/auctions/certs", 2)
+
+	for _, tc := range []struct {
+		name    string
+		sources []Credentials
+		want    Credentials
+	}{
+		{"flags first", []Credentials{flags, env, hosts, defaults},
+			Credentials{CACert: "env-ca.cert", Cert: "flag.cert", Key: "flag.key", PKCS12Password: "secret"}},
+		{"the environment next", []Credentials{{}, env, hosts, defaults},
+			Credentials{CACert: "env-ca.cert", PKCS12: "env.p12", PKCS12Password: "secret"}},
+		{"the hosts file next", []Credentials{{}, {}, hosts, defaults},
+			Credentials{CACert: "/auctions/hosts-ca.cert", Cert: "/auctions/hosts.cert", Key: "/auctions/hosts.key"}},
+		{"the defaults last", []Credentials{{}, {}, {}, defaults},
+			Credentials{CACert: "/auctions/certs/ca.cert", Cert: "/auctions/certs/2.cert", Key: "/auctions/certs/2.key"}},
+	} {
+		if got := mergeCredentials(tc.sources...); got != tc.want {
+			test.Errorf("%v: got %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+
+	pem := "-----BEGIN CERTIFICATE-----\n..."
+	if got := (Credentials{CACert: pem, Cert: "/abs.cert"}).relativeTo("/auctions"); got.CACert != pem || got.Cert != "/abs.cert" {
+		test.Errorf("Made PEM or absolute file names relative: %+v", got)
+	}
+}
+
+func TestCheckIdentity(test *testing.T) {
+	ca, caKey := newTestCertificate(test, nil, nil, "")
+	named, _ := newTestCertificate(test, ca, caKey, "127.0.0.1", PartyURI("127.0.0.1:9002"))
+	unnamed, _ := newTestCertificate(test, ca, caKey, "127.0.0.1")
+
+	hosts := []string{"127.0.0.1:9001", "127.0.0.1:9002", "10.0.0.1:9003"}
+	for _, tc := range []struct {
+		name   string
+		cert   *x509.Certificate
+		id     int
+		legacy bool
+		ok     bool
+	}{
+		{"the certificate of the party", named, 1, false, true},
+		{"the certificate of the party, in legacy mode", named, 1, true, true},
+		{"the certificate of another party on the same host", named, 0, false, false},
+		{"the certificate of another party on the same host, in legacy mode", named, 0, true, false},
+		{"a certificate for another host", named, 2, false, false},
+		{"a certificate naming no party, for the host", unnamed, 0, false, false},
+		{"a certificate naming no party, for the host, in legacy mode", unnamed, 0, true, true},
+		{"a certificate naming no party, for another host, in legacy mode", unnamed, 2, true, false},
+	} {
+		config := &AuctionConfig{Hosts: hosts, LegacyIdentity: tc.legacy}
+		if err := config.checkIdentity(tc.id, tc.cert); (err == nil) != tc.ok {
+			test.Errorf("Checking %v as party %v got %v", tc.name, tc.id, err)
+		}
+	}
+}
+
+func TestShippedCredentials(test *testing.T) {
+	f, err := os.Open("../hosts.auc")
+	if err != nil {
+		test.Fatal(err)
+	}
+	defer f.Close()
+	config, err := ReadAuctionConfig(f, "first_price")
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	creds := defaultCredentials("../certs", config.MyID)
+	cert, pool, err := creds.load()
+	if err != nil {
+		test.Fatalf("Failed to load the shipped credentials: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		test.Fatal(err)
+	}
+	// Parties serve and dial with the same certificate
+	for _, usage := range []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth} {
+		if _, err := leaf.Verify(x509.VerifyOptions{Roots: pool, KeyUsages: []x509.ExtKeyUsage{usage}}); err != nil {
+			test.Errorf("The shipped certificate does not verify with the shipped CA: %v", err)
+		}
+	}
+	if err := config.checkIdentity(config.MyID, leaf); err != nil {
+		test.Errorf("The shipped certificate is not the one of party %v of the shipped hosts file: %v", config.MyID, err)
+	}
+}
@@ -0,0 +1,262 @@
+package lib
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// DescriptorVersion is the version of the hosts file format described
+// below. Hosts files without a version are read as they were before there
+// were versions, with every entry but "hosts" optional and unknown entries
+// ignored.
+//
+// A hosts file of version 1 describes its auction completely:
+//
+//	{
+//	  "version": 1,
+//	  "auctionID": "...",                     unique to the auction
+//	  "protocol": "first_price",              the folder of the auction to run
+//	  "hosts": ["10.0.0.1:9001", ...],        by party id
+//	  "myID": 1,
+//	  "sellerID": 0,                          the seller is always party 0
+//	  "fingerprints": ["...", ...],           SHA-256 of every party's certificate
+//	  "deadline": "2017-03-25T18:00:00Z",     by which the auction must be over
+//	  ...                                     any of the optional entries
+//	}
+//
+// and unknown entries are errors, as they likely are misspelled ones.
+const DescriptorVersion = 1
+
+// descriptor is the content of a hosts file.
+type descriptor struct {
+	Version int `json:"version"`
+
+	AuctionID string   `json:"auctionID"`
+	Protocol  string   `json:"protocol"`
+	Hosts     []string `json:"hosts"`
+	MyID      int      `json:"myID"`
+	Seller    string   `json:"seller"`
+	SellerID  *int     `json:"sellerID"`
+
+	Fingerprints []string `json:"fingerprints"`
+	Deadline     string   `json:"deadline"`
+
+	Group    json.RawMessage `json:"group"`
+	Units    int             `json:"units"`
+	TieBreak TieBreak        `json:"tieBreak"`
+	Prices   json.RawMessage `json:"prices"`
+
+	ConnectTimeout string `json:"connectTimeout"`
+	RoundTimeout   string `json:"roundTimeout"`
+	Timeout        string `json:"timeout"`
+
+	Echo bool `json:"echo"`
+
+	LegacyIdentity bool `json:"legacyIdentity"`
+
+	CACert string `json:"caCert"`
+	Cert   string `json:"cert"`
+	Key    string `json:"key"`
+	PKCS12 string `json:"pkcs12"`
+}
+
+// ReadAuctionConfig reads the hosts file of an auction of the given
+// protocol from r, checking that it is valid and describes such an
+// auction.
+func ReadAuctionConfig(r io.Reader, protocol string) (*AuctionConfig, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var version struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &version); err != nil {
+		return nil, fmt.Errorf("not a hosts file: %v", err)
+	}
+	if version.Version < 0 || version.Version > DescriptorVersion {
+		return nil, fmt.Errorf("unsupported version %v, this party reads versions up to %v",
+			version.Version, DescriptorVersion)
+	}
+
+	var d descriptor
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if version.Version > 0 {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(&d); err != nil {
+		return nil, fmt.Errorf("invalid hosts file: %v", err)
+	}
+	if version.Version > 0 {
+		for _, field := range []struct {
+			name    string
+			missing bool
+		}{
+			{"auctionID", d.AuctionID == ""},
+			{"protocol", d.Protocol == ""},
+			{"sellerID", d.SellerID == nil},
+			{"fingerprints", d.Fingerprints == nil},
+		} {
+			if field.missing {
+				return nil, fmt.Errorf("missing %q, which version %v requires", field.name, version.Version)
+			}
+		}
+	}
+
+	return d.config(protocol)
+}
+
+// config returns the configuration of the auction d describes, checking
+// every entry.
+func (d *descriptor) config(protocol string) (*AuctionConfig, error) {
+	if d.Protocol != "" && d.Protocol != protocol {
+		return nil, fmt.Errorf("the auction runs the %v protocol, not %v", d.Protocol, protocol)
+	}
+
+	if len(d.Hosts) < 2 {
+		return nil, fmt.Errorf("an auction needs at least 2 hosts, not %v", len(d.Hosts))
+	}
+	seen := make(map[string]int)
+	for i, host := range d.Hosts {
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			return nil, fmt.Errorf("invalid host %v: %v", i, err)
+		}
+		if j, ok := seen[host]; ok {
+			return nil, fmt.Errorf("hosts %v and %v are both %v", j, i, host)
+		}
+		seen[host] = i
+	}
+	if d.MyID < 0 || d.MyID >= len(d.Hosts) {
+		return nil, fmt.Errorf("myID %v is not the id of one of the %v hosts", d.MyID, len(d.Hosts))
+	}
+
+	// Every protocol has the seller compute as party 0
+	if d.SellerID != nil && *d.SellerID != 0 {
+		return nil, fmt.Errorf("sellerID is %v, but the seller must be party 0", *d.SellerID)
+	}
+	seller := d.Seller
+	if seller == "" {
+		seller = d.Hosts[0]
+	} else if seller != d.Hosts[0] {
+		return nil, fmt.Errorf("the seller %v is not host 0, %v", seller, d.Hosts[0])
+	}
+
+	var fingerprints [][]byte
+	if d.Fingerprints != nil {
+		if len(d.Fingerprints) != len(d.Hosts) {
+			return nil, fmt.Errorf("there are %v fingerprints for %v hosts", len(d.Fingerprints), len(d.Hosts))
+		}
+		for i, f := range d.Fingerprints {
+			fingerprint, err := hex.DecodeString(f)
+			if err != nil || len(fingerprint) != 32 {
+				return nil, fmt.Errorf("fingerprint %v is not a hexadecimal SHA-256 hash: %q", i, f)
+			}
+			fingerprints = append(fingerprints, fingerprint)
+		}
+	}
+
+	var deadline time.Time
+	if d.Deadline != "" {
+		var err error
+		if deadline, err = time.Parse(time.RFC3339, d.Deadline); err != nil {
+			return nil, fmt.Errorf("invalid deadline: %v", err)
+		}
+	}
+
+	group, err := parseGroup(d.Group)
+	if err != nil {
+		return nil, fmt.Errorf("invalid group: %v", err)
+	}
+	if err := checkGroup(protocol, group); err != nil {
+		return nil, err
+	}
+
+	auctionID := d.AuctionID
+	if auctionID == "" {
+		auctionID = defaultAuctionID(d.Hosts, d.Seller, group)
+	}
+
+	units := d.Units
+	if units == 0 {
+		units = 1
+	} else if units < 0 {
+		return nil, fmt.Errorf("invalid number of units %v", units)
+	}
+
+	tieBreak := d.TieBreak
+	switch tieBreak {
+	case "":
+		tieBreak = TieBreakLowestID
+	case TieBreakLowestID, TieBreakRandom:
+	default:
+		return nil, fmt.Errorf("invalid tie-breaking rule %q", tieBreak)
+	}
+
+	prices, err := ParsePrices(protocol, d.Prices)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prices: %v", err)
+	}
+	if err := CheckPrices(protocol, prices); err != nil {
+		return nil, err
+	}
+
+	var timeouts [3]time.Duration
+	for i, timeout := range []struct {
+		name    string
+		value   string
+		initial time.Duration
+	}{
+		{"connectTimeout", d.ConnectTimeout, DefaultConnectTimeout},
+		{"roundTimeout", d.RoundTimeout, DefaultRoundTimeout},
+		{"timeout", d.Timeout, DefaultTimeout},
+	} {
+		timeouts[i], err = parseTimeout(timeout.value, timeout.initial)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %v: %v", timeout.name, err)
+		}
+	}
+
+	return &AuctionConfig{
+		Hosts:     d.Hosts,
+		MyID:      d.MyID,
+		Seller:    seller,
+		AuctionID: auctionID,
+		Protocol:  protocol,
+		Group:     group,
+		Units:     units,
+		TieBreak:  tieBreak,
+		Prices:    prices,
+
+		Fingerprints: fingerprints,
+		Deadline:     deadline,
+
+		ConnectTimeout: timeouts[0],
+		RoundTimeout:   timeouts[1],
+		Timeout:        timeouts[2],
+
+		Echo:           d.Echo,
+		LegacyIdentity: d.LegacyIdentity,
+
+		Credentials: Credentials{CACert: d.CACert, Cert: d.Cert, Key: d.Key, PKCS12: d.PKCS12},
+	}, nil
+}
+
+// checkFingerprint checks that der, the certificate of party id, has the
+// fingerprint the hosts file gives, if any.
+func (c *AuctionConfig) checkFingerprint(id int, der []byte) error {
+	if c.Fingerprints == nil {
+		return nil
+	}
+	fingerprint := sha256.Sum256(der)
+	if !bytes.Equal(fingerprint[:], c.Fingerprints[id]) {
+		return fmt.Errorf("its fingerprint is %x, not %x", fingerprint, c.Fingerprints[id])
+	}
+	return nil
+}
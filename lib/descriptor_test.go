@@ -0,0 +1,186 @@
+package lib
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	fingerprint0 = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	fingerprint1 = "202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f"
+)
+
+// descriptorV1 returns a valid hosts file of version 1, with the entries
+// of extra, given as name=value;..., added or replacing its own. An empty
+// value leaves the entry out.
+func descriptorV1(extra string) string {
+	entries := map[string]string{
+		"version":      `1`,
+		"auctionID":    `"test"`,
+		"protocol":     `"first_price"`,
+		"hosts":        `["127.0.0.1:9001", "127.0.0.1:9002"]`,
+		"myID":         `1`,
+		"sellerID":     `0`,
+		"fingerprints": `["` + fingerprint0 + `", "` + fingerprint1 + `"]`,
+		"deadline":     `"2017-03-25T18:00:00Z"`,
+	}
+	order := []string{"version", "auctionID", "protocol", "hosts", "myID", "sellerID", "fingerprints", "deadline"}
+	for _, entry := range strings.Split(extra, ";") {
+		if entry == "" {
+			continue
+		}
+		kv := strings.SplitN(entry, "=", 2)
+		if _, ok := entries[kv[0]]; !ok {
+			order = append(order, kv[0])
+		}
+		entries[kv[0]] = kv[1]
+	}
+
+	var b bytes.Buffer
+	b.WriteString("{")
+	for _, name := range order {
+		if entries[name] == "" {
+			continue
+		}
+		if b.Len() > 1 {
+			b.WriteString(", ")
+		}
+		b.WriteString(`"` + name + `": ` + entries[name])
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func TestReadAuctionConfig(test *testing.T) {
+	c, err := ReadAuctionConfig(strings.NewReader(descriptorV1("")), "first_price")
+	if err != nil {
+		test.Fatalf("Failed to read a valid hosts file: %v", err)
+	}
+	if c.AuctionID != "test" || c.Protocol != "first_price" || c.MyID != 1 || c.Seller != "127.0.0.1:9001" {
+		test.Errorf("Got auction %v of %v as party %v with seller %v", c.AuctionID, c.Protocol, c.MyID, c.Seller)
+	}
+	if len(c.Fingerprints) != 2 || c.Fingerprints[1][0] != 0x20 {
+		test.Errorf("Got fingerprints %x", c.Fingerprints)
+	}
+	if want := time.Date(2017, 3, 25, 18, 0, 0, 0, time.UTC); !c.Deadline.Equal(want) {
+		test.Errorf("Got deadline %v, want %v", c.Deadline, want)
+	}
+	if c.Units != 1 || c.TieBreak != TieBreakLowestID || c.Prices.Len() != DefaultMaxPrice+1 {
+		test.Errorf("Got %v units, tie-breaking rule %q and %v prices instead of the defaults", c.Units, c.TieBreak, c.Prices.Len())
+	}
+
+	c, err = ReadAuctionConfig(strings.NewReader(descriptorV1(`caCert="certs/ca.cert";pkcs12="certs/1.p12"`)), "first_price")
+	if err != nil {
+		test.Fatalf("Failed to read a hosts file with credentials: %v", err)
+	}
+	if want := (Credentials{CACert: "certs/ca.cert", PKCS12: "certs/1.p12"}); c.Credentials != want {
+		test.Errorf("Got credentials %+v, want %+v", c.Credentials, want)
+	}
+
+	c, err = ReadAuctionConfig(strings.NewReader(descriptorV1("legacyIdentity=true")), "first_price")
+	if err != nil {
+		test.Fatalf("Failed to read a hosts file in legacy identity mode: %v", err)
+	}
+	if !c.LegacyIdentity {
+		test.Errorf("Got no legacy identity mode")
+	}
+
+	// Files without a version keep their old meaning
+	c, err = ReadAuctionConfig(strings.NewReader(`{"hosts": ["127.0.0.1:9001", "127.0.0.1:9002"], "myID": 1, "comment": "ignored"}`), "first_price")
+	if err != nil {
+		test.Fatalf("Failed to read a hosts file without a version: %v", err)
+	}
+	if c.AuctionID == "" || c.Fingerprints != nil || !c.Deadline.IsZero() {
+		test.Errorf("Got auction id %q, fingerprints %x and deadline %v", c.AuctionID, c.Fingerprints, c.Deadline)
+	}
+}
+
+func TestReadAuctionConfigRejects(test *testing.T) {
+	for _, tc := range []struct {
+		name, file, err string
+	}{
+		{"a later version", descriptorV1("version=2"), "unsupported version 2"},
+		{"an unknown entry", descriptorV1(`prics={"max": 9}`), `unknown field "prics"`},
+		{"no fingerprints", descriptorV1("fingerprints="), `missing "fingerprints"`},
+		{"no auction id", descriptorV1("auctionID="), `missing "auctionID"`},
+		{"another protocol", descriptorV1(`protocol="millionaire"`), "runs the millionaire protocol"},
+		{"a single host", descriptorV1(`hosts=["127.0.0.1:9001"];fingerprints=["` + fingerprint0 + `"];myID=0`), "at least 2 hosts"},
+		{"an invalid host", descriptorV1(`hosts=["127.0.0.1:9001", "127.0.0.1"]`), "invalid host 1"},
+		{"duplicate hosts", descriptorV1(`hosts=["127.0.0.1:9001", "127.0.0.1:9001"]`), "hosts 0 and 1"},
+		{"an unknown id", descriptorV1("myID=2"), "myID 2"},
+		{"another seller id", descriptorV1("sellerID=1"), "sellerID is 1"},
+		{"a seller other than host 0", descriptorV1(`seller="127.0.0.1:9002"`), "is not host 0"},
+		{"too few fingerprints", descriptorV1(`fingerprints=["` + fingerprint0 + `"]`), "1 fingerprints for 2 hosts"},
+		{"a short fingerprint", descriptorV1(`fingerprints=["` + fingerprint0 + `", "2021"]`), "fingerprint 1"},
+		{"an invalid deadline", descriptorV1(`deadline="tomorrow"`), "invalid deadline"},
+	} {
+		_, err := ReadAuctionConfig(strings.NewReader(tc.file), "first_price")
+		if err == nil {
+			test.Errorf("Read a hosts file with %v: %v", tc.name, tc.file)
+		} else if !strings.Contains(err.Error(), tc.err) {
+			test.Errorf("Reading a hosts file with %v failed with %q, want %q", tc.name, err, tc.err)
+		}
+	}
+}
+
+func TestReadShippedHostsFile(test *testing.T) {
+	for _, protocol := range []string{"first_price", "second_price", "multi_unit", "millionaire"} {
+		f, err := os.Open("../hosts.auc")
+		if err != nil {
+			test.Fatal(err)
+		}
+		_, err = ReadAuctionConfig(f, protocol)
+		f.Close()
+		if err != nil {
+			test.Errorf("Reading the shipped hosts file for %v got %v", protocol, err)
+		}
+	}
+}
+
+func TestReadAuctionConfigMillionaireGroup(test *testing.T) {
+	for _, tc := range []struct {
+		group string
+		ok    bool
+	}{
+		{``, true},
+		{`"modp2048"`, true},
+		{`"p256"`, false},
+	} {
+		file := descriptorV1(`protocol="millionaire";group=` + tc.group)
+		if _, err := ReadAuctionConfig(strings.NewReader(file), "millionaire"); (err == nil) != tc.ok {
+			test.Errorf("Reading a millionaire auction in group %v got %v", tc.group, err)
+		}
+		// The other protocols run in any group
+		file = descriptorV1(`group=` + tc.group)
+		if _, err := ReadAuctionConfig(strings.NewReader(file), "first_price"); err != nil {
+			test.Errorf("Reading a first price auction in group %v got %v", tc.group, err)
+		}
+	}
+}
+
+func TestReadAuctionConfigMillionairePrices(test *testing.T) {
+	for _, tc := range []struct {
+		prices string
+		ok     bool
+	}{
+		{`{"bits": 6}`, true},
+		{`{"max": 63}`, true},
+		{`["1.00", "2.00"]`, true},
+		{``, true}, // the default of DefaultMillionaireBits bits
+		{`{"max": 62}`, false},
+		{`["1.00", "2.00", "3.00"]`, false},
+	} {
+		file := descriptorV1(`protocol="millionaire";prices=` + tc.prices)
+		if _, err := ReadAuctionConfig(strings.NewReader(file), "millionaire"); (err == nil) != tc.ok {
+			test.Errorf("Reading a millionaire auction with prices %v got %v", tc.prices, err)
+		}
+		// The other protocols take any prices
+		file = descriptorV1(`prices=` + tc.prices)
+		if _, err := ReadAuctionConfig(strings.NewReader(file), "first_price"); err != nil {
+			test.Errorf("Reading a first price auction with prices %v got %v", tc.prices, err)
+		}
+	}
+}
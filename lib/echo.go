@@ -0,0 +1,141 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// As PublishAll sends a message to every party separately, a cheater could
+// send different messages of the same round to different parties, and
+// split their views of the auction. With Echo in the configuration, every
+// round therefore ends with each party telling all others the hash of the
+// data and the signature of every message it received. We check each
+// signature over the message of the round we expect, with the auction id,
+// the client id and the step id of the round, so a signed hash that
+// differs from ours proves that its sender signed two messages for the
+// round, and every honest party finds it, as the two messages went to
+// different honest parties. A party that echoes a hash that its sender did
+// not sign for the round, such as one replayed from another round, is
+// accused like the sender of a message that does not check.
+
+// echoStep returns the step id of the echoes of the given round, which
+// belong to no round, like accusations.
+func echoStep(step int) int32 {
+	return accusationStep - int32(step)
+}
+
+// echoedStep returns the round of echoes with the given step id.
+func echoedStep(stepid int32) int32 {
+	return accusationStep - stepid
+}
+
+// echo sends everyone what we received in the given round, with out as our
+// own message, and compares what the others received with it.
+func (s *Session) echo(ctx context.Context, step int, out *pb.OuterStruct, state interface{}) error {
+	n := s.NumParties()
+	mine := &pb.Echo{Digests: make([][]byte, n), Signatures: make([][]byte, n)}
+	s.dataLock.Lock()
+	for i := 0; i < n; i++ {
+		msg := s.data[i]
+		if i == s.id {
+			msg = out
+		}
+		mine.Digests[i], mine.Signatures[i] = dataHash(msg.Data), msg.Signature
+	}
+	s.dataLock.Unlock()
+
+	data, err := marshalData(mine)
+	if err != nil {
+		return err
+	}
+	msg := &pb.OuterStruct{
+		Clientid: int32(s.id),
+		Stepid:   echoStep(step),
+		Data:     data,
+	}
+	if err := s.sign(msg); err != nil {
+		return err
+	}
+
+	log.Printf("Echoing round %v as %v", step, s.id)
+	if err := s.publishAll(ctx, msg); err != nil {
+		err.(*RoundError).Round = step
+		return err
+	}
+
+	return s.checkAll(ctx, state, func(_ interface{}, result *pb.OuterStruct) error {
+		return s.checkEcho(step, mine, result)
+	}, s.receivedEchoChan, s.echoes)
+}
+
+// checkEchoSignatures checks that the echo in result has the signature of
+// the sender of every message it echoes. It is the check of the evidence
+// of accusations of false echoes.
+func (s *Session) checkEchoSignatures(state interface{}, result *pb.OuterStruct) error {
+	_, err := s.decodeEcho(result)
+	return err
+}
+
+// decodeEcho decodes the echo in result, and checks that every party
+// signed the hash it echoes for the round of the echo.
+func (s *Session) decodeEcho(result *pb.OuterStruct) (*pb.Echo, error) {
+	client := int(result.Clientid)
+	step := echoedStep(result.Stepid)
+	var echo pb.Echo
+	if err := proto.Unmarshal(result.Data, &echo); err != nil {
+		return nil, NewError(DecodeError, client, "Failed to unmarshal Echo: %v", err)
+	}
+	if len(echo.Digests) != s.NumParties() || len(echo.Signatures) != s.NumParties() {
+		return nil, NewError(DecodeError, client, "Echo of %v digests and %v signatures, want %v",
+			len(echo.Digests), len(echo.Signatures), s.NumParties())
+	}
+
+	for i := range echo.Digests {
+		key, err := s.transport.PeerKey(i)
+		if err != nil {
+			return nil, NewError(LocalError, NoClient, "No key of party %v: %v", i, err)
+		}
+		digest := headerDigest(s.config.AuctionID, int32(i), step, echo.Digests[i])
+		if err := verifyDigest(key, digest, echo.Signatures[i]); err != nil {
+			return nil, NewError(ProofError, client, "Echo of a message party %v did not sign in round %v: %v", i, step, err)
+		}
+	}
+	return &echo, nil
+}
+
+// checkEcho compares the echo in result with ours of the given round.
+func (s *Session) checkEcho(step int, mine *pb.Echo, result *pb.OuterStruct) error {
+	if result.Stepid != echoStep(step) {
+		return NewError(DecodeError, int(result.Clientid), "Echo of round %v, want round %v", echoedStep(result.Stepid), step)
+	}
+	echo, err := s.decodeEcho(result)
+	if err != nil {
+		return err
+	}
+
+	for i := range echo.Digests {
+		if bytes.Equal(echo.Digests[i], mine.Digests[i]) {
+			continue
+		}
+
+		s.dataLock.Lock()
+		evidence := s.data[i]
+		s.dataLock.Unlock()
+
+		return &RoundError{
+			Round:       step,
+			Clientid:    i,
+			Kind:        EquivocationError,
+			Err:         fmt.Errorf("sent party %v a message with data hash %x, but us %x", result.Clientid, echo.Digests[i], mine.Digests[i]),
+			Evidence:    evidence,
+			Accuser:     s.id,
+			Conflicting: result,
+		}
+	}
+	return nil
+}
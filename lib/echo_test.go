@@ -0,0 +1,166 @@
+package lib
+
+import (
+	"testing"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// equivocatingTransport sends victim a different message of every round
+// than everyone else, which checks all the same.
+type equivocatingTransport struct {
+	Transport
+	victim int
+}
+
+func (t *equivocatingTransport) Send(ctx context.Context, to int, msg *pb.OuterStruct) error {
+	if to == t.victim && msg.Stepid > 0 {
+		data, _ := proto.Marshal(&pb.OuterStruct{Clientid: msg.Clientid + 1, Stepid: 42})
+		msg = &pb.OuterStruct{Clientid: msg.Clientid, Stepid: msg.Stepid, Data: data}
+		if err := signMessage(t.Key(), msg.Auctionid, msg); err != nil {
+			return err
+		}
+	}
+	return t.Transport.Send(ctx, to, msg)
+}
+
+// falseEchoTransport echoes to victim a message of party forged that it
+// did not sign.
+type falseEchoTransport struct {
+	Transport
+	victim, forged int
+}
+
+func (t *falseEchoTransport) Send(ctx context.Context, to int, msg *pb.OuterStruct) error {
+	if to == t.victim && msg.Stepid < accusationStep {
+		var echo pb.Echo
+		proto.Unmarshal(msg.Data, &echo)
+		echo.Digests[t.forged] = make([]byte, len(echo.Digests[t.forged]))
+		data, _ := proto.Marshal(&echo)
+		msg = &pb.OuterStruct{Clientid: msg.Clientid, Stepid: msg.Stepid, Data: data}
+		if err := signMessage(t.Key(), msg.Auctionid, msg); err != nil {
+			return err
+		}
+	}
+	return t.Transport.Send(ctx, to, msg)
+}
+
+// replayingTransport echoes the hash and the signature of the message of
+// party replayed of round 1 as its message of every later round.
+type replayingTransport struct {
+	Transport
+	replayed int
+	hash     []byte
+	sig      []byte
+}
+
+func (t *replayingTransport) Send(ctx context.Context, to int, msg *pb.OuterStruct) error {
+	if msg.Stepid < accusationStep {
+		var echo pb.Echo
+		proto.Unmarshal(msg.Data, &echo)
+		if msg.Stepid == echoStep(1) {
+			t.hash, t.sig = echo.Digests[t.replayed], echo.Signatures[t.replayed]
+			return t.Transport.Send(ctx, to, msg)
+		}
+		echo.Digests[t.replayed], echo.Signatures[t.replayed] = t.hash, t.sig
+		data, _ := proto.Marshal(&echo)
+		msg = &pb.OuterStruct{Clientid: msg.Clientid, Stepid: msg.Stepid, Data: data}
+		if err := signMessage(t.Key(), msg.Auctionid, msg); err != nil {
+			return err
+		}
+	}
+	return t.Transport.Send(ctx, to, msg)
+}
+
+func TestEcho(test *testing.T) {
+	const n = 4
+
+	network := NewMemoryNetwork(n)
+	errs := runSum(n, true, []int{0, 1, 2, 3}, network.Transport, func(int, Transport) {})
+
+	for i, err := range errs {
+		if err != nil {
+			test.Errorf("Party %v failed: %v", i, err)
+		}
+	}
+}
+
+func TestEquivocation(test *testing.T) {
+	const n, cheater, victim = 4, 2, 1
+
+	network := NewMemoryNetwork(n)
+	errs := runSum(n, true, []int{0, 1, 3}, func(i int) Transport {
+		if i == cheater {
+			return &equivocatingTransport{network.Transport(i), victim}
+		}
+		return network.Transport(i)
+	}, func(int, Transport) {})
+
+	// Both messages check, but every honest party finds that they differ
+	for i, err := range errs {
+		e, ok := err.(*RoundError)
+		if !ok {
+			test.Errorf("Party %v failed with %v, want a *RoundError", i, err)
+			continue
+		}
+		if e.Clientid != cheater || e.Round != 1 || e.Kind != EquivocationError ||
+			e.Evidence == nil || int(e.Evidence.Clientid) != cheater || e.Conflicting == nil {
+			test.Errorf("Party %v failed with %v, with evidence %v and %v, want an %v blaming %v in round 1",
+				i, e, e.Evidence, e.Conflicting, EquivocationError, cheater)
+		}
+	}
+}
+
+func TestFalseEcho(test *testing.T) {
+	const n, liar, victim, forged = 4, 3, 0, 1
+
+	network := NewMemoryNetwork(n)
+	errs := runSum(n, true, []int{0, 1, 2}, func(i int) Transport {
+		if i == liar {
+			return &falseEchoTransport{network.Transport(i), victim, forged}
+		}
+		return network.Transport(i)
+	}, func(int, Transport) {})
+
+	// Only the victim got the false echo, but everyone learns about it
+	for i, err := range errs {
+		e, ok := err.(*RoundError)
+		if !ok {
+			test.Errorf("Party %v failed with %v, want a *RoundError", i, err)
+			continue
+		}
+		if e.Clientid != liar || e.Round != 1 || e.Kind != ProofError || e.Accuser != victim ||
+			e.Evidence == nil || e.Evidence.Stepid != echoStep(1) {
+			test.Errorf("Party %v failed with %v, accused by %v with evidence %v, want a %v blaming %v for its echo of round 1",
+				i, e, e.Accuser, e.Evidence, ProofError, liar)
+		}
+	}
+}
+
+func TestReplayedEcho(test *testing.T) {
+	const n, replayer, replayed = 4, 3, 1
+
+	network := NewMemoryNetwork(n)
+	errs := runSumRounds(n, 2, true, []int{0, 1, 2}, func(i int) Transport {
+		if i == replayer {
+			return &replayingTransport{Transport: network.Transport(i), replayed: replayed}
+		}
+		return network.Transport(i)
+	}, func(int, Transport) {})
+
+	// The replayed signature is over the same data of round 1, which
+	// blames the replayer, not the party that signed it
+	for i, err := range errs {
+		e, ok := err.(*RoundError)
+		if !ok {
+			test.Errorf("Party %v failed with %v, want a *RoundError", i, err)
+			continue
+		}
+		if e.Clientid != replayer || e.Round != 2 || e.Kind != ProofError {
+			test.Errorf("Party %v failed with %v, want a %v blaming %v for its echo of round 2",
+				i, e, ProofError, replayer)
+		}
+	}
+}
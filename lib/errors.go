@@ -0,0 +1,118 @@
+package lib
+
+import (
+	"fmt"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+)
+
+// ErrorKind says what went wrong in a round.
+type ErrorKind int
+
+const (
+	// LocalError is a failure of our own computation, e.g. marshalling.
+	LocalError ErrorKind = iota
+	// TransportError is a failure to send to or receive from another party.
+	TransportError
+	// DecodeError is a message from another party that could not be
+	// decoded, or that has the wrong shape.
+	DecodeError
+	// ProofError is a zero-knowledge proof from another party that does not
+	// verify.
+	ProofError
+	// ConfigError is another party that runs the auction with different
+	// parameters.
+	ConfigError
+	// TimeoutError is a round that did not end before its deadline, as
+	// other parties did not send their messages.
+	TimeoutError
+	// SignatureError is a message that is not signed by the party it
+	// claims to come from, or that belongs to another auction. As the
+	// seller relays messages, that party need not be the one to blame.
+	SignatureError
+	// EquivocationError is a party that sent different messages of the
+	// same round to different parties.
+	EquivocationError
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case LocalError:
+		return "local error"
+	case TransportError:
+		return "transport error"
+	case DecodeError:
+		return "decode error"
+	case ProofError:
+		return "proof verification error"
+	case ConfigError:
+		return "configuration mismatch"
+	case TimeoutError:
+		return "timeout"
+	case SignatureError:
+		return "signature verification error"
+	case EquivocationError:
+		return "equivocation"
+	}
+	return fmt.Sprintf("ErrorKind(%d)", int(k))
+}
+
+// NoClient is the Clientid of a RoundError that is not caused by another
+// party.
+const NoClient = -1
+
+// RoundError is the error returned by Session.Run when a round fails.
+type RoundError struct {
+	// Round is the step id of the round that failed, counting from 1.
+	Round int
+	// Clientid is the id of the offending party, or NoClient.
+	Clientid int
+	Kind     ErrorKind
+	Err      error
+
+	// Missing are the ids of the parties whose messages for the round did
+	// not arrive, for a TimeoutError.
+	Missing []int
+
+	// Evidence is the message of Clientid that failed its check, for a
+	// DecodeError or ProofError, as found by the party Accuser.
+	Evidence *pb.OuterStruct
+	Accuser  int
+
+	// Conflicting is the echo of another party with the signature of
+	// Clientid on a message other than Evidence, for an EquivocationError.
+	Conflicting *pb.OuterStruct
+}
+
+func (e *RoundError) Error() string {
+	if e.Clientid == NoClient {
+		return fmt.Sprintf("round %v: %v: %v", e.Round, e.Kind, e.Err)
+	}
+	return fmt.Sprintf("round %v, client %v: %v: %v", e.Round, e.Clientid, e.Kind, e.Err)
+}
+
+func (e *RoundError) Unwrap() error {
+	return e.Err
+}
+
+// NewError returns an error of the given kind caused by client, for the
+// protocol callbacks to return. Session.Run fills in the round.
+func NewError(kind ErrorKind, client int, format string, args ...interface{}) error {
+	return &RoundError{
+		Clientid: client,
+		Kind:     kind,
+		Err:      fmt.Errorf(format, args...),
+	}
+}
+
+// roundError turns err, as returned by a callback of the given round, into
+// a *RoundError. Errors that are not already RoundErrors are local.
+func roundError(round int, err error) *RoundError {
+	if e, ok := err.(*RoundError); ok {
+		if e.Round == 0 {
+			e.Round = round
+		}
+		return e
+	}
+	return &RoundError{Round: round, Clientid: NoClient, Kind: LocalError, Err: err}
+}
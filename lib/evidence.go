@@ -0,0 +1,70 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+)
+
+// Evidence is what a party writes to disk when it excludes another party
+// from the auction, for resolving disputes later.
+type Evidence struct {
+	AuctionID string    `json:"auctionID"`
+	Time      time.Time `json:"time"`
+	Hosts     []string  `json:"hosts"`
+
+	Round   int    `json:"round"`
+	Accused int    `json:"accused"`
+	Accuser int    `json:"accuser"`
+	Kind    string `json:"kind"`
+	Reason  string `json:"reason"`
+
+	// Message is the message of the accused party that did not check, or
+	// its accusation if it falsely accused another party.
+	Message *pb.OuterStruct `json:"message"`
+	// Conflicting is the echo of another party that shows a different
+	// message of the accused party in the same round, for equivocation.
+	Conflicting *pb.OuterStruct `json:"conflicting,omitempty"`
+}
+
+// WriteEvidence writes the evidence of e, which must have some, into dir,
+// and returns the name of the file.
+func WriteEvidence(dir string, config *AuctionConfig, e *RoundError) (string, error) {
+	evidence := &Evidence{
+		AuctionID: config.AuctionID,
+		Time:      time.Now().UTC(),
+		Hosts:     config.Hosts,
+		Round:     e.Round,
+		Accused:   e.Clientid,
+		Accuser:   e.Accuser,
+		Kind:      e.Kind.String(),
+		Reason:    e.Err.Error(),
+		Message:   e.Evidence,
+
+		Conflicting: e.Conflicting,
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	name := filepath.Join(dir, fmt.Sprintf("%v-round%v-party%v.json",
+		strings.Replace(config.AuctionID, string(filepath.Separator), "_", -1), e.Round, e.Clientid))
+	f, err := os.Create(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(evidence); err != nil {
+		return "", err
+	}
+	return name, f.Close()
+}
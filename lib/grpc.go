@@ -0,0 +1,254 @@
+package lib
+
+import (
+	"crypto"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"crypto/tls"
+	"crypto/x509"
+
+	"google.golang.org/grpc"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+	lib_pb "github.com/ashwinsr/auctions/lib/pb"
+	google_protobuf "github.com/golang/protobuf/ptypes/empty"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+)
+
+// GRPCTransport connects the parties over gRPC with mutually authenticated
+// TLS, listening on and dialing the hosts of the auction configuration.
+type GRPCTransport struct {
+	config *AuctionConfig
+	id     int
+
+	lock    sync.Mutex
+	server  *grpc.Server
+	conns   []*grpc.ClientConn
+	clients map[int]lib_pb.ZKPAuctionClient
+
+	key      crypto.Signer
+	peerKeys map[int]crypto.PublicKey // of the certificates the parties serve with
+}
+
+// NewGRPCTransport returns the gRPC transport of party config.MyID. It is
+// meant as the NewTransport of a Protocol.
+func NewGRPCTransport(config *AuctionConfig) Transport {
+	return &GRPCTransport{
+		config:   config,
+		id:       config.MyID,
+		clients:  make(map[int]lib_pb.ZKPAuctionClient),
+		peerKeys: make(map[int]crypto.PublicKey),
+	}
+}
+
+// server is used to implement lib_pb.ZKPAuctionServer
+type server struct {
+	deliver DeliverFn
+}
+
+func (srv *server) Publish(ctx context.Context, in *pb.OuterStruct) (*google_protobuf.Empty, error) {
+	if err := srv.deliver(in); err != nil {
+		return nil, err
+	}
+	return &google_protobuf.Empty{}, nil
+}
+
+// Connect listens for connections on our host, and then connects to all the
+// other parties.
+func (t *GRPCTransport) Connect(ctx context.Context, deliver DeliverFn) error {
+	lis, err := net.Listen("tcp", t.config.Hosts[t.id])
+	if err != nil {
+		return fmt.Errorf("failed to listen: %v", err)
+	}
+
+	myCert, caPool, err := t.loadCertificate()
+	if err != nil {
+		lis.Close()
+		return err
+	}
+	key, ok := myCert.PrivateKey.(crypto.Signer)
+	if !ok {
+		lis.Close()
+		return fmt.Errorf("Cannot sign with a %T", myCert.PrivateKey)
+	}
+
+	// Get options
+	var opts []grpc.ServerOption
+	opts = []grpc.ServerOption{grpc.Creds(t.getServerCertificate(myCert, caPool))}
+
+	srv := grpc.NewServer(opts...)
+	lib_pb.RegisterZKPAuctionServer(srv, &server{deliver: deliver})
+
+	t.lock.Lock()
+	t.server = srv
+	t.key = key
+	t.lock.Unlock()
+
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			log.Printf("failed to serve: %v", err)
+		}
+	}()
+
+	return t.initClients(ctx, myCert, caPool)
+}
+
+// loadCertificate loads our certificate, which we serve and dial with and
+// whose key signs our messages, and the pool of the certificate authority,
+// as config.Credentials locate them.
+func (t *GRPCTransport) loadCertificate() (tls.Certificate, *x509.CertPool, error) {
+	myCert, caPool, err := t.config.Credentials.load()
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	if err := t.config.checkFingerprint(t.id, myCert.Certificate[0]); err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("Our certificate %v is not the one of the hosts file: %v",
+			t.config.Credentials.certSource(), err)
+	}
+	return myCert, caPool, nil
+}
+
+func (t *GRPCTransport) getClientCertificate(myCert tls.Certificate, caPool *x509.CertPool) credentials.TransportCredentials {
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{myCert},
+		RootCAs:      caPool,
+	})
+}
+
+func (t *GRPCTransport) getServerCertificate(myCert tls.Certificate, caPool *x509.CertPool) credentials.TransportCredentials {
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{myCert},
+		ClientCAs:    caPool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+}
+
+// peerCredentials records the key of the certificate the server of party id
+// presents when we dial it, once it checked that the certificate is for
+// that party and has its fingerprint.
+type peerCredentials struct {
+	credentials.TransportCredentials
+	t  *GRPCTransport
+	id int
+}
+
+func (c *peerCredentials) ClientHandshake(ctx context.Context, authority string, rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	conn, info, err := c.TransportCredentials.ClientHandshake(ctx, authority, rawConn)
+	if err != nil {
+		return conn, info, err
+	}
+
+	tlsInfo, ok := info.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		conn.Close()
+		return nil, nil, fmt.Errorf("no certificate of party %v", c.id)
+	}
+	if err := c.t.config.checkIdentity(c.id, tlsInfo.State.PeerCertificates[0]); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("party %v presented a certificate for another party: %v", c.id, err)
+	}
+	if err := c.t.config.checkFingerprint(c.id, tlsInfo.State.PeerCertificates[0].Raw); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("party %v presented another certificate than the one of the hosts file: %v", c.id, err)
+	}
+	c.t.lock.Lock()
+	c.t.peerKeys[c.id] = tlsInfo.State.PeerCertificates[0].PublicKey
+	c.t.lock.Unlock()
+
+	return conn, info, nil
+}
+
+func (c *peerCredentials) Clone() credentials.TransportCredentials {
+	return &peerCredentials{c.TransportCredentials.Clone(), c.t, c.id}
+}
+
+func (t *GRPCTransport) initClients(ctx context.Context, myCert tls.Certificate, caPool *x509.CertPool) error {
+	log.Println("Initializing clients!")
+	// generate clients sequentially, not so bad
+	for i, host := range t.config.Hosts {
+
+		if i == t.id {
+			continue
+		}
+
+		// Get certificate
+		cert := t.getClientCertificate(myCert, caPool)
+
+		// Configure options to Dial
+		var opts []grpc.DialOption
+		opts = append(opts, grpc.WithTransportCredentials(&peerCredentials{cert, t, i}))
+		opts = append(opts, grpc.WithBackoffMaxDelay(1*time.Second))
+		opts = append(opts, grpc.WithBlock())
+
+		// Set up a connection to the server.
+		conn, err := grpc.DialContext(ctx, host, opts...)
+		if err != nil {
+			return &RoundError{Clientid: i, Kind: TransportError,
+				Err: fmt.Errorf("Did not connect (to host %v): %v", host, err)}
+		}
+
+		t.lock.Lock()
+		t.conns = append(t.conns, conn)
+		t.clients[i] = lib_pb.NewZKPAuctionClient(conn)
+		t.lock.Unlock()
+	}
+
+	return nil
+}
+
+func (t *GRPCTransport) Send(ctx context.Context, to int, msg *pb.OuterStruct) error {
+	t.lock.Lock()
+	client, ok := t.clients[to]
+	t.lock.Unlock()
+
+	if !ok {
+		return fmt.Errorf("not connected to party %v", to)
+	}
+
+	// A party that crashed and is resuming may be gone for a while
+	_, err := client.Publish(ctx, msg, grpc.WaitForReady(true))
+	return err
+}
+
+func (t *GRPCTransport) Key() crypto.Signer {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.key
+}
+
+func (t *GRPCTransport) PeerKey(id int) (crypto.PublicKey, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if id == t.id && t.key != nil {
+		return t.key.Public(), nil
+	}
+	key, ok := t.peerKeys[id]
+	if !ok {
+		return nil, fmt.Errorf("no certificate of party %v", id)
+	}
+	return key, nil
+}
+
+// Close closes the connections to the other parties, and stops the server
+// once it has finished handling the messages it is receiving.
+func (t *GRPCTransport) Close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var err error
+	for _, conn := range t.conns {
+		if e := conn.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	if t.server != nil {
+		t.server.GracefulStop()
+	}
+	return err
+}
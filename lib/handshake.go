@@ -0,0 +1,159 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+	"github.com/ashwinsr/auctions/zkp"
+	"github.com/golang/protobuf/proto"
+)
+
+// Parameter is a named parameter of an auction, which all parties must
+// agree on.
+type Parameter struct {
+	Name, Value string
+}
+
+// Parameters returns the parameters of the auction that every party must
+// agree on: all of config but what each party sets for itself, such as its
+// id and timeouts.
+func (c *AuctionConfig) Parameters() []Parameter {
+	params := []Parameter{
+		{"auction id", c.AuctionID},
+		{"parties", fmt.Sprint(len(c.Hosts))},
+	}
+	for i, host := range c.Hosts {
+		params = append(params, Parameter{fmt.Sprintf("host %v", i), host})
+	}
+	params = append(params, Parameter{"seller", c.Seller})
+
+	if c.Group != nil {
+		params = append(params,
+			Parameter{"group", c.Group.Name()},
+			Parameter{"group order", fmt.Sprintf("%x", c.Group.Order())},
+			Parameter{"group generators", fmt.Sprintf("%x, %x",
+				c.Group.Encode(c.Group.Generator()), c.Group.Encode(c.Group.SecondGenerator()))},
+		)
+	}
+	if group, ok := c.Group.(*zkp.GroupParams); ok && group.P != nil {
+		params = append(params, Parameter{"group modulus", fmt.Sprintf("%x", group.P)})
+	}
+
+	if c.Prices != nil {
+		params = append(params, Parameter{"prices", fmt.Sprintf("%v prices from %v to %v, digest %x",
+			c.Prices.Len(), c.Prices.Price(0), c.Prices.Price(c.Prices.Len()-1), c.Prices.Digest())})
+	}
+	params = append(params,
+		Parameter{"units", fmt.Sprint(c.Units)},
+		Parameter{"tie-breaking rule", string(c.TieBreak)},
+		Parameter{"echo", fmt.Sprint(c.Echo)},
+	)
+
+	for i, fingerprint := range c.Fingerprints {
+		params = append(params, Parameter{fmt.Sprintf("fingerprint %v", i), fmt.Sprintf("%x", fingerprint)})
+	}
+	if !c.Deadline.IsZero() {
+		params = append(params, Parameter{"deadline", c.Deadline.UTC().Format(time.RFC3339)})
+	}
+	return params
+}
+
+// newHandshake returns the handshake of a party running version of
+// protocol with params.
+func newHandshake(protocol string, version int, params []Parameter) *pb.Handshake {
+	h := &pb.Handshake{Protocol: protocol, Version: int32(version)}
+	for _, p := range params {
+		h.Names = append(h.Names, p.Name)
+		h.Values = append(h.Values, p.Value)
+	}
+	h.Digest = handshakeDigest(h)
+	return h
+}
+
+// handshakeDigest returns the hash of the protocol, version and parameters
+// of h.
+func handshakeDigest(h *pb.Handshake) []byte {
+	t := zkp.NewTranscript("auctions/handshake")
+	t.AppendString("protocol", h.Protocol)
+	t.AppendInt("version", int64(h.Version))
+	t.AppendInt("parameters", int64(len(h.Names)))
+	for i, name := range h.Names {
+		t.AppendString("name", name)
+		t.AppendString("value", h.Values[i])
+	}
+	return t.Challenge("digest", new(big.Int).Lsh(zkp.One, 256)).Bytes()
+}
+
+// diffHandshakes returns the parameters on which ours and theirs, the
+// handshake of party client, differ, as "name: ours, theirs" in the order
+// of ours and then of the parameters only they have.
+func diffHandshakes(ours, theirs *pb.Handshake, client int) []string {
+	values := make(map[string]string)
+	for i, name := range theirs.Names {
+		values[name] = theirs.Values[i]
+	}
+
+	var diff []string
+	for i, name := range ours.Names {
+		value, ok := values[name]
+		delete(values, name)
+		if !ok {
+			diff = append(diff, fmt.Sprintf("%v: ours is %q, party %v has none", name, ours.Values[i], client))
+		} else if value != ours.Values[i] {
+			diff = append(diff, fmt.Sprintf("%v: ours is %q, party %v has %q", name, ours.Values[i], client, value))
+		}
+	}
+	for _, name := range theirs.Names {
+		if value, ok := values[name]; ok {
+			diff = append(diff, fmt.Sprintf("%v: we have none, party %v has %q", name, client, value))
+		}
+	}
+	return diff
+}
+
+// HandshakeRound returns the round in which every party publishes the
+// parameters of the auction it is about to run, which are those of config
+// and extra ones of the protocol, along with the version of the protocol
+// and a hash of all of them, and checks that all others publish the same.
+// Protocols start with it, so that parties that disagree on what to compute
+// stop before revealing anything, with an error that lists every parameter
+// they disagree on.
+func HandshakeRound(config *AuctionConfig, version int, extra ...Parameter) Round {
+	ours := newHandshake(config.Protocol, version, append(config.Parameters(), extra...))
+
+	return Round{
+		Compute: func(state interface{}) (proto.Message, bool, error) {
+			return ours, false, nil
+		},
+		Check: func(state interface{}, result *pb.OuterStruct) error {
+			client := int(result.Clientid)
+			var in pb.Handshake
+			if err := proto.Unmarshal(result.Data, &in); err != nil {
+				return NewError(DecodeError, client, "Failed to unmarshal handshake: %v", err)
+			}
+			if len(in.Names) != len(in.Values) {
+				return NewError(DecodeError, client, "Handshake has %v names for %v values", len(in.Names), len(in.Values))
+			}
+			if !bytes.Equal(handshakeDigest(&in), in.Digest) {
+				return NewError(DecodeError, client, "Handshake digest %x is not the hash of its parameters", in.Digest)
+			}
+
+			if in.Protocol != ours.Protocol || in.Version != ours.Version {
+				return NewError(ConfigError, client, "Runs version %v of the %q protocol, we run version %v of %q",
+					in.Version, in.Protocol, ours.Version, ours.Protocol)
+			}
+			if !bytes.Equal(in.Digest, ours.Digest) {
+				return NewError(ConfigError, client, "Disagrees on the parameters of the auction: digest %x, ours is %x: %v",
+					in.Digest, ours.Digest, strings.Join(diffHandshakes(ours, &in, client), "; "))
+			}
+			return nil
+		},
+		Receive: func(state interface{}, results []*pb.OuterStruct) error {
+			return nil
+		},
+	}
+}
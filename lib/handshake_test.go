@@ -0,0 +1,107 @@
+package lib
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+func TestHandshakeRound(test *testing.T) {
+	const n, odd = 4, 2
+
+	same, _ := MaxPriceLadder(9)
+	other, _ := MaxPriceLadder(10)
+
+	hosts := []string{"127.0.0.1:9001", "127.0.0.1:9002", "127.0.0.1:9003", "127.0.0.1:9004"}
+	network := NewMemoryNetwork(n)
+	errs := make([]error, n)
+
+	// Every party sends its only message before checking the others', so
+	// none of them is cancelled when the first one fails
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		i := i
+		prices := same
+		if i == odd {
+			prices = other
+		}
+		config := &AuctionConfig{Hosts: hosts, MyID: i, Seller: hosts[0], AuctionID: "test",
+			Protocol: "first_price", Units: 1, Prices: prices}
+		session := NewSession(config, network.Transport(i))
+		rounds := []Round{HandshakeRound(config, 1)}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if errs[i] = session.Connect(ctx); errs[i] == nil {
+				errs[i] = session.Run(ctx, rounds, nil)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		if i == odd {
+			continue
+		}
+		e, ok := errs[i].(*RoundError)
+		if !ok {
+			test.Errorf("Party %v failed with %v, want a *RoundError", i, errs[i])
+			continue
+		}
+		if e.Clientid != odd || e.Round != 1 || e.Kind != ConfigError {
+			test.Errorf("Party %v failed with %v, want a %v blaming %v in round 1", i, e, ConfigError, odd)
+		}
+		// Only the prices differ
+		if msg := e.Error(); !strings.Contains(msg, "prices: ours is \"10 prices from 0 to 9") ||
+			!strings.Contains(msg, "party 2 has \"11 prices from 0 to 10") || strings.Contains(msg, "host") {
+			test.Errorf("Party %v failed with %v, want the difference in prices", i, e)
+		}
+	}
+}
+
+func TestDiffHandshakes(test *testing.T) {
+	ours := newHandshake("first_price", 1, []Parameter{{"a", "1"}, {"b", "2"}, {"c", "3"}})
+	theirs := newHandshake("first_price", 1, []Parameter{{"b", "2"}, {"c", "4"}, {"d", "5"}})
+
+	want := []string{
+		`a: ours is "1", party 3 has none`,
+		`c: ours is "3", party 3 has "4"`,
+		`d: we have none, party 3 has "5"`,
+	}
+	if diff := diffHandshakes(ours, theirs, 3); strings.Join(diff, "\n") != strings.Join(want, "\n") {
+		test.Errorf("Got diff %q, want %q", diff, want)
+	}
+	if diff := diffHandshakes(ours, ours, 3); len(diff) != 0 {
+		test.Errorf("Got diff %q of equal handshakes", diff)
+	}
+}
+
+func TestHandshakeRoundRejects(test *testing.T) {
+	config := &AuctionConfig{Hosts: []string{"a:1", "b:2"}, Seller: "a:1", Protocol: "first_price"}
+	round := HandshakeRound(config, 2)
+
+	for _, tc := range []struct {
+		name string
+		in   *pb.Handshake
+		kind ErrorKind
+	}{
+		{"another version", newHandshake("first_price", 1, config.Parameters()), ConfigError},
+		{"another protocol", newHandshake("millionaire", 2, config.Parameters()), ConfigError},
+		{"a wrong digest", &pb.Handshake{Protocol: "first_price", Version: 2, Digest: []byte{1}}, DecodeError},
+	} {
+		data, _ := proto.Marshal(tc.in)
+		err := round.Check(nil, &pb.OuterStruct{Clientid: 1, Stepid: 1, Data: data})
+		if e, ok := err.(*RoundError); !ok || e.Kind != tc.kind || e.Clientid != 1 {
+			test.Errorf("Checking a handshake of %v failed with %v, want a %v", tc.name, err, tc.kind)
+		}
+	}
+}
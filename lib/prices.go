@@ -0,0 +1,234 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ashwinsr/auctions/zkp"
+)
+
+// MaxPrices is the largest number of prices a price ladder may have. Every
+// bidder encrypts one value per price, so larger ladders are impractical
+// anyway.
+const MaxPrices = 1 << 16
+
+// DefaultMaxPrice is the highest price of the default price ladder, which
+// has the prices 0, 1, ..., DefaultMaxPrice.
+const DefaultMaxPrice = 99
+
+// DefaultMillionaireBits is the number of bits of the prices of the default
+// price ladder of the millionaire protocol, which needs a power of two of
+// prices: 0, 1, ..., 63.
+const DefaultMillionaireBits = 6
+
+// PriceLadder is the list of prices the bidders may bid, in increasing
+// order. The protocols compute with the index of a price in the ladder.
+type PriceLadder struct {
+	prices   []*big.Rat
+	decimals int // the number of decimals prices are written with
+}
+
+// NewPriceLadder returns the ladder of the given prices, which must be
+// increasing decimal numbers, e.g. "10.00".
+func NewPriceLadder(prices []string) (*PriceLadder, error) {
+	if len(prices) == 0 {
+		return nil, fmt.Errorf("no prices")
+	}
+	if len(prices) > MaxPrices {
+		return nil, fmt.Errorf("%v prices, more than %v", len(prices), MaxPrices)
+	}
+
+	l := &PriceLadder{}
+	for i, s := range prices {
+		price, decimals, err := parsePrice(s)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 && price.Cmp(l.prices[i-1]) <= 0 {
+			return nil, fmt.Errorf("prices are not increasing at %v", s)
+		}
+		l.prices = append(l.prices, price)
+		if decimals > l.decimals {
+			l.decimals = decimals
+		}
+	}
+
+	return l, nil
+}
+
+// MaxPriceLadder returns the ladder of the prices 0, 1, ..., max.
+func MaxPriceLadder(max uint) (*PriceLadder, error) {
+	if max >= MaxPrices {
+		return nil, fmt.Errorf("%v prices, more than %v", uint64(max)+1, MaxPrices)
+	}
+
+	l := &PriceLadder{}
+	for i := uint(0); i <= max; i++ {
+		l.prices = append(l.prices, new(big.Rat).SetInt64(int64(i)))
+	}
+	return l, nil
+}
+
+// BitsPriceLadder returns the ladder of the prices that are bits bits long,
+// 0, 1, ..., 2^bits - 1.
+func BitsPriceLadder(bits uint) (*PriceLadder, error) {
+	if bits == 0 || bits > 16 {
+		return nil, fmt.Errorf("%v bits is not between 1 and 16", bits)
+	}
+	return MaxPriceLadder(1<<bits - 1)
+}
+
+// RangePriceLadder returns the ladder of the prices from, from+step, ...,
+// up to and including to if it is a step away from from.
+func RangePriceLadder(from, to, step string) (*PriceLadder, error) {
+	var bounds [3]*big.Rat
+	l := &PriceLadder{}
+	for i, s := range []string{from, to, step} {
+		n, decimals, err := parsePrice(s)
+		if err != nil {
+			return nil, err
+		}
+		bounds[i] = n
+		if decimals > l.decimals {
+			l.decimals = decimals
+		}
+	}
+
+	if bounds[2].Sign() <= 0 {
+		return nil, fmt.Errorf("step %v is not positive", step)
+	}
+	for price := bounds[0]; price.Cmp(bounds[1]) <= 0; price = new(big.Rat).Add(price, bounds[2]) {
+		if len(l.prices) == MaxPrices {
+			return nil, fmt.Errorf("more than %v prices", MaxPrices)
+		}
+		l.prices = append(l.prices, price)
+	}
+	if len(l.prices) == 0 {
+		return nil, fmt.Errorf("no prices from %v to %v", from, to)
+	}
+
+	return l, nil
+}
+
+// parsePrice parses a non-negative decimal number, and returns it with its
+// number of decimals.
+func parsePrice(s string) (*big.Rat, int, error) {
+	price, ok := new(big.Rat).SetString(s)
+	if !ok || strings.ContainsAny(s, "/eE") {
+		return nil, 0, fmt.Errorf("%q is not a decimal number", s)
+	}
+	if price.Sign() < 0 {
+		return nil, 0, fmt.Errorf("price %v is negative", s)
+	}
+
+	decimals := 0
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		decimals = len(s) - i - 1
+	}
+	return price, decimals, nil
+}
+
+// Len returns the number of prices.
+func (l *PriceLadder) Len() int {
+	return len(l.prices)
+}
+
+// Bits returns the number of bits of the largest index.
+func (l *PriceLadder) Bits() uint {
+	bits := uint(big.NewInt(int64(len(l.prices) - 1)).BitLen())
+	if bits == 0 {
+		bits = 1
+	}
+	return bits
+}
+
+// FillsBits returns whether every number of Bits bits is the index of a
+// price, that is whether the number of prices is a power of two.
+func (l *PriceLadder) FillsBits() bool {
+	return len(l.prices) == 1<<l.Bits()
+}
+
+// CheckPrices checks that protocol can run with prices. The millionaire
+// protocol compares bids bit by bit and cannot keep a cheater from bidding
+// a number of Bits bits beyond the last price, so it needs a number of
+// prices that is a power of two.
+func CheckPrices(protocol string, prices *PriceLadder) error {
+	if protocol == "millionaire" && !prices.FillsBits() {
+		return fmt.Errorf("the millionaire protocol needs a power of two of prices, such as {\"bits\": %v}, not %v",
+			prices.Bits(), prices.Len())
+	}
+	return nil
+}
+
+// Price returns the price with index i.
+func (l *PriceLadder) Price(i int) string {
+	return l.prices[i].FloatString(l.decimals)
+}
+
+// Index returns the index of price, which must be one of the prices.
+func (l *PriceLadder) Index(price string) (int, error) {
+	p, _, err := parsePrice(price)
+	if err != nil {
+		return 0, err
+	}
+	for i, q := range l.prices {
+		if p.Cmp(q) == 0 {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%v is not one of the prices from %v to %v", price, l.Price(0), l.Price(l.Len()-1))
+}
+
+// Digest returns a hash of the prices, which is the same for two ladders
+// exactly when they have the same prices.
+func (l *PriceLadder) Digest() []byte {
+	t := zkp.NewTranscript("auctions/prices")
+	for _, price := range l.prices {
+		t.AppendString("price", price.RatString())
+	}
+	return t.Challenge("digest", new(big.Int).Lsh(zkp.One, 256)).Bytes()
+}
+
+// ParsePrices parses the "prices" entry of a hosts file, which is one of
+//
+//	{"max": 99}                                      the prices 0 to 99
+//	{"bits": 6}                                      the prices 0 to 63
+//	{"from": "10.00", "to": "500.00", "step": "0.25"}
+//	["1.00", "2.50", "5.00"]                         any increasing prices
+//
+// Missing prices select the prices 0 to DefaultMaxPrice, or for the
+// millionaire protocol, the prices of DefaultMillionaireBits bits.
+func ParsePrices(protocol string, raw json.RawMessage) (*PriceLadder, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		if protocol == "millionaire" {
+			return BitsPriceLadder(DefaultMillionaireBits)
+		}
+		return MaxPriceLadder(DefaultMaxPrice)
+	}
+
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return NewPriceLadder(list)
+	}
+
+	var params struct {
+		Max            *uint
+		Bits           *uint
+		From, To, Step string
+	}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case params.Max != nil:
+		return MaxPriceLadder(*params.Max)
+	case params.Bits != nil:
+		return BitsPriceLadder(*params.Bits)
+	case params.From != "" || params.To != "" || params.Step != "":
+		return RangePriceLadder(params.From, params.To, params.Step)
+	}
+	return nil, fmt.Errorf("needs max, bits, or from, to and step")
+}
@@ -0,0 +1,109 @@
+package lib
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParsePrices(test *testing.T) {
+	for _, tc := range []struct {
+		json   string
+		prices []string
+	}{
+		{``, nil}, // the default ladder, checked below
+		{`{"max": 3}`, []string{"0", "1", "2", "3"}},
+		{`{"bits": 2}`, []string{"0", "1", "2", "3"}},
+		{`{"from": "10.00", "to": "11.00", "step": "0.25"}`, []string{"10.00", "10.25", "10.50", "10.75", "11.00"}},
+		{`{"from": "1", "to": "2", "step": "0.3"}`, []string{"1.0", "1.3", "1.6", "1.9"}},
+		{`["1", "2.5", "10"]`, []string{"1.0", "2.5", "10.0"}},
+	} {
+		l, err := ParsePrices("first_price", []byte(tc.json))
+		if err != nil {
+			test.Errorf("%v: %v", tc.json, err)
+			continue
+		}
+		if tc.prices == nil {
+			if l.Len() != DefaultMaxPrice+1 || l.Price(l.Len()-1) != "99" {
+				test.Errorf("Default ladder has %v prices up to %v", l.Len(), l.Price(l.Len()-1))
+			}
+			continue
+		}
+
+		var prices []string
+		for i := 0; i < l.Len(); i++ {
+			prices = append(prices, l.Price(i))
+		}
+		if len(prices) != len(tc.prices) {
+			test.Errorf("%v: got prices %v, want %v", tc.json, prices, tc.prices)
+			continue
+		}
+		for i := range prices {
+			if prices[i] != tc.prices[i] {
+				test.Errorf("%v: got prices %v, want %v", tc.json, prices, tc.prices)
+				break
+			}
+			if j, err := l.Index(prices[i]); err != nil || j != i {
+				test.Errorf("%v: index of %v is %v, %v, want %v", tc.json, prices[i], j, err, i)
+			}
+		}
+	}
+}
+
+func TestParsePricesRejects(test *testing.T) {
+	for _, json := range []string{
+		`{}`,
+		`{"max": 65536}`,
+		`{"bits": 0}`,
+		`{"bits": 17}`,
+		`{"from": "2", "to": "1", "step": "1"}`,
+		`{"from": "0", "to": "1", "step": "0"}`,
+		`{"from": "0", "to": "1000", "step": "0.001"}`,
+		`[]`,
+		`["1", "1"]`,
+		`["2", "1"]`,
+		`["-1", "1"]`,
+		`["1/2"]`,
+		`["1e3"]`,
+		`"cheap"`,
+	} {
+		if _, err := ParsePrices("first_price", []byte(json)); err == nil {
+			test.Errorf("%v: accepted", json)
+		}
+	}
+}
+
+func TestPriceLadderIndex(test *testing.T) {
+	l, err := RangePriceLadder("10.00", "500.00", "0.25")
+	if err != nil {
+		test.Fatal(err)
+	}
+	if l.Len() != 1961 || l.Bits() != 11 {
+		test.Errorf("Ladder has %v prices of %v bits, want 1961 of 11 bits", l.Len(), l.Bits())
+	}
+
+	for price, want := range map[string]int{"10": 0, "10.25": 1, "12.5": 10, "500.00": 1960} {
+		if i, err := l.Index(price); err != nil || i != want {
+			test.Errorf("Index of %v is %v, %v, want %v", price, i, err, want)
+		}
+	}
+	for _, price := range []string{"9.75", "10.10", "500.25", "ten"} {
+		if _, err := l.Index(price); err == nil {
+			test.Errorf("%v has an index", price)
+		}
+	}
+}
+
+func TestPriceLadderDigest(test *testing.T) {
+	a, _ := NewPriceLadder([]string{"0", "1", "2", "3"})
+	b, _ := MaxPriceLadder(3)
+	c, _ := BitsPriceLadder(2)
+	d, _ := MaxPriceLadder(4)
+	e, _ := NewPriceLadder([]string{"0", "1", "2", "3.5"})
+
+	if !bytes.Equal(a.Digest(), b.Digest()) || !bytes.Equal(a.Digest(), c.Digest()) {
+		test.Errorf("Equal ladders have different digests")
+	}
+	if bytes.Equal(a.Digest(), d.Digest()) || bytes.Equal(a.Digest(), e.Digest()) {
+		test.Errorf("Different ladders have equal digests")
+	}
+}
@@ -0,0 +1,226 @@
+package lib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+)
+
+var (
+	publishedResult = flag.String("result", "", "JSON result of the auction, as printed with -json, to compare with the one verified")
+)
+
+// Replay holds the messages of a transcript, to run the rounds of an
+// auction again offline and check every message of every party. The
+// secrets of the parties are not in the transcript, so the Compute function
+// of a replayed round does not compute a message, but restores what the
+// party computed from its message, which Message returns.
+type Replay struct {
+	config   *AuctionConfig // of the run of the transcript
+	recorder int            // the party that wrote the transcript
+
+	// messages are the messages of the rounds, by step and client id
+	messages map[int32][]*pb.OuterStruct
+}
+
+// NewReplay returns the replay of transcript t of the auction described by
+// config. It checks that t belongs to the auction, and that every message
+// in it is signed by its sender.
+func NewReplay(config *AuctionConfig, t *Transcript) (*Replay, error) {
+	if t.AuctionID != config.AuctionID && !strings.HasPrefix(t.AuctionID, config.AuctionID+"-excluding-") {
+		return nil, fmt.Errorf("transcript of auction %q, not %q", t.AuctionID, config.AuctionID)
+	}
+
+	// Parties may have been excluded since the configuration was written,
+	// which keeps the others in order
+	c := *config
+	c.Hosts, c.AuctionID, c.hostIDs, c.Fingerprints = t.Hosts, t.AuctionID, nil, nil
+	i := 0
+	for _, host := range t.Hosts {
+		for i < len(config.Hosts) && config.Hosts[i] != host {
+			i++
+		}
+		if i == len(config.Hosts) {
+			return nil, fmt.Errorf("transcript of unknown host %v", host)
+		}
+		c.hostIDs = append(c.hostIDs, config.HostID(i))
+		if config.Fingerprints != nil {
+			c.Fingerprints = append(c.Fingerprints, config.Fingerprints[i])
+		}
+		i++
+	}
+	r := &Replay{
+		config:   &c,
+		recorder: t.MyID,
+		messages: make(map[int32][]*pb.OuterStruct),
+	}
+
+	for _, record := range t.Records {
+		msg := record.Message
+		client := int(msg.Clientid)
+		if msg.Stepid <= 0 {
+			// Echoes and accusations are not part of the rounds
+			continue
+		}
+		if client < 0 || client >= len(t.Hosts) {
+			return nil, fmt.Errorf("message of unknown party %v", client)
+		}
+		if msg.Auctionid != t.AuctionID {
+			return nil, fmt.Errorf("message of party %v in round %v is of auction %q", client, msg.Stepid, msg.Auctionid)
+		}
+		if err := verifyMessage(t.Keys[client], msg); err != nil {
+			return nil, fmt.Errorf("message of party %v in round %v is not signed by it: %v", client, msg.Stepid, err)
+		}
+
+		round := r.messages[msg.Stepid]
+		if round == nil {
+			round = make([]*pb.OuterStruct, len(t.Hosts))
+			r.messages[msg.Stepid] = round
+		}
+		if prev := round[client]; prev != nil {
+			if !reflect.DeepEqual(messageDigest(prev), messageDigest(msg)) {
+				return nil, fmt.Errorf("party %v signed different messages in round %v", client, msg.Stepid)
+			}
+			continue
+		}
+		round[client] = msg
+	}
+
+	return r, nil
+}
+
+// Parties returns the parties to replay the transcript as, so that every
+// message is checked: the party that wrote it, and another one that checks
+// its messages.
+func (r *Replay) Parties() []int {
+	return []int{r.recorder, (r.recorder + 1) % len(r.config.Hosts)}
+}
+
+// Config returns the configuration of party id in the run of the
+// transcript.
+func (r *Replay) Config(id int) *AuctionConfig {
+	c := *r.config
+	c.MyID = id
+	return &c
+}
+
+// Message returns the message of client in step, or nil.
+func (r *Replay) Message(step, client int) *pb.OuterStruct {
+	round := r.messages[int32(step)]
+	if round == nil || client < 0 || client >= len(round) {
+		return nil
+	}
+	return round[client]
+}
+
+// Run replays rounds as party config.MyID with the given state: every round
+// restores its state with Compute, checks the messages of all other
+// parties, and receives them. It returns the Result the party found, but
+// for the winners and the price, which the protocol fills in from its state.
+func (r *Replay) Run(config *AuctionConfig, rounds []Round, state interface{}) (*Result, error) {
+	transcript := sha256.New()
+
+	for i, round := range rounds {
+		step := i + 1
+		msgs := make([]*pb.OuterStruct, len(r.config.Hosts))
+		for client := range msgs {
+			if msgs[client] = r.Message(step, client); msgs[client] == nil {
+				return nil, &RoundError{Round: step, Clientid: client, Kind: TimeoutError,
+					Err: fmt.Errorf("no message in the transcript")}
+			}
+		}
+
+		if _, _, err := round.Compute(state); err != nil {
+			return nil, roundError(step, err)
+		}
+		for client, msg := range msgs {
+			if client == config.MyID {
+				continue
+			}
+			if err := round.Check(state, msg); err != nil {
+				e, ok := err.(*RoundError)
+				if !ok {
+					e = &RoundError{Clientid: client, Kind: ProofError, Err: err}
+				}
+				return nil, roundError(step, e)
+			}
+		}
+		if err := round.Receive(state, msgs); err != nil {
+			return nil, roundError(step, err)
+		}
+
+		hashMessages(transcript, msgs)
+	}
+
+	return &Result{
+		AuctionID:      r.config.AuctionID,
+		TranscriptHash: hex.EncodeToString(transcript.Sum(nil)),
+	}, nil
+}
+
+// Verifier replays a transcript as party config.MyID, and returns the result
+// it finds, for Verify.
+type Verifier func(config *AuctionConfig, replay *Replay) (*Result, error)
+
+// Verify is the verify command of a protocol. It replays the transcript file
+// name of the auction described by config as every party of Parties with
+// verify, and checks that they find the same result, and the one in the
+// file given by the -result flag, if any. It returns that result.
+func Verify(config *AuctionConfig, name string, verify Verifier) (*Result, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	t, err := ReadTranscript(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transcript %v: %v", name, err)
+	}
+	replay, err := NewReplay(config, t)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *Result
+	for _, id := range replay.Parties() {
+		r, err := verify(replay.Config(id), replay)
+		if err != nil {
+			return nil, fmt.Errorf("replaying as party %v: %v", id, err)
+		}
+		if result != nil && !sameResult(r, result) {
+			return nil, fmt.Errorf("party %v finds %+v, but party %v %+v", id, r, replay.Parties()[0], result)
+		}
+		result = r
+	}
+
+	if *publishedResult != "" {
+		data, err := os.ReadFile(*publishedResult)
+		if err != nil {
+			return nil, err
+		}
+		var published Result
+		if err := json.Unmarshal(data, &published); err != nil {
+			return nil, fmt.Errorf("invalid result %v: %v", *publishedResult, err)
+		}
+		if !sameResult(&published, result) {
+			return nil, fmt.Errorf("the published result %+v does not follow from the transcript, which gives %+v", &published, result)
+		}
+	}
+
+	return result, nil
+}
+
+// sameResult returns whether a and b have the same auction, winners, price
+// and transcript hash.
+func sameResult(a, b *Result) bool {
+	return a.AuctionID == b.AuctionID && reflect.DeepEqual(a.Winners, b.Winners) &&
+		a.Price == b.Price && a.TranscriptHash == b.TranscriptHash
+}
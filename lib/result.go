@@ -0,0 +1,53 @@
+package lib
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+var (
+	jsonOutput = flag.Bool("json", false, "Print the result of the auction as JSON on standard output")
+)
+
+// Result is the outcome of an auction, as seen by one party.
+type Result struct {
+	AuctionID string `json:"auctionID"`
+
+	// Winners are the ids of the winning parties.
+	Winners []int `json:"winners"`
+	// Price is the price the winners pay, one of the prices of the
+	// auction. It is empty for protocols without a price.
+	Price string `json:"price,omitempty"`
+
+	// RoundDurations are the wall-clock durations of the rounds, in
+	// nanoseconds in JSON.
+	RoundDurations []time.Duration `json:"roundDurations"`
+	BytesSent      int64           `json:"bytesSent"`
+	BytesReceived  int64           `json:"bytesReceived"`
+
+	// TranscriptHash is a hash of every message of every round, which is
+	// the same for all parties that saw the same auction.
+	TranscriptHash string `json:"transcriptHash"` // in hexadecimal
+}
+
+// Write writes r to w, as JSON if the -json flag is set, or else as text.
+func (r *Result) Write(w io.Writer) error {
+	if *jsonOutput {
+		return json.NewEncoder(w).Encode(r)
+	}
+
+	_, err := fmt.Fprintf(w, "Winners: %v\nPrice: %v\nRound Durations: %v\nBytes Sent: %v\nBytes Received: %v\nTranscript Hash: %v\n",
+		r.Winners, r.Price, r.RoundDurations, r.BytesSent, r.BytesReceived, r.TranscriptHash)
+	return err
+}
+
+// PrintResult writes r to standard output, as JSON if the -json flag is set.
+func PrintResult(r *Result) {
+	if err := r.Write(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to print the result: %v\n", err)
+	}
+}
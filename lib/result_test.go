@@ -0,0 +1,47 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestResultJSON(test *testing.T) {
+	oldJSON := *jsonOutput
+	*jsonOutput = true
+	defer func() { *jsonOutput = oldJSON }()
+
+	r := &Result{
+		AuctionID:      "test",
+		Winners:        []int{2},
+		Price:          "10.25",
+		RoundDurations: []time.Duration{time.Second, time.Millisecond},
+		BytesSent:      100,
+		BytesReceived:  200,
+		TranscriptHash: "00ff",
+	}
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		test.Fatal(err)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &fields); err != nil {
+		test.Fatalf("Result is not JSON: %v: %s", err, buf.Bytes())
+	}
+	want := map[string]interface{}{
+		"auctionID":      "test",
+		"winners":        []interface{}{2.0},
+		"price":          "10.25",
+		"roundDurations": []interface{}{1e9, 1e6},
+		"bytesSent":      100.0,
+		"bytesReceived":  200.0,
+		"transcriptHash": "00ff",
+	}
+	if !reflect.DeepEqual(fields, want) {
+		test.Errorf("Result is %s, want %v", buf.Bytes(), want)
+	}
+}
@@ -0,0 +1,104 @@
+package lib
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+)
+
+// Every message is signed by the party that sent it with the key of its
+// certificate, over the auction id, the client id, the step id and the
+// hash of the data. So a message relayed by the seller still proves who
+// sent it, and the evidence against a cheater cannot be forged by its
+// accuser.
+
+// messageDigest returns the hash of msg that its signature signs.
+func messageDigest(msg *pb.OuterStruct) []byte {
+	return headerDigest(msg.Auctionid, msg.Clientid, msg.Stepid, dataHash(msg.Data))
+}
+
+// dataHash returns the hash of the data of a message.
+func dataHash(data []byte) []byte {
+	h := sha256.Sum256(data)
+	return h[:]
+}
+
+// headerDigest returns the digest that a message of the given auction,
+// client and step, with data of the given hash, is signed over. As it
+// covers the data by its hash, an echo can show what a party signed with
+// the hash alone.
+func headerDigest(auctionID string, clientid, stepid int32, hash []byte) []byte {
+	h := sha256.New()
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[0:], uint32(len(auctionID)))
+	h.Write([]byte("auctions/message"))
+	h.Write(header[:4])
+	h.Write([]byte(auctionID))
+	binary.BigEndian.PutUint32(header[0:], uint32(clientid))
+	binary.BigEndian.PutUint32(header[4:], uint32(stepid))
+	h.Write(header[:])
+	h.Write(hash)
+	return h.Sum(nil)
+}
+
+// signMessage sets the auction id of msg, and signs it with key.
+func signMessage(key crypto.Signer, auctionID string, msg *pb.OuterStruct) error {
+	if key == nil {
+		return fmt.Errorf("no key to sign with")
+	}
+	msg.Auctionid = auctionID
+	sig, err := key.Sign(rand.Reader, messageDigest(msg), crypto.SHA256)
+	if err != nil {
+		return fmt.Errorf("Could not sign message: %v", err)
+	}
+	msg.Signature = sig
+	return nil
+}
+
+// verifyMessage checks that msg is signed with the private key of pub.
+func verifyMessage(pub crypto.PublicKey, msg *pb.OuterStruct) error {
+	return verifyDigest(pub, messageDigest(msg), msg.Signature)
+}
+
+// verifyDigest checks that sig is a signature of the message digest with
+// the private key of pub.
+func verifyDigest(pub crypto.PublicKey, digest, sig []byte) error {
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest, sig)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, digest, sig) {
+			return fmt.Errorf("ECDSA verification failure")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported key type %T", pub)
+}
+
+// sign signs our message msg for the current auction.
+func (s *Session) sign(msg *pb.OuterStruct) error {
+	return signMessage(s.transport.Key(), s.config.AuctionID, msg)
+}
+
+// verify checks that msg belongs to the current auction, and is signed by
+// the party it claims to come from. It returns a SignatureError otherwise.
+func (s *Session) verify(msg *pb.OuterStruct) error {
+	client := int(msg.Clientid)
+	if msg.Auctionid != s.config.AuctionID {
+		return NewError(SignatureError, client, "Message of auction %q, not %q", msg.Auctionid, s.config.AuctionID)
+	}
+	key, err := s.transport.PeerKey(client)
+	if err != nil {
+		return NewError(SignatureError, client, "No key to verify with: %v", err)
+	}
+	if err := verifyMessage(key, msg); err != nil {
+		return NewError(SignatureError, client, "Bad signature on step %v: %v", msg.Stepid, err)
+	}
+	return nil
+}
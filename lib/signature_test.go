@@ -0,0 +1,114 @@
+package lib
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+	"golang.org/x/net/context"
+)
+
+func TestSignMessage(test *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		test.Fatal(err)
+	}
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	for _, key := range []crypto.Signer{rsaKey, ecdsaKey} {
+		msg := &pb.OuterStruct{Clientid: 1, Stepid: 2, Data: []byte("data")}
+		if err := signMessage(key, "auction", msg); err != nil {
+			test.Fatalf("%T: %v", key, err)
+		}
+		if msg.Auctionid != "auction" {
+			test.Errorf("%T: signed message of auction %q, want %q", key, msg.Auctionid, "auction")
+		}
+		if err := verifyMessage(key.Public(), msg); err != nil {
+			test.Errorf("%T: %v", key, err)
+		}
+
+		for _, tamper := range []func(*pb.OuterStruct){
+			func(m *pb.OuterStruct) { m.Clientid = 2 },
+			func(m *pb.OuterStruct) { m.Stepid = 3 },
+			func(m *pb.OuterStruct) { m.Data = []byte("date") },
+			func(m *pb.OuterStruct) { m.Auctionid = "other" },
+		} {
+			m := *msg
+			tamper(&m)
+			if err := verifyMessage(key.Public(), &m); err == nil {
+				test.Errorf("%T: tampered message %v verifies", key, &m)
+			}
+		}
+	}
+}
+
+func TestVerify(test *testing.T) {
+	network := NewMemoryNetwork(2)
+	session := NewSession(&AuctionConfig{Hosts: make([]string, 2), AuctionID: "auction"}, network.Transport(0))
+
+	for _, tc := range []struct {
+		name      string
+		auctionID string
+		signer    int
+		ok        bool
+	}{
+		{"signed", "auction", 1, true},
+		{"other auction", "other", 1, false},
+		{"wrong signer", "auction", 0, false},
+	} {
+		msg := &pb.OuterStruct{Clientid: 1, Stepid: 1}
+		signMessage(network.Transport(tc.signer).Key(), tc.auctionID, msg)
+
+		err := session.verify(msg)
+		if tc.ok {
+			if err != nil {
+				test.Errorf("%v: %v", tc.name, err)
+			}
+			continue
+		}
+		if e, ok := err.(*RoundError); !ok || e.Kind != SignatureError || e.Clientid != 1 {
+			test.Errorf("%v: got %v, want a %v blaming 1", tc.name, err, SignatureError)
+		}
+	}
+}
+
+// relayingTransport changes the data of the messages to victim, as a relay
+// could, without being able to sign them.
+type relayingTransport struct {
+	Transport
+	victim int
+}
+
+func (t *relayingTransport) Send(ctx context.Context, to int, msg *pb.OuterStruct) error {
+	if to == t.victim && msg.Stepid > 0 {
+		msg = &pb.OuterStruct{Clientid: msg.Clientid, Stepid: msg.Stepid, Data: []byte{},
+			Signature: msg.Signature, Auctionid: msg.Auctionid}
+	}
+	return t.Transport.Send(ctx, to, msg)
+}
+
+func TestBadSignature(test *testing.T) {
+	const n, sender, victim = 3, 2, 0
+
+	network := NewMemoryNetwork(n)
+	errs := runSum(n, false, []int{victim}, func(i int) Transport {
+		if i == sender {
+			return &relayingTransport{network.Transport(i), victim}
+		}
+		return network.Transport(i)
+	}, func(int, Transport) {})
+
+	// The message proves nothing, so it is no evidence to accuse with
+	e, ok := errs[0].(*RoundError)
+	if !ok || e.Kind != SignatureError || e.Clientid != sender || e.Round != 1 || e.Evidence != nil {
+		test.Errorf("Party %v failed with %v, want a %v blaming %v in round 1 without evidence",
+			victim, errs[0], SignatureError, sender)
+	}
+}
@@ -0,0 +1,200 @@
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// A transcript file holds every message a party sent and received in a run
+// of an auction, as a sequence of pb.TranscriptRecords, each preceded by its
+// length as a varint. Every record holds the SHA-256 hash of the record
+// before it, so records cannot be dropped or changed without breaking the
+// chain.
+
+// transcriptTransport records everything sent and received over another
+// transport in a transcript file.
+type transcriptTransport struct {
+	Transport
+	config *AuctionConfig
+
+	lock sync.Mutex
+	w    io.Writer
+	prev []byte // the hash of the last record
+	err  error  // the first error writing to w
+}
+
+// RecordTranscript returns transport, recording the run of config in the
+// transcript file w.
+func RecordTranscript(config *AuctionConfig, transport Transport, w io.Writer) Transport {
+	return &transcriptTransport{Transport: transport, config: config, w: w}
+}
+
+// record appends record to the transcript. Failing to do so does not stop
+// the auction; the error is returned by Close.
+func (t *transcriptTransport) record(record *pb.TranscriptRecord) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.err != nil {
+		return
+	}
+
+	record.Prev = t.prev
+	data, err := proto.Marshal(record)
+	if err == nil {
+		var length [binary.MaxVarintLen64]byte
+		n := binary.PutUvarint(length[:], uint64(len(data)))
+		if _, err = t.w.Write(length[:n]); err == nil {
+			_, err = t.w.Write(data)
+		}
+	}
+	if err != nil {
+		log.Printf("Failed to write the transcript: %v", err)
+		t.err = err
+		return
+	}
+
+	hash := sha256.Sum256(data)
+	t.prev = hash[:]
+}
+
+func (t *transcriptTransport) Connect(ctx context.Context, deliver DeliverFn) error {
+	t.record(&pb.TranscriptRecord{
+		Auctionid: t.config.AuctionID,
+		Hosts:     t.config.Hosts,
+		Myid:      int32(t.config.MyID),
+	})
+
+	err := t.Transport.Connect(ctx, func(in *pb.OuterStruct) error {
+		t.record(&pb.TranscriptRecord{Message: in})
+		return deliver(in)
+	})
+	if err != nil {
+		return err
+	}
+
+	keys := make([][]byte, len(t.config.Hosts))
+	for i := range keys {
+		key, err := t.PeerKey(i)
+		if err == nil {
+			keys[i], err = x509.MarshalPKIXPublicKey(key)
+		}
+		if err != nil {
+			log.Printf("Failed to record the key of party %v: %v", i, err)
+		}
+	}
+	t.record(&pb.TranscriptRecord{Keys: keys})
+
+	return nil
+}
+
+func (t *transcriptTransport) Send(ctx context.Context, to int, msg *pb.OuterStruct) error {
+	if err := t.Transport.Send(ctx, to, msg); err != nil {
+		return err
+	}
+	t.record(&pb.TranscriptRecord{Message: msg, Sent: true, Peer: int32(to)})
+	return nil
+}
+
+func (t *transcriptTransport) Close() error {
+	err := t.Transport.Close()
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if err == nil && t.err != nil {
+		err = fmt.Errorf("failed to write the transcript: %v", t.err)
+	}
+	return err
+}
+
+// Transcript is a transcript file, as read by ReadTranscript.
+type Transcript struct {
+	// AuctionID, Hosts and MyID describe the run of the auction, as seen
+	// by the party MyID that wrote the transcript.
+	AuctionID string
+	Hosts     []string
+	MyID      int
+
+	// Keys are the public keys of the parties.
+	Keys []crypto.PublicKey
+
+	// Records are the messages sent and received, in order.
+	Records []*pb.TranscriptRecord
+}
+
+// ReadTranscript reads a transcript file, and checks its hash chain.
+func ReadTranscript(r io.Reader) (*Transcript, error) {
+	br := bufio.NewReader(r)
+	t := &Transcript{}
+	var prev []byte
+
+	for i := 0; ; i++ {
+		length, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("record %v: %v", i, err)
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return nil, fmt.Errorf("record %v: %v", i, err)
+		}
+
+		var record pb.TranscriptRecord
+		if err := proto.Unmarshal(data, &record); err != nil {
+			return nil, fmt.Errorf("record %v: %v", i, err)
+		}
+		if !bytes.Equal(record.Prev, prev) {
+			return nil, fmt.Errorf("record %v does not follow the one before it", i)
+		}
+		hash := sha256.Sum256(data)
+		prev = hash[:]
+
+		switch {
+		case i == 0:
+			if record.Message != nil || len(record.Hosts) == 0 {
+				return nil, fmt.Errorf("record 0 does not describe the auction")
+			}
+			t.AuctionID, t.Hosts, t.MyID = record.Auctionid, record.Hosts, int(record.Myid)
+			if t.MyID < 0 || t.MyID >= len(t.Hosts) {
+				return nil, fmt.Errorf("party %v is not one of the %v hosts", t.MyID, len(t.Hosts))
+			}
+		case record.Message != nil:
+			t.Records = append(t.Records, &record)
+		case t.Keys == nil:
+			if len(record.Keys) != len(t.Hosts) {
+				return nil, fmt.Errorf("record %v has %v keys for %v hosts", i, len(record.Keys), len(t.Hosts))
+			}
+			for j, der := range record.Keys {
+				key, err := x509.ParsePKIXPublicKey(der)
+				if err != nil {
+					return nil, fmt.Errorf("key of party %v: %v", j, err)
+				}
+				t.Keys = append(t.Keys, key)
+			}
+		default:
+			return nil, fmt.Errorf("record %v holds nothing", i)
+		}
+	}
+
+	if t.Hosts == nil {
+		return nil, fmt.Errorf("empty transcript")
+	}
+	if t.Keys == nil {
+		return nil, fmt.Errorf("transcript without the keys of the parties")
+	}
+	return t, nil
+}
@@ -0,0 +1,69 @@
+package lib
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTranscript(test *testing.T) {
+	const n, recorder = 3, 1
+
+	var buf bytes.Buffer
+	network := NewMemoryNetwork(n)
+	errs := runSum(n, false, []int{0, 1, 2}, func(i int) Transport {
+		t := network.Transport(i)
+		if i == recorder {
+			t = RecordTranscript(&AuctionConfig{Hosts: make([]string, n), MyID: i, AuctionID: "test"}, t, &buf)
+		}
+		return t
+	}, func(int, Transport) {})
+	for i, err := range errs {
+		if err != nil {
+			test.Fatalf("Party %v failed: %v", i, err)
+		}
+	}
+
+	data := buf.Bytes()
+	t, err := ReadTranscript(bytes.NewReader(data))
+	if err != nil {
+		test.Fatalf("Failed to read the transcript: %v", err)
+	}
+	if t.AuctionID != "test" || len(t.Hosts) != n || t.MyID != recorder || len(t.Keys) != n {
+		test.Errorf("Read transcript of auction %q among %v hosts by party %v with %v keys",
+			t.AuctionID, len(t.Hosts), t.MyID, len(t.Keys))
+	}
+
+	// The sum round and the acknowledgements, sent to and received from
+	// both other parties
+	sent, received := 0, 0
+	for _, record := range t.Records {
+		if record.Sent {
+			sent++
+		} else {
+			received++
+		}
+		if err := verifyMessage(t.Keys[record.Message.Clientid], record.Message); err != nil {
+			test.Errorf("Message %v does not verify: %v", record.Message, err)
+		}
+	}
+	if sent != 4 || received != 4 {
+		test.Errorf("Transcript has %v messages sent and %v received, want 4 and 4", sent, received)
+	}
+
+	// Changing the first record breaks the chain at the second
+	changed := append([]byte(nil), data...)
+	changed[bytes.Index(changed, []byte("test"))] ^= 1
+
+	for _, tc := range []struct {
+		name string
+		data []byte
+	}{
+		{"truncated", data[:len(data)-1]},
+		{"changed", changed},
+		{"empty", nil},
+	} {
+		if _, err := ReadTranscript(bytes.NewReader(tc.data)); err == nil {
+			test.Errorf("%v transcript was read", tc.name)
+		}
+	}
+}
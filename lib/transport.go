@@ -0,0 +1,149 @@
+package lib
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"sync"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// DeliverFn hands a message received from another party to the session.
+type DeliverFn func(*pb.OuterStruct) error
+
+// Transport carries the messages of a session between the parties.
+type Transport interface {
+	// Connect connects to all the other parties. From then on, every
+	// message another party sends us is passed to deliver.
+	Connect(ctx context.Context, deliver DeliverFn) error
+	// Send sends msg to the party with the given id, and returns once that
+	// party has received it.
+	Send(ctx context.Context, to int, msg *pb.OuterStruct) error
+	// Close disconnects from the other parties.
+	Close() error
+
+	// Key returns the private key our messages are signed with, once
+	// connected.
+	Key() crypto.Signer
+	// PeerKey returns the public key the messages of the party with the
+	// given id are signed with, once connected.
+	PeerKey(id int) (crypto.PublicKey, error)
+}
+
+// MemoryNetwork connects the sessions of parties that run in the same
+// process, e.g. in tests.
+type MemoryNetwork struct {
+	lock     sync.Mutex
+	delivers []DeliverFn
+	ready    []chan struct{} // closed once party i is connected
+	keys     []*ecdsa.PrivateKey
+
+	deliveries []sync.WaitGroup // the messages being delivered to party i
+}
+
+// NewMemoryNetwork returns a network of n parties, with ids 0 to n-1.
+func NewMemoryNetwork(n int) *MemoryNetwork {
+	network := &MemoryNetwork{
+		delivers: make([]DeliverFn, n),
+		ready:    make([]chan struct{}, n),
+		keys:     make([]*ecdsa.PrivateKey, n),
+
+		deliveries: make([]sync.WaitGroup, n),
+	}
+	for i := range network.ready {
+		network.ready[i] = make(chan struct{})
+
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			panic(fmt.Sprintf("Could not generate key of party %v: %v", i, err))
+		}
+		network.keys[i] = key
+	}
+	return network
+}
+
+// Transport returns the transport of the party with the given id.
+func (network *MemoryNetwork) Transport(id int) Transport {
+	return &memoryTransport{network: network, id: id}
+}
+
+// Disconnect disconnects the party with the given id, as if its process
+// died, so that it may connect again. Until then, messages to it wait. It
+// returns once the messages being delivered to it are.
+func (network *MemoryNetwork) Disconnect(id int) {
+	network.lock.Lock()
+	if network.delivers[id] != nil {
+		network.delivers[id] = nil
+		network.ready[id] = make(chan struct{})
+	}
+	network.lock.Unlock()
+
+	network.deliveries[id].Wait()
+}
+
+// memoryTransport is one party's end of a MemoryNetwork.
+type memoryTransport struct {
+	network *MemoryNetwork
+	id      int
+}
+
+func (t *memoryTransport) Connect(ctx context.Context, deliver DeliverFn) error {
+	t.network.lock.Lock()
+	defer t.network.lock.Unlock()
+
+	if t.network.delivers[t.id] != nil {
+		return fmt.Errorf("party %v is already connected", t.id)
+	}
+	t.network.delivers[t.id] = deliver
+	close(t.network.ready[t.id])
+
+	return nil
+}
+
+func (t *memoryTransport) Send(ctx context.Context, to int, msg *pb.OuterStruct) error {
+	if to < 0 || to >= len(t.network.ready) {
+		return fmt.Errorf("no party %v", to)
+	}
+
+	for {
+		t.network.lock.Lock()
+		ready, deliver := t.network.ready[to], t.network.delivers[to]
+		if deliver != nil {
+			t.network.deliveries[to].Add(1)
+		}
+		t.network.lock.Unlock()
+
+		if deliver != nil {
+			defer t.network.deliveries[to].Done()
+			// Copy, as if the message went over the wire
+			return deliver(proto.Clone(msg).(*pb.OuterStruct))
+		}
+
+		// Wait for the receiver to connect
+		select {
+		case <-ready:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (t *memoryTransport) Close() error {
+	return nil
+}
+
+func (t *memoryTransport) Key() crypto.Signer {
+	return t.network.keys[t.id]
+}
+
+func (t *memoryTransport) PeerKey(id int) (crypto.PublicKey, error) {
+	if id < 0 || id >= len(t.network.keys) {
+		return nil, fmt.Errorf("no party %v", id)
+	}
+	return t.network.keys[id].Public(), nil
+}
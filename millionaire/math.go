@@ -6,23 +6,21 @@ import (
 	"math/big"
 )
 
-// Computes PI_{d=j+1}^{k} (a1_d/a2_d)^2^d-2
+// Computes PI_{d=j+1}^{k} (a1_d/a2_d)^2^(d-j+1)
 // where a1 and a2 are either both alpha arrays
-// or beta
+// or beta.
+// Any difference in the bits above j then contributes at least Y^4 or
+// Y^-4, which Y^0..Y^2 from bit j itself can never cancel out.
 func multiplyDivideExponentiate(a1 []big.Int, a2 []big.Int, j int, p big.Int) big.Int {
 	product := *big.NewInt(1)
 	var temp, tempExp big.Int
 
-	for d := j + 1; uint(d) < zkp.K_Mill; d++ {
+	for d := j + 1; d < len(a1); d++ {
 		temp.ModInverse(&a2[d], &p)
 		temp.Mul(&a1[d], &temp)
 
-		// because d is considered an array index, when used for the actual math,
-		// we must add 1 to it
-		tempD := big.NewInt(int64(d + 1))
+		tempD := big.NewInt(int64(d - j + 1))
 		tempExp.Exp(zkp.Two, tempD, nil)
-		tempExp.Sub(&tempExp, zkp.Two)
-		// tempExp.Mod(&tempExp, &p)
 
 		temp.Exp(&temp, &tempExp, &p)
 
@@ -38,7 +36,7 @@ func MillionaireCalculateGammaDelta(alpha_1 []big.Int, alpha_2 []big.Int,
 	beta_1 []big.Int, beta_2 []big.Int, bigY big.Int, p big.Int) *GammaDeltaStruct {
 	var gds GammaDeltaStruct
 
-	for j := 0; uint(j) < zkp.K_Mill; j++ {
+	for j := 0; j < len(alpha_1); j++ {
 		var gammaJ, deltaJ big.Int
 		var temp, temp2, temp3, temp4 big.Int
 
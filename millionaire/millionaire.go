@@ -2,7 +2,6 @@ package main
 
 import (
 	"flag"
-	"fmt"
 	"log"
 	"math/big"
 
@@ -10,6 +9,7 @@ import (
 	"github.com/ashwinsr/auctions/lib"
 	"github.com/ashwinsr/auctions/zkp"
 	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
 )
 
 type PhiStruct struct {
@@ -26,6 +26,13 @@ type AlphaBetaStruct struct {
 
 // keeps state
 type state struct {
+	id   int
+	bid  uint // the index of the bid in the prices
+	bits uint // the number of bits of a bid
+
+	group     *zkp.GroupParams
+	auctionID string
+
 	myPrivateKey big.Int
 	myPublicKey  big.Int
 	keys         []big.Int
@@ -41,6 +48,8 @@ type state struct {
 	phisBeforeExponentiation      *PhiStruct
 	myPhis                        *PhiStruct
 	theirPhis                     *PhiStruct
+
+	winner int
 }
 
 func getState(state_ interface{}) (s *state) {
@@ -54,10 +63,50 @@ func getState(state_ interface{}) (s *state) {
 
 var (
 	myAddress = flag.String("address", "localhost:1234", "address")
-	bid       = flag.Uint("bid", 0, "Amount of money")
-	id        = new(int)
+	bid       = flag.String("bid", "0", "Amount of money, one of the prices of the hosts file")
+)
+
+// The step ids of the rounds, as numbered by lib.Session.Run
+const (
+	stepHandshake = iota + 1
+	stepRound1
+	stepRound2
+	stepRound3
+	stepRound4
+	stepRound5
+	stepRound6
 )
 
+// millionaireRounds returns the rounds of the protocol. They start with
+// the handshake, checking that both parties agree on the auction.
+//
+// The bids are compared bit by bit, so that a cheater cannot bid an index
+// beyond the last price, lib.ReadAuctionConfig only accepts a number of
+// prices that is a power of two.
+func millionaireRounds(config *lib.AuctionConfig) []lib.Round {
+	return []lib.Round{
+		lib.HandshakeRound(config, protocolVersion, lib.Parameter{Name: "proof label", Value: protocolLabel}),
+		{computeRound1, checkRound1, receiveRound1},
+		{computeRound2, checkRound2, receiveRound2},
+		{computeRound3, checkRound3, receiveRound3},
+		{computeRound4, checkRound4, receiveRound4},
+		{computeRound5, checkRound5, receiveRound5},
+		{computeRound6, checkRound6, receiveRound6},
+	}
+}
+
+// The label of the protocol in the transcripts of its proofs
+const protocolLabel = "auctions/millionaire"
+
+// The version of the protocol, which changes whenever its messages do
+const protocolVersion = 1
+
+// transcript returns the transcript of the proofs sent by client in step,
+// where steps are numbered from 1 as by lib.Session.Run.
+func (s *state) transcript(step int, client int) *zkp.Transcript {
+	return zkp.NewRoundTranscript(protocolLabel, s.auctionID, s.group, step, client)
+}
+
 // ROUND 1 FUNCTIONS
 
 /*
@@ -68,59 +117,64 @@ var (
  * 5. Receives n public keys from keyChan, puts them in state.keys
  * 6. Calculates the final public key, and stores into state.
  */
-func computeRound1(state interface{}) (proto.Message, bool) {
+func computeRound1(state interface{}) (proto.Message, bool, error) {
 	s := getState(state)
 
 	// Generate private key
-	s.myPrivateKey.Rand(zkp.RandGen, new(big.Int).Sub(zkp.Q, zkp.One))
-	s.myPrivateKey.Add(&s.myPrivateKey, zkp.One)
+	s.myPrivateKey.Set(zkp.RandomExponent(s.group.Q))
 	// Calculate public key
-	s.myPublicKey.Exp(zkp.G, &s.myPrivateKey, zkp.P)
+	s.myPublicKey.Exp(s.group.G, &s.myPrivateKey, s.group.P)
 
 	// Generate zkp of private key
-	t, r := zkp.DiscreteLogKnowledge(s.myPrivateKey, *zkp.G, *zkp.P, *zkp.Q)
+	t, r := zkp.DiscreteLogKnowledge(s.transcript(stepRound1, s.id), s.myPrivateKey, *s.group.G, *s.group.P, *s.group.Q)
 
 	return &pb.Key{
 		Key:   s.myPublicKey.Bytes(),
 		Proof: pb.CreateDiscreteLogKnowledge(t, r),
-	}, false
+	}, false, nil
 }
 
 func checkRound1(state interface{}, result *pb.OuterStruct) (err error) {
+	s := getState(state)
 	var key pb.Key
 
 	err = proto.Unmarshal(result.Data, &key)
 	if err != nil {
-		fmt.Println(err)
-		log.Fatalf("Failed to unmarshal pb.Key.\n")
+		return lib.NewError(lib.DecodeError, int(result.Clientid), "Failed to unmarshal pb.Key: %v", err)
+	}
+
+	if key.Proof == nil {
+		return lib.NewError(lib.DecodeError, int(result.Clientid), "Missing zero-knowledge proof of key")
 	}
 
 	var k big.Int
 	k.SetBytes(key.Key)
+	if k.Cmp(zkp.One) <= 0 || k.Cmp(s.group.P) >= 0 {
+		return lib.NewError(lib.DecodeError, int(result.Clientid), "Received invalid key %v", &k)
+	}
 	t, r := pb.DestructDiscreteLogKnowledge(key.Proof)
 
-	err = zkp.CheckDiscreteLogKnowledgeProof(*zkp.G, k, t, r, *zkp.P, *zkp.Q)
+	err = zkp.CheckDiscreteLogKnowledgeProof(s.transcript(stepRound1, int(result.Clientid)), *s.group.G, k, t, r, *s.group.P, *s.group.Q)
 	if err != nil {
-		log.Fatalf("Received incorrect zero-knowledge proof. Key=%v, t=%v, r=%v", k, t, r)
+		return lib.NewError(lib.ProofError, int(result.Clientid), "Received incorrect zero-knowledge proof. Key=%v, t=%v, r=%v: %v", &k, &t, &r, err)
 	}
 
 	return
 }
 
-func receiveRound1(state interface{}, results []*pb.OuterStruct) {
+func receiveRound1(state interface{}, results []*pb.OuterStruct) error {
 	s := getState(state)
 	var key pb.Key
 
 	s.keys = append(s.keys, s.myPublicKey)
 
 	for i := 0; i < len(results); i++ {
-		if i == *id {
+		if i == s.id {
 			continue
 		}
 		err := proto.Unmarshal(results[i].Data, &key)
 		if err != nil {
-			fmt.Println(err)
-			log.Fatalf("Failed to unmarshal pb.Key.\n")
+			return lib.NewError(lib.DecodeError, i, "Failed to unmarshal pb.Key: %v", err)
 		}
 		var k big.Int
 		k.SetBytes(key.Key)
@@ -131,55 +185,59 @@ func receiveRound1(state interface{}, results []*pb.OuterStruct) {
 	s.publicKey.Set(zkp.One)
 	for _, key := range s.keys {
 		s.publicKey.Mul(&s.publicKey, &key)
-		s.publicKey.Mod(&s.publicKey, zkp.P)
+		s.publicKey.Mod(&s.publicKey, s.group.P)
 	}
 
 	log.Printf("Calculated public key: %v\n", s.publicKey.String())
+
+	return nil
 }
 
 // ROUND 2 FUNCTIONS
 
-func computeRound2(state interface{}) (proto.Message, bool) {
+func computeRound2(state interface{}) (proto.Message, bool, error) {
 	s := getState(state)
 
 	var alphasInts, betasInts []big.Int
 
 	var proofs []*pb.EqualsOneOfTwo
 
+	tr := s.transcript(stepRound2, s.id)
+
 	var j uint
-	for j = 0; j < zkp.K_Mill; j++ {
+	for j = 0; j < s.bits; j++ {
 		var alphaJ, betaJ, rJ big.Int
-		rJ.Rand(zkp.RandGen, zkp.Q)
+		rJ.Set(zkp.RandomExponent(s.group.Q))
 
-		// log.Printf("r_%v,%v = %v\n", *id, j, rJ.String())
+		// log.Printf("r_%v,%v = %v\n", s.id, j, rJ.String())
 
 		// get the j-th bit of bid
-		Bij := (((*bid) >> j) & 1)
-		log.Printf("B_%v,%v = %v", *id, j, Bij)
+		Bij := (((s.bid) >> j) & 1)
+		log.Printf("B_%v,%v = %v", s.id, j, Bij)
 
 		// calculate alpha_j
-		// log.Printf("Public key: %v, Rj: %v, P: %v\n", s.publicKey, rJ, *zkp.P)
-		alphaJ.Exp(&s.publicKey, &rJ, zkp.P)
+		// log.Printf("Public key: %v, Rj: %v, P: %v\n", s.publicKey, rJ, *s.group.P)
+		alphaJ.Exp(&s.publicKey, &rJ, s.group.P)
 		if Bij == 1 {
-			alphaJ.Mul(&alphaJ, zkp.Y_Mill)
-			alphaJ.Mod(&alphaJ, zkp.P)
+			alphaJ.Mul(&alphaJ, s.group.Y)
+			alphaJ.Mod(&alphaJ, s.group.P)
 		}
 
 		// calculate beta_j
-		betaJ.Exp(zkp.G, &rJ, zkp.P)
+		betaJ.Exp(s.group.G, &rJ, s.group.P)
 
 		alphasInts = append(alphasInts, alphaJ)
 		betasInts = append(betasInts, betaJ)
 
 		var m big.Int
 		if Bij == 1 {
-			m.Set(zkp.Y_Mill)
+			m.Set(s.group.Y)
 		} else {
 			m.Set(zkp.One)
 		}
 		a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2 :=
-			zkp.EncryptedValueIsOneOfTwo(m, s.publicKey, rJ, *zkp.G,
-				*zkp.Y_Mill, *zkp.P, *zkp.Q)
+			zkp.EncryptedValueIsOneOfTwo(tr.Fork("bid", int(j)), m, s.publicKey, rJ, *s.group.G,
+				*s.group.Y, *s.group.P, *s.group.Q)
 
 		proofs = append(proofs, pb.CreateIsOneOfTwo(a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2))
 	}
@@ -196,7 +254,7 @@ func computeRound2(state interface{}) (proto.Message, bool) {
 		Alphas: pb.BigIntSliceToByteSlice(alphasInts),
 		Betas:  pb.BigIntSliceToByteSlice(betasInts),
 		Proofs: proofs,
-	}, false
+	}, false, nil
 }
 
 func checkRound2(state interface{}, result *pb.OuterStruct) (err error) {
@@ -205,110 +263,126 @@ func checkRound2(state interface{}, result *pb.OuterStruct) (err error) {
 
 	err = proto.Unmarshal(result.Data, &in)
 	if err != nil {
-		log.Fatalf("Failed to unmarshal AlphaBeta.\n")
+		return lib.NewError(lib.DecodeError, int(result.Clientid), "Failed to unmarshal AlphaBeta: %v", err)
 	}
 
-	fmt.Println(len(in.Alphas))
-	fmt.Println(len(in.Betas))
-	fmt.Println(len(in.Proofs))
-	fmt.Println(uint(len(in.Proofs)))
-	fmt.Println(zkp.K_Mill)
+	log.Println(len(in.Alphas))
+	log.Println(len(in.Betas))
+	log.Println(len(in.Proofs))
+	log.Println(uint(len(in.Proofs)))
+	log.Println(s.bits)
 
-	if len(in.Alphas) != len(in.Betas) || len(in.Proofs) != len(in.Betas) || uint(len(in.Proofs)) != zkp.K_Mill {
-		log.Fatalf("Incorrect number of alpha/betas in round 2")
+	if len(in.Alphas) != len(in.Betas) || len(in.Proofs) != len(in.Betas) || uint(len(in.Proofs)) != s.bits {
+		return lib.NewError(lib.DecodeError, int(result.Clientid), "Incorrect number of alpha/betas in round 2")
 	}
 
 	alphas := pb.ByteSliceToBigIntSlice(in.Alphas)
 	betas := pb.ByteSliceToBigIntSlice(in.Betas)
 
+	tr := s.transcript(stepRound2, int(result.Clientid))
+
 	for i := 0; i < len(in.Alphas); i++ {
+		if in.Proofs[i] == nil {
+			return lib.NewError(lib.DecodeError, int(result.Clientid), "Missing zero-knowledge proof for alpha/beta")
+		}
+
 		a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2 :=
 			pb.DestructIsOneOfTwo(in.Proofs[i])
 
-		if err := zkp.CheckEncryptedValueIsOneOfTwo(alphas[i], betas[i], *zkp.P, *zkp.Q,
+		if err := zkp.CheckEncryptedValueIsOneOfTwo(tr.Fork("bid", i), alphas[i], betas[i], *s.group.P, *s.group.Q,
 			a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2,
-			*zkp.G, s.publicKey, *zkp.Y_Mill); err != nil {
-			log.Fatalf("Received incorrect zero-knowledge proof for alpha/beta")
+			*s.group.G, s.publicKey, *s.group.Y); err != nil {
+			return lib.NewError(lib.ProofError, int(result.Clientid), "Received incorrect zero-knowledge proof for alpha/beta: %v", err)
 		}
 	}
 
 	return
 }
 
-func receiveRound2(state interface{}, results []*pb.OuterStruct) {
+func receiveRound2(state interface{}, results []*pb.OuterStruct) error {
 	s := getState(state)
 	var alphabeta AlphaBeta
 	s.theirAlphasBetas = &AlphaBetaStruct{}
 
 	// Wait for alphas and betas of other client
 	for i := 0; i < len(results); i++ {
-		if i == *id {
+		if i == s.id {
 			continue
 		}
 		err := proto.Unmarshal(results[i].Data, &alphabeta)
 		if err != nil {
-			log.Fatalf("Failed to unmarshal AlphaBeta.\n")
+			return lib.NewError(lib.DecodeError, i, "Failed to unmarshal AlphaBeta: %v", err)
 		}
 		s.theirAlphasBetas.alphas = pb.ByteSliceToBigIntSlice(alphabeta.Alphas)
 		s.theirAlphasBetas.betas = pb.ByteSliceToBigIntSlice(alphabeta.Betas)
 	}
+
+	return nil
 }
 
 // ROUND 3 FUNCTIONS
 
-func computeRound3(state interface{}) (proto.Message, bool) {
-	s := getState(state)
-
+// computeGammasDeltas computes the gammas and deltas from the alphas and
+// betas of both parties, which are the same for both.
+func (s *state) computeGammasDeltas() {
 	var gds *GammaDeltaStruct
-	if *id == 0 {
+	if s.id == 0 {
 		gds = MillionaireCalculateGammaDelta(s.myAlphasBetas.alphas, s.theirAlphasBetas.alphas,
-			s.myAlphasBetas.betas, s.theirAlphasBetas.betas, *zkp.Y_Mill, *zkp.P)
+			s.myAlphasBetas.betas, s.theirAlphasBetas.betas, *s.group.Y, *s.group.P)
 	} else {
 		gds = MillionaireCalculateGammaDelta(s.theirAlphasBetas.alphas, s.myAlphasBetas.alphas,
-			s.theirAlphasBetas.betas, s.myAlphasBetas.betas, *zkp.Y_Mill, *zkp.P)
+			s.theirAlphasBetas.betas, s.myAlphasBetas.betas, *s.group.Y, *s.group.P)
 	}
 
 	s.myGammasDeltas = gds
 	s.theirGammasDeltas = gds
+}
+
+func computeRound3(state interface{}) (proto.Message, bool, error) {
+	s := getState(state)
 
-	if *id == 0 {
+	s.computeGammasDeltas()
+
+	if s.id == 0 {
 		// if our ID is 0 we verifiably secret shuffle
 		e := zkp.AlphasBetasToCipherTexts(s.myGammasDeltas.Gammas, s.myGammasDeltas.Deltas)
-		E, c, cd, cD, ER, f, fd, yd, zd, F, yD, zD, Z :=
-			zkp.RandomlyPermute(e, *zkp.P, *zkp.Q, *zkp.G, s.publicKey)
+		E, proof := zkp.RandomlyPermute(s.transcript(stepRound3, s.id), e, *s.group.P, *s.group.Q, *s.group.G, s.publicKey)
 		permutedGammas, permutedDeltas := zkp.CipherTextsToAlphasBetas(E)
 		s.myGammasDeltas.Gammas = permutedGammas
 		s.myGammasDeltas.Deltas = permutedDeltas
 		return &MixedOutput{
 			Gammas: pb.BigIntSliceToByteSlice(permutedGammas),
 			Deltas: pb.BigIntSliceToByteSlice(permutedDeltas),
-			Proof:  pb.CreateVerifiableSecretShuffle(c, cd, cD, ER, f, fd, yd, zd, F, yD, zD, Z),
-		}, false
+			Proof:  pb.CreateVerifiableSecretShuffle(proof),
+		}, false, nil
 	}
 
-	return nil, false
+	return nil, false, nil
 }
 
 func checkRound3(state interface{}, result *pb.OuterStruct) (err error) {
+	s := getState(state)
 	log.Printf("About to check for round %v", result.Stepid)
 	// if we are ID 0, we should not receive anything in this round.
-	if *id == 0 {
+	if s.id == 0 {
 		return nil
 	}
 	// otherwise, we have received shuffled gammas/deltas
-	s := getState(state)
 	var in MixedOutput
 
 	err = proto.Unmarshal(result.Data, &in)
 	if err != nil {
-		log.Fatalf("Failed to unmarshal MixedOutput.\n")
+		return lib.NewError(lib.DecodeError, int(result.Clientid), "Failed to unmarshal MixedOutput: %v", err)
 	}
 
-	fmt.Println(len(in.Gammas))
-	fmt.Println(len(in.Deltas))
+	log.Println(len(in.Gammas))
+	log.Println(len(in.Deltas))
 
-	if len(in.Gammas) != len(in.Deltas) {
-		log.Fatalf("Incorrect number of gammas/deltas received from id %v", result.Clientid)
+	if uint(len(in.Gammas)) != s.bits || uint(len(in.Deltas)) != s.bits {
+		return lib.NewError(lib.DecodeError, int(result.Clientid), "Incorrect number of gammas/deltas")
+	}
+	if in.Proof == nil {
+		return lib.NewError(lib.DecodeError, int(result.Clientid), "Missing proof of the shuffle")
 	}
 
 	gammas := pb.ByteSliceToBigIntSlice(in.Gammas)
@@ -316,52 +390,52 @@ func checkRound3(state interface{}, result *pb.OuterStruct) (err error) {
 
 	e := zkp.AlphasBetasToCipherTexts(s.myGammasDeltas.Gammas, s.myGammasDeltas.Deltas)
 	E := zkp.AlphasBetasToCipherTexts(gammas, deltas)
-	
-	log.Printf("Checking: %v, %v\n", e, E)
 
+	err = zkp.CheckVerifiableSecretShuffle(s.transcript(stepRound3, int(result.Clientid)), e, E,
+		*s.group.P, *s.group.Q, *s.group.G, s.publicKey, pb.DestructVerifiableSecretShuffle(in.Proof))
 	if err != nil {
-		log.Fatalf("Received incorrect zero knowledge proof for permuted output 1")
+		return lib.NewError(lib.ProofError, int(result.Clientid), "Received incorrect zero knowledge proof for permuted output 1: %v", err)
 	}
 
 	log.Printf("Checked for round %v!", result.Stepid)
 	return err
 }
 
-func receiveRound3(state interface{}, results []*pb.OuterStruct) {
-	log.Printf("About to receive for round %v", results[1-*id].Stepid)
-	if *id == 0 {
-		return // nothing to actually receive here for ID 0, do not try to demartial
-	}
+func receiveRound3(state interface{}, results []*pb.OuterStruct) error {
 	s := getState(state)
+	log.Printf("About to receive for round %v", results[1-s.id].Stepid)
+	if s.id == 0 {
+		return nil // nothing to actually receive here for ID 0, do not try to demartial
+	}
 	var mixedOutput MixedOutput
 
 	// Wait for alphas and betas of other client
 	for i := 0; i < len(results); i++ {
-		if i == *id {
+		if i == s.id {
 			continue
 		}
 		err := proto.Unmarshal(results[i].Data, &mixedOutput)
 		if err != nil {
-			log.Fatalf("Failed to unmarshal MixedOutput.\n")
+			return lib.NewError(lib.DecodeError, i, "Failed to unmarshal MixedOutput: %v", err)
 		}
 		s.theirGammasDeltas.Gammas = pb.ByteSliceToBigIntSlice(mixedOutput.Gammas)
 		s.theirGammasDeltas.Deltas = pb.ByteSliceToBigIntSlice(mixedOutput.Deltas)
 	}
+
+	return nil
 }
 
 // ROUND 4 FUNCTIONS
 
-func computeRound4(state interface{}) (proto.Message, bool) {
-	if *id == 0 {
-		return nil, false // nothing to actually send here for ID 0
-	}
-
+func computeRound4(state interface{}) (proto.Message, bool, error) {
 	s := getState(state)
+	if s.id == 0 {
+		return nil, false, nil // nothing to actually send here for ID 0
+	}
 
 	// if our ID is 1 we verifiably secret shuffle what we received from ID 0 last round
 	e := zkp.AlphasBetasToCipherTexts(s.theirGammasDeltas.Gammas, s.theirGammasDeltas.Deltas)
-	E, c, cd, cD, ER, f, fd, yd, zd, F, yD, zD, Z :=
-		zkp.RandomlyPermute(e, *zkp.P, *zkp.Q, *zkp.G, s.publicKey)
+	E, proof := zkp.RandomlyPermute(s.transcript(stepRound4, s.id), e, *s.group.P, *s.group.Q, *s.group.G, s.publicKey)
 	permutedGammas, permutedDeltas := zkp.CipherTextsToAlphasBetas(E)
 	s.myGammasDeltas.Gammas = permutedGammas
 	s.myGammasDeltas.Deltas = permutedDeltas
@@ -370,30 +444,33 @@ func computeRound4(state interface{}) (proto.Message, bool) {
 	return &MixedOutput{
 		Gammas: pb.BigIntSliceToByteSlice(permutedGammas),
 		Deltas: pb.BigIntSliceToByteSlice(permutedDeltas),
-		Proof:  pb.CreateVerifiableSecretShuffle(c, cd, cD, ER, f, fd, yd, zd, F, yD, zD, Z),
-	}, false
+		Proof:  pb.CreateVerifiableSecretShuffle(proof),
+	}, false, nil
 }
 
 func checkRound4(state interface{}, result *pb.OuterStruct) (err error) {
+	s := getState(state)
 	log.Printf("About to check for round %v", result.Stepid)
 	// if we are ID 1, we should not receive anything real in this round.
-	if *id == 1 {
+	if s.id == 1 {
 		return nil
 	}
 	// otherwise, we have received shuffled gammas/deltas
-	s := getState(state)
 	var in MixedOutput
 
 	err = proto.Unmarshal(result.Data, &in)
 	if err != nil {
-		log.Fatalf("Failed to unmarshal MixedOutput.\n")
+		return lib.NewError(lib.DecodeError, int(result.Clientid), "Failed to unmarshal MixedOutput: %v", err)
 	}
 
-	fmt.Println(len(in.Gammas))
-	fmt.Println(len(in.Deltas))
+	log.Println(len(in.Gammas))
+	log.Println(len(in.Deltas))
 
-	if len(in.Gammas) != len(in.Deltas) {
-		log.Fatalf("Incorrect number of gammas/deltas received from id %v", result.Clientid)
+	if uint(len(in.Gammas)) != s.bits || uint(len(in.Deltas)) != s.bits {
+		return lib.NewError(lib.DecodeError, int(result.Clientid), "Incorrect number of gammas/deltas")
+	}
+	if in.Proof == nil {
+		return lib.NewError(lib.DecodeError, int(result.Clientid), "Missing proof of the shuffle")
 	}
 
 	gammas := pb.ByteSliceToBigIntSlice(in.Gammas)
@@ -402,58 +479,61 @@ func checkRound4(state interface{}, result *pb.OuterStruct) (err error) {
 	e := zkp.AlphasBetasToCipherTexts(s.myGammasDeltas.Gammas, s.myGammasDeltas.Deltas)
 	E := zkp.AlphasBetasToCipherTexts(gammas, deltas)
 
-	log.Printf("Checking: %v, %v\n", e, E)
-
+	err = zkp.CheckVerifiableSecretShuffle(s.transcript(stepRound4, int(result.Clientid)), e, E,
+		*s.group.P, *s.group.Q, *s.group.G, s.publicKey, pb.DestructVerifiableSecretShuffle(in.Proof))
 	if err != nil {
-		log.Fatalf("Received incorrect zero knowledge proof for permuted output 2")
+		return lib.NewError(lib.ProofError, int(result.Clientid), "Received incorrect zero knowledge proof for permuted output 2: %v", err)
 	}
 
 	return err
 }
 
-func receiveRound4(state interface{}, results []*pb.OuterStruct) {
-	log.Printf("About to receive for round %v", results[1-*id].Stepid)
+func receiveRound4(state interface{}, results []*pb.OuterStruct) error {
+	s := getState(state)
+	log.Printf("About to receive for round %v", results[1-s.id].Stepid)
 	// if we are ID 1, we should not receive anything real in this round.
-	if *id == 1 {
-		return
+	if s.id == 1 {
+		return nil
 	}
-
-	s := getState(state)
 	var mixedOutput MixedOutput
 
 	// Wait for alphas and betas of other client
 	for i := 0; i < len(results); i++ {
-		if i == *id {
+		if i == s.id {
 			continue
 		}
 		err := proto.Unmarshal(results[i].Data, &mixedOutput)
 		if err != nil {
-			log.Fatalf("Failed to unmarshal MixedOutput.\n")
+			return lib.NewError(lib.DecodeError, i, "Failed to unmarshal MixedOutput: %v", err)
 		}
 		s.theirGammasDeltas.Gammas = pb.ByteSliceToBigIntSlice(mixedOutput.Gammas)
 		s.theirGammasDeltas.Deltas = pb.ByteSliceToBigIntSlice(mixedOutput.Deltas)
 		s.myGammasDeltas.Gammas = s.theirGammasDeltas.Gammas
 		s.myGammasDeltas.Deltas = s.theirGammasDeltas.Deltas
 	}
+
+	return nil
 }
 
 // ROUND 5 FUNCTIONS
 
-func computeRound5(state interface{}) (proto.Message, bool) {
+func computeRound5(state interface{}) (proto.Message, bool, error) {
 	s := getState(state)
 	var proofs []*pb.DiscreteLogEquality
 
 	s.myExponentiatedGammasDeltas = &GammaDeltaStruct{}
 
+	tr := s.transcript(stepRound5, s.id)
+
 	// compute exponentiated gamma and delta
-	for j := 0; j < int(zkp.K_Mill); j++ {
+	for j := 0; j < int(s.bits); j++ {
 		// this is our random exponent
 		var m big.Int
-		m.Rand(zkp.RandGen, zkp.Q)
+		m.Set(zkp.RandomExponent(s.group.Q))
 
 		var newGamma, newDelta big.Int
-		newGamma.Exp(&s.myGammasDeltas.Gammas[j], &m, zkp.P)
-		newDelta.Exp(&s.myGammasDeltas.Deltas[j], &m, zkp.P)
+		newGamma.Exp(&s.myGammasDeltas.Gammas[j], &m, s.group.P)
+		newDelta.Exp(&s.myGammasDeltas.Deltas[j], &m, s.group.P)
 
 		log.Printf("Computed m_%v = %v, gamma_%v = %v, delta_%v = %v\n", j, m.String(), j, newGamma.String(), j, newDelta.String())
 
@@ -468,13 +548,13 @@ func computeRound5(state interface{}) (proto.Message, bool) {
 		// log.Println("Beginning random exponentiation4")
 
 		// create proof and add it to proof list
-		ts, r := zkp.DiscreteLogEquality(m, gs, *zkp.P, *zkp.Q)
+		ts, r := zkp.DiscreteLogEquality(tr.Fork("gammadelta", j), m, gs, *s.group.P, *s.group.Q)
 
 		//checking the proof here before we send it
 		var results, results2 []big.Int
 		var a, b big.Int
-		a.Exp(&gs[0], &m, zkp.P)
-		b.Exp(&gs[1], &m, zkp.P)
+		a.Exp(&gs[0], &m, s.group.P)
+		b.Exp(&gs[1], &m, s.group.P)
 		results = append(results, a)
 		results = append(results, b)
 		results2 = append(results2, s.myExponentiatedGammasDeltas.Gammas[j])
@@ -489,7 +569,7 @@ func computeRound5(state interface{}) (proto.Message, bool) {
 		Gammas: pb.BigIntSliceToByteSlice(s.myExponentiatedGammasDeltas.Gammas),
 		Deltas: pb.BigIntSliceToByteSlice(s.myExponentiatedGammasDeltas.Deltas),
 		Proofs: proofs,
-	}, false
+	}, false, nil
 }
 
 func checkRound5(state interface{}, result *pb.OuterStruct) (err error) {
@@ -498,16 +578,18 @@ func checkRound5(state interface{}, result *pb.OuterStruct) (err error) {
 
 	err = proto.Unmarshal(result.Data, &in)
 	if err != nil {
-		log.Fatalf("Failed to unmarshal RandomizedOutput.\n")
+		return lib.NewError(lib.DecodeError, int(result.Clientid), "Failed to unmarshal RandomizedOutput: %v", err)
 	}
 
-	if len(in.Gammas) != len(in.Deltas) || len(in.Proofs) != len(in.Deltas) || uint(len(in.Proofs)) != zkp.K_Mill {
-		log.Fatalf("Incorrect number of gamma/deltas in round 5")
+	if len(in.Gammas) != len(in.Deltas) || len(in.Proofs) != len(in.Deltas) || uint(len(in.Proofs)) != s.bits {
+		return lib.NewError(lib.DecodeError, int(result.Clientid), "Incorrect number of gamma/deltas in round 5")
 	}
 
 	gammas := pb.ByteSliceToBigIntSlice(in.Gammas)
 	deltas := pb.ByteSliceToBigIntSlice(in.Deltas)
 
+	tr := s.transcript(stepRound5, int(result.Clientid))
+
 	for j := 0; j < len(in.Gammas); j++ {
 		log.Printf("RECEIVED gamma_%v = %v, delta_%v = %v\n", j, gammas[j].String(), j, deltas[j].String())
 
@@ -515,17 +597,20 @@ func checkRound5(state interface{}, result *pb.OuterStruct) (err error) {
 		results := []big.Int{gammas[j], deltas[j]}
 
 		// set proof values
+		if in.Proofs[j] == nil {
+			return lib.NewError(lib.DecodeError, int(result.Clientid), "Missing zero-knowledge proof")
+		}
 		ts, r := pb.DestructDiscreteLogEquality(in.Proofs[j])
 
-		if err := zkp.CheckDiscreteLogEqualityProof(bases, results, ts, r, *zkp.P, *zkp.Q); err != nil {
-			log.Fatalf("Received incorrect zero-knowledge proof for exponentiated gammas/deltas")
+		if err := zkp.CheckDiscreteLogEqualityProof(tr.Fork("gammadelta", j), bases, results, ts, r, *s.group.P, *s.group.Q); err != nil {
+			return lib.NewError(lib.ProofError, int(result.Clientid), "Received incorrect zero-knowledge proof for exponentiated gammas/deltas: %v", err)
 		}
 	}
 
 	return
 }
 
-func receiveRound5(state interface{}, results []*pb.OuterStruct) {
+func receiveRound5(state interface{}, results []*pb.OuterStruct) error {
 	s := getState(state)
 	var randomizedoutput RandomizedOutput
 
@@ -533,22 +618,32 @@ func receiveRound5(state interface{}, results []*pb.OuterStruct) {
 
 	// Wait for alphas and betas of other client
 	for i := 0; i < len(results); i++ {
-		if i == *id {
+		if i == s.id {
 			continue
 		}
 		err := proto.Unmarshal(results[i].Data, &randomizedoutput)
 		if err != nil {
-			log.Fatalf("Failed to unmarshal RandomizedOutput.\n")
+			return lib.NewError(lib.DecodeError, i, "Failed to unmarshal RandomizedOutput: %v", err)
 		}
 
 		s.theirExponentiatedGammasDelta.Gammas = pb.ByteSliceToBigIntSlice(randomizedoutput.Gammas)
 		s.theirExponentiatedGammasDelta.Deltas = pb.ByteSliceToBigIntSlice(randomizedoutput.Deltas)
 	}
+
+	return nil
 }
 
 // ROUND 6 FUNCTIONS
 
-func computeRound6(state interface{}) (proto.Message, bool) {
+// phi returns the product of the exponentiated deltas of bit i of both
+// parties, before both exponentiate it with their private keys.
+func (s *state) phi(i int) *big.Int {
+	var phi big.Int
+	phi.Mul(&s.myExponentiatedGammasDeltas.Deltas[i], &s.theirExponentiatedGammasDelta.Deltas[i])
+	return phi.Mod(&phi, s.group.P)
+}
+
+func computeRound6(state interface{}) (proto.Message, bool, error) {
 	s := getState(state)
 	log.Println("Beginning decryption")
 
@@ -557,19 +652,20 @@ func computeRound6(state interface{}) (proto.Message, bool) {
 	s.myPhis = new(PhiStruct)
 	s.phisBeforeExponentiation = new(PhiStruct)
 
+	tr := s.transcript(stepRound6, s.id)
+
 	// compute exponentiated gamma and delta
-	for i := 0; i < int(zkp.K_Mill); i++ {
+	for i := 0; i < int(s.bits); i++ {
 		// calculate phi
 		var phi, phi2 big.Int
-		phi.Mul(&s.myExponentiatedGammasDeltas.Deltas[i], &s.theirExponentiatedGammasDelta.Deltas[i])
-		phi.Mod(&phi, zkp.P)
+		phi.Set(s.phi(i))
 		// before exponentiating, add it to our list for checking the ZKP
 		phi2.Set(&phi)
 		s.phisBeforeExponentiation.Phis = append(s.phisBeforeExponentiation.Phis, phi2)
 
 		log.Printf("COMPUTED: Before exponentiation, phi_%v = %v\n", i, phi2.String())
 
-		phi.Exp(&phi, &s.myPrivateKey, zkp.P)
+		phi.Exp(&phi, &s.myPrivateKey, s.group.P)
 		s.myPhis.Phis = append(s.myPhis.Phis, phi)
 
 		log.Printf("COMPUTED: phi_%v = %v\n", i, phi.String())
@@ -577,10 +673,10 @@ func computeRound6(state interface{}) (proto.Message, bool) {
 		// to pass the bases to the zkp generator
 		var gs []big.Int
 		gs = append(gs, phi2)
-		gs = append(gs, *zkp.G)
+		gs = append(gs, *s.group.G)
 
 		// create proof and add it to proof list
-		ts, r := zkp.DiscreteLogEquality(s.myPrivateKey, gs, *zkp.P, *zkp.Q)
+		ts, r := zkp.DiscreteLogEquality(tr.Fork("phi", i), s.myPrivateKey, gs, *s.group.P, *s.group.Q)
 		// log.Printf("Creating proof.\nBases=%v\nExponent=%v\nTs=%vn,R=%v\n", gs, s.myPrivateKey, ts, r)
 
 		proofs = append(proofs, pb.CreateDiscreteLogEquality(ts, r))
@@ -589,7 +685,7 @@ func computeRound6(state interface{}) (proto.Message, bool) {
 	return &DecryptionInfo{
 		Phis:   pb.BigIntSliceToByteSlice(s.myPhis.Phis),
 		Proofs: proofs,
-	}, false
+	}, false, nil
 }
 
 func checkRound6(state interface{}, result *pb.OuterStruct) (err error) {
@@ -598,92 +694,119 @@ func checkRound6(state interface{}, result *pb.OuterStruct) (err error) {
 
 	err = proto.Unmarshal(result.Data, &in)
 	if err != nil {
-		log.Fatalf("Failed to unmarshal DecryptionInfo.\n")
+		return lib.NewError(lib.DecodeError, int(result.Clientid), "Failed to unmarshal DecryptionInfo: %v", err)
 	}
 
-	if len(in.Phis) != len(in.Proofs) || uint(len(in.Proofs)) != zkp.K_Mill {
-		log.Printf("len of phis=%v, len of proofs=%v, k=%v\n", len(in.Phis), uint(len(in.Proofs)), zkp.K_Mill)
-		log.Fatalf("Incorrect number of phis or proofs in round 6")
+	if len(in.Phis) != len(in.Proofs) || uint(len(in.Proofs)) != s.bits {
+		log.Printf("len of phis=%v, len of proofs=%v, k=%v\n", len(in.Phis), uint(len(in.Proofs)), s.bits)
+		return lib.NewError(lib.DecodeError, int(result.Clientid), "Incorrect number of phis or proofs in round 6")
 	}
 
 	phis := pb.ByteSliceToBigIntSlice(in.Phis)
 
+	tr := s.transcript(stepRound6, int(result.Clientid))
+
 	for j := 0; j < len(in.Phis); j++ {
 		log.Printf("RECEIVED: phi_%v = %v\n", j, phis[j].String())
 
 		var bases, results []big.Int
 		// proof equality of logarithms of the received phi and their public key
 		bases = append(bases, s.phisBeforeExponentiation.Phis[j])
-		bases = append(bases, *zkp.G)
+		bases = append(bases, *s.group.G)
 		results = append(results, phis[j])
 		results = append(results, s.keys[1]) // their public key!
 
 		// set proof values
+		if in.Proofs[j] == nil {
+			return lib.NewError(lib.DecodeError, int(result.Clientid), "Missing zero-knowledge proof")
+		}
 		ts, r := pb.DestructDiscreteLogEquality(in.Proofs[j])
 
 		// log.Printf("Checking proof.\nBases=%v\nResults=%v\nTs=%vn,R=%v\n", bases, results, ts, r)
-		if err := zkp.CheckDiscreteLogEqualityProof(bases, results, ts, r, *zkp.P, *zkp.Q); err != nil {
-			fmt.Println(err)
-			log.Fatalf("Received incorrect zero-knowledge proof for phis")
+		if err := zkp.CheckDiscreteLogEqualityProof(tr.Fork("phi", j), bases, results, ts, r, *s.group.P, *s.group.Q); err != nil {
+			return lib.NewError(lib.ProofError, int(result.Clientid), "Received incorrect zero-knowledge proof for phis: %v", err)
 		}
 	}
 
 	return
 }
 
-func receiveRound6(state interface{}, results []*pb.OuterStruct) {
+func receiveRound6(state interface{}, results []*pb.OuterStruct) error {
 	s := getState(state)
 	var decInfo DecryptionInfo
-	err := proto.Unmarshal(results[1-*id].Data, &decInfo) // just need their result
+	err := proto.Unmarshal(results[1-s.id].Data, &decInfo) // just need their result
 	if err != nil {
-		log.Fatalf("Failed to unmarshal DecryptionInfo.\n")
+		return lib.NewError(lib.DecodeError, 1-s.id, "Failed to unmarshal DecryptionInfo: %v", err)
 	}
 	log.Printf("%v\n", decInfo)
 
 	// Calculate the final output (division + which one is bigger)
 	phis := pb.ByteSliceToBigIntSlice(decInfo.Phis)
-	for j := 0; j < int(zkp.K_Mill); j++ {
+	for j := 0; j < int(s.bits); j++ {
 
 		v := MillionaireCalculateV(s.myExponentiatedGammasDeltas.Gammas[j],
 			s.theirExponentiatedGammasDelta.Gammas[j],
 			s.myPhis.Phis[j],
 			phis[j],
-			*zkp.P)
+			*s.group.P)
 
 		log.Printf("v_%v = %v\n", j, v)
 
 		if v.Cmp(zkp.One) == 0 {
-			log.Fatalf("ID 0 is the winner\n")
+			log.Printf("ID 0 is the winner\n")
+			s.winner = 0
+			return nil
 		}
 	}
-	log.Fatalf("ID 1 is the winner\n")
+	log.Printf("ID 1 is the winner\n")
+	s.winner = 1
+	return nil
 }
 
 func main() {
 	flag.Parse()
 
-	myState := &state{}
-
-	hosts, myID := lib.GetHostsAndID()
-	*id = myID
+	config := lib.GetAuctionConfig("millionaire")
 
-	lib.Init(*id)
-	myAddr := hosts[*id]
+	// The verifiable shuffle only exists for mod p groups, which are the
+	// only ones lib.ReadAuctionConfig accepts for the millionaire protocol
+	group := config.Group.(*zkp.GroupParams)
 
-	fmt.Println(myAddr)
-
-	go lib.RunServer(myAddr)
-
-	lib.InitClients(hosts, myAddr)
+	if flag.Arg(0) == "verify" {
+		result, err := lib.Verify(config, flag.Arg(1), verify)
+		if err != nil {
+			log.Fatalf("Verification failed: %v", err)
+		}
+		lib.PrintResult(result)
+		return
+	}
 
-	rounds := []lib.Round{
-		{computeRound1, checkRound1, receiveRound1},
-		{computeRound2, checkRound2, receiveRound2},
-		{computeRound3, checkRound3, receiveRound3},
-		{computeRound4, checkRound4, receiveRound4},
-		{computeRound5, checkRound5, receiveRound5},
-		{computeRound6, checkRound6, receiveRound6},
+	index, err := config.Prices.Index(*bid)
+	if err != nil {
+		log.Fatalf("Invalid bid: %v", err)
+	}
+
+	log.Println(config.Hosts[config.MyID])
+
+	// With only two parties, a cheater cannot be excluded, but RunAuction
+	// still keeps the evidence
+	session, myState, err := lib.RunAuction(context.Background(), config, lib.Protocol{
+		NewTransport: lib.NewGRPCTransport,
+		Start: func(config *lib.AuctionConfig, session *lib.Session) ([]lib.Round, interface{}, error) {
+			return millionaireRounds(config), &state{
+				id:        config.MyID,
+				bid:       uint(index),
+				bits:      config.Prices.Bits(),
+				group:     group,
+				auctionID: config.AuctionID,
+			}, nil
+		},
+	})
+	if err != nil {
+		log.Fatalf("Auction failed: %v", err)
 	}
 
-	lib.Register(rounds, myState)
+	result := session.Result()
+	result.Winners = []int{myState.(*state).winner}
+	lib.PrintResult(result)
 }
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+	"github.com/ashwinsr/auctions/lib"
+	"github.com/ashwinsr/auctions/zkp"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// The number of bits of a bid in the tests
+const testBits = 6
+
+// tamperFn changes the message a cheating party computed for its round.
+type tamperFn func(group *zkp.GroupParams, msg proto.Message)
+
+// runMillionaire runs the millionaire protocol between two parties over a
+// memory network, in which cheater, unless it is lib.NoClient, tampers with
+// its message of the given step. It returns the state and the error of
+// both parties.
+func runMillionaire(test *testing.T, bids [2]uint, cheater int, step int, tamper tamperFn) ([]*state, []error) {
+	return runRecordedMillionaire(test, bids, cheater, step, tamper, nil)
+}
+
+// runRecordedMillionaire is runMillionaire, in which both parties record
+// their transcripts to transcripts, unless it is nil.
+func runRecordedMillionaire(test *testing.T, bids [2]uint, cheater int, step int, tamper tamperFn, transcripts []io.Writer) ([]*state, []error) {
+	g, err := zkp.NamedGroup(zkp.ToyGroupName)
+	if err != nil {
+		test.Fatal(err)
+	}
+	group := g.(*zkp.GroupParams)
+
+	prices, err := lib.BitsPriceLadder(testBits)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	hosts := make([]string, 2)
+	network := lib.NewMemoryNetwork(2)
+	states := make([]*state, 2)
+	errs := make([]error, 2)
+
+	// Once a party fails, the other may wait forever for its messages
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		config := &lib.AuctionConfig{Hosts: hosts, MyID: i, Group: group, AuctionID: "test", Prices: prices}
+		transport := network.Transport(i)
+		if transcripts != nil {
+			transport = lib.RecordTranscript(config, transport, transcripts[i])
+		}
+		session := lib.NewSession(config, transport)
+		states[i] = &state{
+			id:        i,
+			bid:       bids[i],
+			bits:      prices.Bits(),
+			group:     group,
+			auctionID: config.AuctionID,
+		}
+
+		myRounds := millionaireRounds(config)
+		if i == cheater {
+			myRounds = tamperedRounds(group, myRounds, step, tamper)
+		}
+
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if errs[i] = session.Connect(ctx); errs[i] == nil {
+				errs[i] = session.Run(ctx, myRounds, states[i])
+			}
+			if errs[i] != nil {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return states, errs
+}
+
+// tamperedRounds returns rounds, with tamper applied to the message computed
+// in step.
+func tamperedRounds(group *zkp.GroupParams, rounds []lib.Round, step int, tamper tamperFn) []lib.Round {
+	tampered := append([]lib.Round(nil), rounds...)
+	compute := rounds[step-1].Compute
+	tampered[step-1].Compute = func(state interface{}) (proto.Message, bool, error) {
+		msg, sendToSeller, err := compute(state)
+		if err == nil {
+			tamper(group, msg)
+		}
+		return msg, sendToSeller, err
+	}
+	return tampered
+}
+
+// tamperInt returns the encoding of the number enc times the generator.
+func tamperInt(group *zkp.GroupParams, enc []byte) []byte {
+	var n big.Int
+	n.SetBytes(enc)
+	n.Mul(&n, group.G)
+	n.Mod(&n, group.P)
+	return n.Bytes()
+}
+
+// TestGammaDelta checks the arithmetic of MillionaireCalculateGammaDelta on
+// unblinded bids, whose alphas are Y to the power of their bits: gamma j is
+// 1 for exactly one j, the highest bit on which the bids differ, if the
+// first bid is higher, and for none otherwise.
+func TestGammaDelta(test *testing.T) {
+	g, err := zkp.NamedGroup(zkp.ToyGroupName)
+	if err != nil {
+		test.Fatal(err)
+	}
+	group := g.(*zkp.GroupParams)
+
+	bits := func(bid uint) []big.Int {
+		alphas := make([]big.Int, testBits)
+		for j := range alphas {
+			alphas[j].Exp(group.Y, big.NewInt(int64(bid>>uint(j)&1)), group.P)
+		}
+		return alphas
+	}
+
+	for a := uint(0); a < 1<<testBits; a++ {
+		for b := uint(0); b < 1<<testBits; b++ {
+			gds := MillionaireCalculateGammaDelta(bits(a), bits(b), bits(a), bits(b), *group.Y, *group.P)
+
+			var ones []int
+			for j := range gds.Gammas {
+				if gds.Gammas[j].Cmp(zkp.One) == 0 {
+					ones = append(ones, j)
+				}
+			}
+			if a > b && (len(ones) != 1 || a>>uint(ones[0]) != b>>uint(ones[0])+1) {
+				test.Errorf("Bids %v and %v: gamma is 1 at %v, want only at the highest differing bit", a, b, ones)
+			} else if a <= b && len(ones) != 0 {
+				test.Errorf("Bids %v and %v: gamma is 1 at %v, want nowhere", a, b, ones)
+			}
+		}
+	}
+}
+
+func TestMillionaire(test *testing.T) {
+	max := uint(1)<<testBits - 1
+
+	for _, tc := range []struct {
+		bids   [2]uint
+		winner int
+	}{
+		{[2]uint{3, 0}, 0},
+		{[2]uint{0, 3}, 1},
+		{[2]uint{1, 0}, 0},
+		{[2]uint{0, 1}, 1},
+		{[2]uint{32, 31}, 0},
+		{[2]uint{21, 42}, 1},
+		{[2]uint{42, 21}, 0},
+		{[2]uint{max, max - 1}, 0},
+		{[2]uint{max - 1, max}, 1},
+		{[2]uint{0, max}, 1},
+		{[2]uint{max, 0}, 0},
+		// Ties go to party 1
+		{[2]uint{0, 0}, 1},
+		{[2]uint{5, 5}, 1},
+		{[2]uint{max, max}, 1},
+	} {
+		states, errs := runMillionaire(test, tc.bids, lib.NoClient, 0, nil)
+
+		for i, s := range states {
+			if errs[i] != nil {
+				test.Errorf("Bids %v: party %v failed: %v", tc.bids, i, errs[i])
+				continue
+			}
+			if s.winner != tc.winner {
+				test.Errorf("Bids %v: party %v found winner %v, want %v", tc.bids, i, s.winner, tc.winner)
+			}
+		}
+	}
+}
+
+func TestMillionaireVerify(test *testing.T) {
+	g, err := zkp.NamedGroup(zkp.ToyGroupName)
+	if err != nil {
+		test.Fatal(err)
+	}
+	prices, err := lib.BitsPriceLadder(testBits)
+	if err != nil {
+		test.Fatal(err)
+	}
+	config := &lib.AuctionConfig{Hosts: make([]string, 2), Group: g, AuctionID: "test", Prices: prices}
+
+	dir := test.TempDir()
+	names := make([]string, 2)
+	transcripts := make([]io.Writer, 2)
+	for i := range transcripts {
+		names[i] = filepath.Join(dir, fmt.Sprintf("party%v.transcript", i))
+		f, err := os.Create(names[i])
+		if err != nil {
+			test.Fatal(err)
+		}
+		defer f.Close()
+		transcripts[i] = f
+	}
+
+	states, errs := runRecordedMillionaire(test, [2]uint{42, 21}, lib.NoClient, 0, nil, transcripts)
+
+	for i, s := range states {
+		if errs[i] != nil {
+			test.Fatalf("Party %v failed: %v", i, errs[i])
+		}
+
+		result, err := lib.Verify(config, names[i], verify)
+		if err != nil {
+			test.Errorf("Transcript of party %v does not verify: %v", i, err)
+			continue
+		}
+		if len(result.Winners) != 1 || result.Winners[0] != s.winner {
+			test.Errorf("Transcript of party %v gives winners %v, want %v", i, result.Winners, s.winner)
+		}
+	}
+}
+
+func TestMillionaireDetectsCheating(test *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		cheater int
+		step    int
+		tamper  tamperFn
+		kind    lib.ErrorKind
+	}{
+		{"key without its secret", 1, stepRound1, func(group *zkp.GroupParams, msg proto.Message) {
+			key := msg.(*pb.Key)
+			key.Key = tamperInt(group, key.Key)
+		}, lib.ProofError},
+		{"missing bits", 1, stepRound2, func(group *zkp.GroupParams, msg proto.Message) {
+			in := msg.(*AlphaBeta)
+			in.Alphas = in.Alphas[1:]
+		}, lib.DecodeError},
+		{"invalid bit", 1, stepRound2, func(group *zkp.GroupParams, msg proto.Message) {
+			in := msg.(*AlphaBeta)
+			in.Alphas[0] = tamperInt(group, in.Alphas[0])
+		}, lib.ProofError},
+		{"unshuffled output", 0, stepRound3, func(group *zkp.GroupParams, msg proto.Message) {
+			in := msg.(*MixedOutput)
+			in.Gammas[0] = tamperInt(group, in.Gammas[0])
+		}, lib.ProofError},
+		{"missing shuffle proof", 0, stepRound3, func(group *zkp.GroupParams, msg proto.Message) {
+			in := msg.(*MixedOutput)
+			in.Proof = nil
+		}, lib.DecodeError},
+		{"unreshuffled output", 1, stepRound4, func(group *zkp.GroupParams, msg proto.Message) {
+			in := msg.(*MixedOutput)
+			in.Deltas[0] = tamperInt(group, in.Deltas[0])
+		}, lib.ProofError},
+		{"wrong exponent", 1, stepRound5, func(group *zkp.GroupParams, msg proto.Message) {
+			in := msg.(*RandomizedOutput)
+			in.Gammas[0] = tamperInt(group, in.Gammas[0])
+		}, lib.ProofError},
+		{"wrong key", 1, stepRound6, func(group *zkp.GroupParams, msg proto.Message) {
+			in := msg.(*DecryptionInfo)
+			in.Phis[0] = tamperInt(group, in.Phis[0])
+		}, lib.ProofError},
+	} {
+		_, errs := runMillionaire(test, [2]uint{5, 9}, tc.cheater, tc.step, tc.tamper)
+
+		honest := 1 - tc.cheater
+		e, ok := errs[honest].(*lib.RoundError)
+		if !ok {
+			test.Errorf("%v: party %v failed with %v, want a *lib.RoundError", tc.name, honest, errs[honest])
+			continue
+		}
+		if e.Clientid != tc.cheater || e.Round != tc.step || e.Kind != tc.kind {
+			test.Errorf("%v: party %v failed with %v, want a %v blaming %v in round %v",
+				tc.name, honest, e, tc.kind, tc.cheater, tc.step)
+		}
+	}
+}
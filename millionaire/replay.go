@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+	"github.com/ashwinsr/auctions/lib"
+	"github.com/ashwinsr/auctions/zkp"
+	"github.com/golang/protobuf/proto"
+)
+
+// A transcript has the messages of both parties, but none of their secrets.
+// So to replay the protocol as a party, every round restores what the party
+// computed from its message in the transcript instead of computing it, and
+// then checks and receives the message of the other as in the protocol.
+
+// restoreFn restores the state s of its party from msg, its message of a
+// round.
+type restoreFn func(s *state, msg *pb.OuterStruct) error
+
+// replayRounds returns the rounds of party config.MyID that replay the
+// transcript in replay.
+func replayRounds(config *lib.AuctionConfig, replay *lib.Replay) []lib.Round {
+	rounds := millionaireRounds(config)
+
+	for step, restore := range map[int]restoreFn{
+		stepRound1: restoreRound1,
+		stepRound2: restoreRound2,
+		stepRound3: restoreRound3,
+		stepRound4: restoreRound4,
+		stepRound5: restoreRound5,
+		stepRound6: restoreRound6,
+	} {
+		step, restore := step, restore
+		rounds[step-1].Compute = func(state interface{}) (proto.Message, bool, error) {
+			s := getState(state)
+			return nil, false, restore(s, replay.Message(step, s.id))
+		}
+	}
+
+	return rounds
+}
+
+// verify replays the transcript in replay as party config.MyID, for
+// lib.Verify.
+func verify(config *lib.AuctionConfig, replay *lib.Replay) (*lib.Result, error) {
+	group, ok := config.Group.(*zkp.GroupParams)
+	if !ok {
+		return nil, fmt.Errorf("the millionaire protocol needs a mod p group, not %v", config.Group.Name())
+	}
+	s := &state{
+		id:        config.MyID,
+		bits:      config.Prices.Bits(),
+		group:     group,
+		auctionID: config.AuctionID,
+	}
+
+	result, err := replay.Run(config, replayRounds(config, replay), s)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Winners = []int{s.winner}
+	return result, nil
+}
+
+func restoreRound1(s *state, msg *pb.OuterStruct) error {
+	var key pb.Key
+	if err := proto.Unmarshal(msg.Data, &key); err != nil {
+		return lib.NewError(lib.DecodeError, s.id, "Failed to unmarshal pb.Key: %v", err)
+	}
+
+	s.myPublicKey.SetBytes(key.Key)
+	return nil
+}
+
+func restoreRound2(s *state, msg *pb.OuterStruct) error {
+	var in AlphaBeta
+	if err := proto.Unmarshal(msg.Data, &in); err != nil {
+		return lib.NewError(lib.DecodeError, s.id, "Failed to unmarshal AlphaBeta: %v", err)
+	}
+	if uint(len(in.Alphas)) != s.bits || uint(len(in.Betas)) != s.bits {
+		return lib.NewError(lib.DecodeError, s.id, "Incorrect number of alpha/betas in round 2")
+	}
+
+	s.myAlphasBetas = &AlphaBetaStruct{
+		alphas: pb.ByteSliceToBigIntSlice(in.Alphas),
+		betas:  pb.ByteSliceToBigIntSlice(in.Betas),
+	}
+	return nil
+}
+
+func restoreRound3(s *state, msg *pb.OuterStruct) error {
+	s.computeGammasDeltas()
+	if s.id != 0 {
+		return nil
+	}
+
+	// ID 0 shuffled the gammas and deltas
+	return restoreMixedOutput(s, msg)
+}
+
+func restoreRound4(s *state, msg *pb.OuterStruct) error {
+	if s.id != 1 {
+		return nil
+	}
+
+	// ID 1 shuffled what it received from ID 0
+	return restoreMixedOutput(s, msg)
+}
+
+// restoreMixedOutput restores the gammas and deltas shuffled in msg, which
+// are both ours and theirs.
+func restoreMixedOutput(s *state, msg *pb.OuterStruct) error {
+	var in MixedOutput
+	if err := proto.Unmarshal(msg.Data, &in); err != nil {
+		return lib.NewError(lib.DecodeError, s.id, "Failed to unmarshal MixedOutput: %v", err)
+	}
+	if uint(len(in.Gammas)) != s.bits || uint(len(in.Deltas)) != s.bits {
+		return lib.NewError(lib.DecodeError, s.id, "Incorrect number of gammas/deltas")
+	}
+
+	s.myGammasDeltas.Gammas = pb.ByteSliceToBigIntSlice(in.Gammas)
+	s.myGammasDeltas.Deltas = pb.ByteSliceToBigIntSlice(in.Deltas)
+	s.theirGammasDeltas.Gammas = s.myGammasDeltas.Gammas
+	s.theirGammasDeltas.Deltas = s.myGammasDeltas.Deltas
+	return nil
+}
+
+func restoreRound5(s *state, msg *pb.OuterStruct) error {
+	var in RandomizedOutput
+	if err := proto.Unmarshal(msg.Data, &in); err != nil {
+		return lib.NewError(lib.DecodeError, s.id, "Failed to unmarshal RandomizedOutput: %v", err)
+	}
+	if uint(len(in.Gammas)) != s.bits || uint(len(in.Deltas)) != s.bits {
+		return lib.NewError(lib.DecodeError, s.id, "Incorrect number of gamma/deltas in round 5")
+	}
+
+	s.myExponentiatedGammasDeltas = &GammaDeltaStruct{
+		Gammas: pb.ByteSliceToBigIntSlice(in.Gammas),
+		Deltas: pb.ByteSliceToBigIntSlice(in.Deltas),
+	}
+	return nil
+}
+
+func restoreRound6(s *state, msg *pb.OuterStruct) error {
+	var in DecryptionInfo
+	if err := proto.Unmarshal(msg.Data, &in); err != nil {
+		return lib.NewError(lib.DecodeError, s.id, "Failed to unmarshal DecryptionInfo: %v", err)
+	}
+	if uint(len(in.Phis)) != s.bits {
+		return lib.NewError(lib.DecodeError, s.id, "Incorrect number of phis in round 6")
+	}
+
+	s.myPhis = &PhiStruct{Phis: pb.ByteSliceToBigIntSlice(in.Phis)}
+	s.phisBeforeExponentiation = new(PhiStruct)
+	for i := 0; i < int(s.bits); i++ {
+		s.phisBeforeExponentiation.Phis = append(s.phisBeforeExponentiation.Phis, *s.phi(i))
+	}
+	return nil
+}
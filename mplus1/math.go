@@ -0,0 +1,55 @@
+package mplus1
+
+import (
+	"math/big"
+
+	"github.com/ashwinsr/auctions/zkp"
+)
+
+type GetElementFunc func(i int) zkp.Element
+
+// multiplies getter(start) * getter(start + 1) * ... * getter(end - 1)
+func Multiply(group zkp.Group, start, end int, getter GetElementFunc) zkp.Element {
+	result := group.Identity()
+	for i := start; i < end; i++ {
+		result = group.Mul(result, getter(i))
+	}
+	return result
+}
+
+type GetNumFunc func(x, y int) zkp.Element
+
+// returns PI_{h} PI_{d=j}^{k-1} getNum(h, d) * (PI_{h} PI_{d=j+1}^{k-1} getNum(h, d))^2,
+// which has Y to the number of bids >= j plus the number of bids > j
+func Round2ComputeInitialValue(group zkp.Group, n, k, j int, getNum GetNumFunc) zkp.Element {
+	above := Multiply(group, 0, n, func(h int) zkp.Element {
+		return Multiply(group, j+1, k, func(d int) zkp.Element {
+			return getNum(h, d)
+		})
+	})
+
+	atJ := Multiply(group, 0, n, func(h int) zkp.Element {
+		return getNum(h, j)
+	})
+
+	return group.Mul(atJ, group.Mul(above, above))
+}
+
+// Ensre that you use the right indices
+// returns cachedVal * (PI_{d=0}^{j} getNum(i, d))^(2M+2)
+//
+// Divided by Y^(2M+1), this has Y to
+//
+//	(#bids >= j) + (#bids > j) - (2M+1) + (2M+2)*[bid_i <= j]
+//
+// As all bids are different, that is 0 exactly when bidder i bid more
+// than j, and j is the (M+1)st highest bid: (#bids >= j) = M+1 and
+// (#bids > j) = M.
+func Round2ComputeOutcome(group zkp.Group, i, j, m int, cachedVal zkp.Element, getNum GetNumFunc) zkp.Element {
+	// upper limit is j + 1 and this multiply function is NON-INCLUSIVE
+	atMostJ := Multiply(group, 0, j+1, func(d int) zkp.Element {
+		return getNum(i, d)
+	})
+
+	return group.Mul(cachedVal, group.Exp(atMostJ, big.NewInt(int64(2*m+2))))
+}
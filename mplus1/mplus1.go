@@ -0,0 +1,867 @@
+/*
+ * Package mplus1 implements the (M+1)st price auction among multiple
+ * bidders for M identical units: the M highest bidders win one unit each,
+ * and all pay the (M+1)st highest bid. For M = 1 this is the second price
+ * (Vickrey) auction. The description of the protocol itself can be found
+ * in:
+ *
+ * Brandt, Felix. "How to obtain full privacy in auctions."
+ * International Journal of Information Security 5.4 (2006): 201-216.
+ *
+ * The protocol needs all bids to be different, so bidder i bidding the
+ * price with index b is given the price index b*n + (n-1-i) among K*n
+ * price indices, where K is the number of prices: ties go to the lowest id.
+ * The parties only report the price of the index at which the outcomes of
+ * the winners decrypt to the identity, not the index, whose offset would
+ * name the bidder of the (M+1)st highest bid. The outcomes are public,
+ * though, so anyone with the transcript can still find that bidder: hiding
+ * it too would take a verifiable shuffle of the price indices of each price
+ * before decryption, which the protocol does not have.
+ *
+ * The second_price and multi_unit commands run it.
+ */
+
+package mplus1
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+	"github.com/ashwinsr/auctions/lib"
+	"github.com/ashwinsr/auctions/zkp"
+	"github.com/golang/protobuf/proto"
+)
+
+// The label of the protocol in the transcripts of its proofs
+const protocolLabel = "auctions/mplus1"
+
+// The version of the protocol, which changes whenever its messages do
+const protocolVersion = 1
+
+// The step ids of the rounds, as numbered by lib.Session.Run
+const (
+	stepHandshake = iota + 1
+	stepPrologue
+	stepRound1
+	stepRound2
+	stepRound3
+)
+
+type AlphaBetaStruct struct {
+	alphas, betas []zkp.Element
+}
+
+type GammaDeltaStruct struct {
+	gammas, deltas []zkp.Element
+}
+
+// State is the state of one party in an auction.
+type State struct {
+	session *lib.Session
+	id      int
+	bid     uint // the index of the bid in prices
+	units   int  // M
+
+	prices    *lib.PriceLadder
+	group     zkp.Group
+	auctionID string
+	hostID    func(i int) int // the id of party i in the hosts file
+
+	myPrivateKey *big.Int
+	myPublicKey  zkp.Element
+	keys         []zkp.Element
+	publicKey    zkp.Element
+	currRound    int
+
+	AlphasBetas []*AlphaBetaStruct
+
+	GammasDeltasBeforeExponentiation []*GammaDeltaStruct   // indices (i, j)
+	GammasDeltasAfterExponentiation  [][]*GammaDeltaStruct // indices (a, i, j)
+
+	PhisBeforeExponentiation [][]zkp.Element   // indices (i, j)
+	PhisAfterExponentiation  [][][]zkp.Element // indices (a, i, j)
+
+	winners []int // the M highest bidders, by their ids in this run
+	price   uint  // the index of the (M+1)st highest bid in prices
+
+	sellerRound3 Round3
+}
+
+// NewState returns the state of a party bidding bid, one of the prices of
+// config, in session, in an auction of the given number of units.
+func NewState(session *lib.Session, config *lib.AuctionConfig, bid string, units int) (*State, error) {
+	if units < 1 || units >= len(config.Hosts) {
+		return nil, fmt.Errorf("cannot sell %v units to %v parties", units, len(config.Hosts))
+	}
+	index, err := config.Prices.Index(bid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &State{
+		session:   session,
+		id:        config.MyID,
+		bid:       uint(index),
+		units:     units,
+		prices:    config.Prices,
+		group:     config.Group,
+		auctionID: config.AuctionID,
+		hostID:    config.HostID,
+	}, nil
+}
+
+// Winners returns the ids of the M highest bidders in the hosts file, once
+// the auction is over.
+func (s *State) Winners() []int {
+	winners := make([]int, len(s.winners))
+	for i, a := range s.winners {
+		winners[i] = s.hostID(a)
+	}
+	return winners
+}
+
+// Price returns the price every winner pays, once the auction is over.
+func (s *State) Price() string {
+	return s.prices.Price(int(s.price))
+}
+
+// Result returns the outcome of the auction, once it is over.
+func (s *State) Result() *lib.Result {
+	r := s.session.Result()
+	r.Winners = s.Winners()
+	r.Price = s.Price()
+	return r
+}
+
+// Rounds returns the rounds of the party config.MyID. They start with
+// the handshake, checking that all parties agree on the auction.
+func Rounds(config *lib.AuctionConfig) []lib.Round {
+	handshake := lib.HandshakeRound(config, protocolVersion, lib.Parameter{Name: "proof label", Value: protocolLabel})
+
+	if config.MyID == 0 {
+		// If seller
+		return []lib.Round{
+			handshake,
+			{computePrologue, checkPrologue, receivePrologue},
+			{computeRound1, checkRound1, receiveRound1},
+			{computeRound2, checkRound2, receiveRound2},
+			{computeRound3, checkRound3, sellerReceiveRound3},
+		}
+	}
+
+	// If bidder
+	return []lib.Round{
+		handshake,
+		{computePrologue, checkPrologue, receivePrologue},
+		{computeRound1, checkRound1, receiveRound1},
+		{computeRound2, checkRound2, receiveRound2},
+		{computeRound3, checkRound3, receiveRound3},
+	}
+}
+
+func getState(state interface{}) (s *State) {
+	s, ok := state.(*State)
+	if !ok {
+		log.Fatalf("Failed to typecast State.\n")
+	}
+	return
+}
+
+// transcript returns the transcript of the proofs sent by client in step.
+func (s *State) transcript(step int, client int) *zkp.Transcript {
+	protocol := fmt.Sprintf("%v/%v", protocolLabel, s.units)
+	return zkp.NewRoundTranscript(protocol, s.auctionID, s.group, step, client)
+}
+
+// numPrices returns the number of price indices, one per price for every
+// bidder.
+func (s *State) numPrices() int {
+	return s.prices.Len() * len(s.keys)
+}
+
+// priceIndex returns the price index of the bid of the given bidder.
+func (s *State) priceIndex(bid uint, id int) int {
+	n := len(s.keys)
+	return int(bid)*n + (n - 1 - id)
+}
+
+func checkPrologue(state interface{}, result *pb.OuterStruct) (err error) {
+	s := getState(state)
+	client := int(result.Clientid)
+	var key pb.Key
+
+	err = proto.Unmarshal(result.Data, &key)
+	if err != nil {
+		return lib.NewError(lib.DecodeError, client, "Failed to unmarshal pb.Key: %v", err)
+	}
+
+	k, err := zkp.DecodeKey(s.group, key.Key)
+	if err != nil {
+		return lib.NewError(lib.DecodeError, client, "Received invalid key: %v", err)
+	}
+
+	t, r, err := pb.DestructGroupDiscreteLogKnowledge(s.group, key.Proof)
+	if err != nil {
+		return lib.NewError(lib.DecodeError, client, "Received invalid zero-knowledge proof of key: %v", err)
+	}
+
+	err = zkp.CheckGroupDiscreteLogKnowledgeProof(s.transcript(stepPrologue, client), s.group, s.group.Generator(), k, t, r)
+	if err != nil {
+		return lib.NewError(lib.ProofError, client, "Received incorrect zero-knowledge proof of key %x: %v", key.Key, err)
+	}
+
+	return
+}
+
+func checkRound1(state interface{}, result *pb.OuterStruct) (err error) {
+	s := getState(state)
+	client := int(result.Clientid)
+	var in Round1
+
+	err = proto.Unmarshal(result.Data, &in)
+	if err != nil {
+		return lib.NewError(lib.DecodeError, client, "Failed to unmarshal Round1: %v", err)
+	}
+
+	if len(in.Alphas) != len(in.Betas) || len(in.Proofs) != len(in.Betas) || len(in.Proofs) != s.numPrices() {
+		return lib.NewError(lib.DecodeError, client, "Incorrect number of alpha/betas in round 1")
+	}
+
+	alphas, err := zkp.DecodeElements(s.group, in.Alphas)
+	if err != nil {
+		return lib.NewError(lib.DecodeError, client, "Received invalid alphas: %v", err)
+	}
+	betas, err := zkp.DecodeElements(s.group, in.Betas)
+	if err != nil {
+		return lib.NewError(lib.DecodeError, client, "Received invalid betas: %v", err)
+	}
+
+	tr := s.transcript(stepRound1, client)
+
+	for i := 0; i < len(in.Alphas); i++ {
+		a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2, err := pb.DestructGroupIsOneOfTwo(s.group, in.Proofs[i])
+		if err != nil {
+			return lib.NewError(lib.DecodeError, client, "Received invalid zero-knowledge proof for alpha/beta: %v", err)
+		}
+
+		if err := zkp.CheckGroupEncryptedValueIsOneOfTwo(tr.Fork("bid", i), s.group, alphas[i], betas[i],
+			a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2,
+			s.group.Generator(), s.publicKey, s.group.SecondGenerator()); err != nil {
+			return lib.NewError(lib.ProofError, client, "Received incorrect zero-knowledge proof for alpha/beta: %v", err)
+		}
+	}
+
+	// This checks if the bidder bid exactly one value:
+	// Only one of the alphas should have Y as a factor, and therefore
+	// dividing their product by Y gives us y^(sum of the r's).
+	// Then multiplying all of the betas together gives us g^(sum of the r's).
+	// Therefore we check that these two have the same exponent!
+
+	yExpSumR := Multiply(s.group, 0, len(alphas), func(i int) zkp.Element { return alphas[i] })
+	gExpSumR := Multiply(s.group, 0, len(betas), func(i int) zkp.Element { return betas[i] })
+
+	// divide by Y
+	yExpSumR = zkp.Divide(s.group, yExpSumR, s.group.SecondGenerator())
+
+	bases := []zkp.Element{s.publicKey, s.group.Generator()}
+	results := []zkp.Element{yExpSumR, gExpSumR}
+
+	ts, r, err := pb.DestructGroupDiscreteLogEquality(s.group, in.Proof)
+	if err != nil {
+		return lib.NewError(lib.DecodeError, client, "Received invalid zero-knowledge proof for alphas/betas: %v", err)
+	}
+
+	if err := zkp.CheckGroupDiscreteLogEqualityProof(tr.Fork("sum"), s.group, bases, results, ts, r); err != nil {
+		return lib.NewError(lib.ProofError, client, "Received incorrect zero-knowledge proof for alphas/betas: bidder bid multiple values? %v", err)
+	}
+
+	return
+}
+
+func checkRound2(state interface{}, result *pb.OuterStruct) (err error) {
+	s := getState(state)
+	client := int(result.Clientid)
+	var in Round2
+
+	err = proto.Unmarshal(result.Data, &in)
+	if err != nil {
+		return lib.NewError(lib.DecodeError, client, "Failed to unmarshal Round2: %v", err)
+	}
+
+	if len(in.DoubleGammas) != len(in.DoubleDeltas) ||
+		len(in.DoubleDeltas) != len(in.DoubleProofs) ||
+		len(in.DoubleGammas) != len(s.keys) {
+		return lib.NewError(lib.DecodeError, client, "Incorrect number of double gamma/deltas")
+	}
+
+	tr := s.transcript(stepRound2, client)
+
+	for i := 0; i < len(in.DoubleGammas); i++ {
+		if in.DoubleGammas[i] == nil || in.DoubleDeltas[i] == nil || in.DoubleProofs[i] == nil {
+			return lib.NewError(lib.DecodeError, client, "Missing gammas, deltas or proofs in round 2")
+		}
+
+		if len(in.DoubleGammas[i].Gammas) != len(in.DoubleDeltas[i].Deltas) ||
+			len(in.DoubleDeltas[i].Deltas) != len(in.DoubleProofs[i].Proofs) ||
+			len(in.DoubleGammas[i].Gammas) != s.numPrices() {
+			return lib.NewError(lib.DecodeError, client, "Incorrect number of proofs in round 2 %v %v %v",
+				len(in.DoubleGammas[i].Gammas),
+				len(in.DoubleDeltas[i].Deltas),
+				len(in.DoubleProofs[i].Proofs))
+		}
+
+		gammas, err := zkp.DecodeElements(s.group, in.DoubleGammas[i].Gammas)
+		if err != nil {
+			return lib.NewError(lib.DecodeError, client, "Received invalid gammas: %v", err)
+		}
+		deltas, err := zkp.DecodeElements(s.group, in.DoubleDeltas[i].Deltas)
+		if err != nil {
+			return lib.NewError(lib.DecodeError, client, "Received invalid deltas: %v", err)
+		}
+
+		for j := 0; j < len(in.DoubleGammas[i].Gammas); j++ {
+			ts, r, err := pb.DestructGroupDiscreteLogEquality(s.group, in.DoubleProofs[i].Proofs[j])
+			if err != nil {
+				return lib.NewError(lib.DecodeError, client, "Received invalid zero-knowledge proof for gamma/delta: %v", err)
+			}
+
+			// bases are their gammas and deltas before exponentiation!
+			bases := []zkp.Element{
+				s.GammasDeltasBeforeExponentiation[i].gammas[j],
+				s.GammasDeltasBeforeExponentiation[i].deltas[j],
+			}
+			results := []zkp.Element{gammas[j], deltas[j]}
+
+			if err := zkp.CheckGroupDiscreteLogEqualityProof(tr.Fork("gammadelta", i, j), s.group, bases, results, ts, r); err != nil {
+				return lib.NewError(lib.ProofError, client, "Received incorrect zero-knowledge proof for gamma/delta: %v", err)
+			}
+		}
+	}
+
+	return
+}
+
+func checkRound3(state interface{}, result *pb.OuterStruct) (err error) {
+	s := getState(state)
+	client := int(result.Clientid)
+	var in Round3
+
+	err = proto.Unmarshal(result.Data, &in)
+	if err != nil {
+		return lib.NewError(lib.DecodeError, client, "Failed to unmarshal Round3: %v", err)
+	}
+
+	if len(in.DoublePhis) != len(in.DoubleProofs) ||
+		len(in.DoubleProofs) != len(s.keys) {
+		return lib.NewError(lib.DecodeError, client, "Incorrect number of double phis in round 3")
+	}
+
+	tr := s.transcript(stepRound3, client)
+
+	for i := 0; i < len(in.DoublePhis); i++ {
+		if in.DoublePhis[i] == nil || in.DoubleProofs[i] == nil {
+			return lib.NewError(lib.DecodeError, client, "Missing phis or proofs in round 3")
+		}
+
+		if len(in.DoublePhis[i].Phis) != len(in.DoubleProofs[i].Proofs) ||
+			len(in.DoubleProofs[i].Proofs) != s.numPrices() {
+			return lib.NewError(lib.DecodeError, client, "Incorrect number of proofs in round 3 %v %v",
+				len(in.DoublePhis[i].Phis),
+				len(in.DoubleProofs[i].Proofs))
+		}
+
+		phis, err := zkp.DecodeElements(s.group, in.DoublePhis[i].Phis)
+		if err != nil {
+			return lib.NewError(lib.DecodeError, client, "Received invalid phis: %v", err)
+		}
+
+		for j := 0; j < len(in.DoublePhis[i].Phis); j++ {
+			ts, r, err := pb.DestructGroupDiscreteLogEquality(s.group, in.DoubleProofs[i].Proofs[j])
+			if err != nil {
+				return lib.NewError(lib.DecodeError, client, "Received invalid zero-knowledge proof for phis: %v", err)
+			}
+
+			// bases are the phis before exponentiation and the generator
+			bases := []zkp.Element{
+				s.PhisBeforeExponentiation[i][j],
+				s.group.Generator(),
+			}
+			results := []zkp.Element{phis[j], s.keys[result.Clientid]}
+
+			if err := zkp.CheckGroupDiscreteLogEqualityProof(tr.Fork("phi", i, j), s.group, bases, results, ts, r); err != nil {
+				return lib.NewError(lib.ProofError, client, "Received incorrect zero-knowledge proof for phis: %v", err)
+			}
+		}
+	}
+
+	return
+}
+
+func receivePrologue(State interface{}, results []*pb.OuterStruct) error {
+	s := getState(State)
+	var key pb.Key
+
+	s.keys = make([]zkp.Element, len(results))
+
+	s.keys[s.id] = s.myPublicKey
+
+	for i := 0; i < len(results); i++ {
+		if i == s.id {
+			continue
+		}
+		err := proto.Unmarshal(results[i].Data, &key)
+		if err != nil {
+			return lib.NewError(lib.DecodeError, i, "Failed to unmarshal pb.Key: %v", err)
+		}
+		s.keys[i], err = zkp.DecodeKey(s.group, key.Key)
+		if err != nil {
+			return lib.NewError(lib.DecodeError, i, "Failed to decode key: %v", err)
+		}
+	}
+
+	// Calculating final public key by multiplying them all together
+	s.publicKey = Multiply(s.group, 0, len(s.keys), func(i int) zkp.Element { return s.keys[i] })
+
+	log.Printf("Calculated public key: %x\n", s.group.Encode(s.publicKey))
+
+	return nil
+}
+
+func receiveRound1(State interface{}, results []*pb.OuterStruct) error {
+	s := getState(State)
+
+	var round1 Round1
+
+	// Store all received alphas and betas
+	for i := 0; i < len(results); i++ {
+		if i == s.id {
+			continue
+		}
+		err := proto.Unmarshal(results[i].Data, &round1)
+		if err != nil {
+			return lib.NewError(lib.DecodeError, i, "Failed to unmarshal Round1: %v", err)
+		}
+
+		s.AlphasBetas[i] = new(AlphaBetaStruct)
+		if s.AlphasBetas[i].alphas, err = decodeElements(s.group, i, round1.Alphas); err != nil {
+			return err
+		}
+		if s.AlphasBetas[i].betas, err = decodeElements(s.group, i, round1.Betas); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func receiveRound2(State interface{}, results []*pb.OuterStruct) error {
+	s := getState(State)
+
+	var round2 Round2
+
+	// Store all received alphas and betas
+	for a := 0; a < len(results); a++ {
+		if a == s.id {
+			continue
+		}
+		err := proto.Unmarshal(results[a].Data, &round2)
+		if err != nil {
+			return lib.NewError(lib.DecodeError, a, "Failed to unmarshal Round2: %v", err)
+		}
+
+		s.GammasDeltasAfterExponentiation[a] = make([]*GammaDeltaStruct, len(s.keys))
+
+		for i := 0; i < len(s.keys); i++ { // len(s.keys) == len(results)
+			gd := new(GammaDeltaStruct)
+			if gd.gammas, err = decodeElements(s.group, a, round2.DoubleGammas[i].Gammas); err != nil {
+				return err
+			}
+			if gd.deltas, err = decodeElements(s.group, a, round2.DoubleDeltas[i].Deltas); err != nil {
+				return err
+			}
+			s.GammasDeltasAfterExponentiation[a][i] = gd
+		}
+
+		log.Printf("[Round 2] Receiving ID %v\n", a)
+	}
+
+	return nil
+}
+
+func receiveRound3(State interface{}, results []*pb.OuterStruct) error {
+	s := getState(State)
+
+	var round3 Round3
+
+	// Store all received alphas and betas
+	log.Printf("results round 3: %v", len(results))
+	for a := 0; a < len(results); a++ {
+		if a == s.id {
+			continue
+		}
+
+		log.Printf("Received Clientid %v", results[a].Clientid)
+
+		err := proto.Unmarshal(results[a].Data, &round3)
+		if err != nil {
+			return lib.NewError(lib.DecodeError, a, "Failed to unmarshal Round3: %v", err)
+		}
+
+		s.PhisAfterExponentiation[a] = make([][]zkp.Element, len(s.keys))
+
+		for i := 0; i < len(s.keys); i++ { // len(s.keys) == len(results)
+			if s.PhisAfterExponentiation[a][i], err = decodeElements(s.group, a, round3.DoublePhis[i].Phis); err != nil {
+				return err
+			}
+		}
+
+		log.Printf("[Round 3] Receiving ID %v\n", a)
+	}
+
+	return epilogue(s)
+}
+
+func sellerReceiveRound3(State interface{}, results []*pb.OuterStruct) error {
+	s := getState(State)
+
+	var round3 Round3
+
+	// Store all received alphas and betas
+	log.Printf("Results Size: %v", len(results))
+	for a := 0; a < len(results); a++ {
+		if a == s.id {
+			continue
+		}
+		err := proto.Unmarshal(results[a].Data, &round3)
+		if err != nil {
+			return lib.NewError(lib.DecodeError, a, "Seller failed to unmarshal Round3: %v", err)
+		}
+
+		s.PhisAfterExponentiation[a] = make([][]zkp.Element, len(s.keys))
+
+		for i := 0; i < len(s.keys); i++ { // len(s.keys) == len(results)
+			if s.PhisAfterExponentiation[a][i], err = decodeElements(s.group, a, round3.DoublePhis[i].Phis); err != nil {
+				return err
+			}
+		}
+
+		log.Printf("[Round 3] Receiving ID %v\n", a)
+		log.Printf("Publishing Clientid %v, Stepid %v", results[a].Clientid, results[a].Stepid)
+
+		if err := s.session.PublishAll(results[a]); err != nil {
+			return err
+		}
+	}
+
+	r, err := proto.Marshal(&s.sellerRound3)
+	if err != nil {
+		return err
+	}
+
+	out := &pb.OuterStruct{
+		Clientid: int32(s.id),
+		Stepid:   stepRound3,
+		Data:     r,
+	}
+
+	if err := s.session.PublishAll(out); err != nil {
+		return err
+	}
+
+	return epilogue(s)
+}
+
+// decodeElements decodes elements received from client.
+func decodeElements(group zkp.Group, client int, encs [][]byte) ([]zkp.Element, error) {
+	elems, err := zkp.DecodeElements(group, encs)
+	if err != nil {
+		return nil, lib.NewError(lib.DecodeError, client, "Failed to decode elements: %v", err)
+	}
+	return elems, nil
+}
+
+func computePrologue(State interface{}) (proto.Message, bool, error) {
+	s := getState(State)
+
+	// Generate private key in [1, q)
+	s.myPrivateKey = zkp.RandomExponent(s.group.Order())
+	// Calculate public key
+	s.myPublicKey = s.group.Exp(s.group.Generator(), s.myPrivateKey)
+
+	// Generate zkp of private key
+	t, r := zkp.GroupDiscreteLogKnowledge(s.transcript(stepPrologue, s.id), s.group, s.myPrivateKey, s.group.Generator())
+
+	return &pb.Key{
+		Key:   s.group.Encode(s.myPublicKey),
+		Proof: pb.CreateGroupDiscreteLogKnowledge(s.group, t, r),
+	}, false, nil
+}
+
+func computeRound1(State interface{}) (proto.Message, bool, error) {
+	s := getState(State)
+	s.AlphasBetas = make([]*AlphaBetaStruct, len(s.keys))
+	s.AlphasBetas[s.id] = new(AlphaBetaStruct)
+
+	log.Printf("Len: %v\n", len(s.keys))
+
+	var alphas, betas []zkp.Element
+	var proofs []*pb.EqualsOneOfTwo
+	var sumR big.Int
+	tr := s.transcript(stepRound1, s.id)
+
+	myIndex := s.priceIndex(s.bid, s.id)
+	for j := 0; j < s.numPrices(); j++ {
+		var m zkp.Element
+
+		rJ := zkp.RandomExponent(s.group.Order())
+		sumR.Add(&sumR, rJ)
+
+		if j == myIndex {
+			m = s.group.SecondGenerator()
+		} else {
+			m = s.group.Identity()
+		}
+
+		// (alpha_j, beta_j) = (m*y^r_j, g^r_j)
+		alphaJ, betaJ := zkp.GroupEncryptElGamal(s.group, m, rJ, s.publicKey)
+
+		alphas = append(alphas, alphaJ)
+		betas = append(betas, betaJ)
+
+		a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2 :=
+			zkp.GroupEncryptedValueIsOneOfTwo(tr.Fork("bid", j), s.group, m, s.publicKey, rJ,
+				s.group.Generator(), s.group.SecondGenerator())
+
+		proofs = append(proofs, pb.CreateGroupIsOneOfTwo(s.group, a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2))
+	}
+
+	log.Printf("Id: %v\n", s.id)
+	s.AlphasBetas[s.id].alphas = alphas
+	s.AlphasBetas[s.id].betas = betas
+
+	sumR.Mod(&sumR, s.group.Order())
+
+	gs := []zkp.Element{s.publicKey, s.group.Generator()}
+
+	ts, r := zkp.GroupDiscreteLogEquality(tr.Fork("sum"), s.group, &sumR, gs)
+
+	// create the proto Round1 structure
+	return &Round1{
+		Proofs: proofs,
+		Proof:  pb.CreateGroupDiscreteLogEquality(s.group, ts, r),
+		Alphas: zkp.EncodeElements(s.group, alphas),
+		Betas:  zkp.EncodeElements(s.group, betas),
+	}, false, nil
+}
+
+func computeRound2(State interface{}) (proto.Message, bool, error) {
+	s := getState(State)
+	n := len(s.keys)
+
+	proofs := make([]*DiscreteLogEqualityProofs, n)
+	gammas := make([]*Gammas, n)
+	deltas := make([]*Deltas, n)
+
+	s.GammasDeltasBeforeExponentiation = make([]*GammaDeltaStruct, n)
+	s.GammasDeltasAfterExponentiation = make([][]*GammaDeltaStruct, n)
+	s.GammasDeltasAfterExponentiation[s.id] = make([]*GammaDeltaStruct, n)
+
+	tr := s.transcript(stepRound2, s.id)
+
+	getNumAlphas := func(x, y int) zkp.Element {
+		return s.AlphasBetas[x].alphas[y]
+	}
+	getNumBetas := func(x, y int) zkp.Element {
+		return s.AlphasBetas[x].betas[y]
+	}
+
+	// gamma_ij decrypts to Y^0 only if i is a winner and j is the price
+	// index of the (M+1)st highest bid, see Round2ComputeOutcome
+	yExp := s.group.Exp(s.group.SecondGenerator(), big.NewInt(int64(2*s.units+1)))
+
+	// calculate all gammas and deltas before exponentiation
+	// these are the same for everyone!
+	// then calculate exponentiated values, one for each i and j.
+	// Every person will send as i*j different exponentiated gammas
+	// and i*j different exponentiated deltas!!!
+	k := s.numPrices()
+	for j := 0; j < k; j++ {
+		log.Printf("[Round 2] %v-th outer loop\n", j)
+		cachedValGamma := Round2ComputeInitialValue(s.group, n, k, j, getNumAlphas)
+		cachedValDelta := Round2ComputeInitialValue(s.group, n, k, j, getNumBetas)
+		for i := 0; i < n; i++ {
+			// initialize if necessary
+			if j == 0 {
+				s.GammasDeltasBeforeExponentiation[i] = new(GammaDeltaStruct)
+				s.GammasDeltasAfterExponentiation[s.id][i] = new(GammaDeltaStruct)
+				proofs[i] = new(DiscreteLogEqualityProofs)
+				gammas[i] = new(Gammas)
+				deltas[i] = new(Deltas)
+			}
+
+			// compute unexponentiated gammas/deltas
+			gamma := Round2ComputeOutcome(s.group, i, j, s.units, cachedValGamma, getNumAlphas)
+			gamma = zkp.Divide(s.group, gamma, yExp)
+			delta := Round2ComputeOutcome(s.group, i, j, s.units, cachedValDelta, getNumBetas)
+
+			s.GammasDeltasBeforeExponentiation[i].gammas =
+				append(s.GammasDeltasBeforeExponentiation[i].gammas, gamma)
+			s.GammasDeltasBeforeExponentiation[i].deltas =
+				append(s.GammasDeltasBeforeExponentiation[i].deltas, delta)
+
+			// now exponentiate to find the value we will publish to all!
+			mIJ := zkp.RandomExponent(s.group.Order())
+
+			gammaExp := s.group.Exp(gamma, mIJ)
+			deltaExp := s.group.Exp(delta, mIJ)
+
+			// add exponentiated value to our exponentiated Gammas/Deltas struct
+			s.GammasDeltasAfterExponentiation[s.id][i].gammas =
+				append(s.GammasDeltasAfterExponentiation[s.id][i].gammas, gammaExp)
+			s.GammasDeltasAfterExponentiation[s.id][i].deltas =
+				append(s.GammasDeltasAfterExponentiation[s.id][i].deltas, deltaExp)
+
+			// must prove that our exponentiated values have same exponent
+			gs := []zkp.Element{gamma, delta}
+
+			// now generate proof!
+			ts, r := zkp.GroupDiscreteLogEquality(tr.Fork("gammadelta", i, j), s.group, mIJ, gs)
+
+			// and add to the list of proofs!
+			proofs[i].Proofs = append(proofs[i].Proofs, pb.CreateGroupDiscreteLogEquality(s.group, ts, r))
+			// add the number manually
+			gammas[i].Gammas = append(gammas[i].Gammas, s.group.Encode(gammaExp))
+			deltas[i].Deltas = append(deltas[i].Deltas, s.group.Encode(deltaExp))
+		}
+	}
+
+	log.Printf("[Round 2] Sending ID %v\n", s.id)
+
+	return &Round2{
+		DoubleProofs: proofs,
+		DoubleGammas: gammas,
+		DoubleDeltas: deltas,
+	}, false, nil
+}
+
+func computeRound3(State interface{}) (proto.Message, bool, error) {
+	s := getState(State)
+	n := len(s.keys)
+
+	var doublePhis []*Phis
+	var proofs []*DiscreteLogEqualityProofs
+
+	s.PhisAfterExponentiation = make([][][]zkp.Element, n)
+
+	tr := s.transcript(stepRound3, s.id)
+
+	for i := 0; i < n; i++ {
+		s.PhisBeforeExponentiation =
+			append(s.PhisBeforeExponentiation, nil)
+		s.PhisAfterExponentiation[s.id] =
+			append(s.PhisAfterExponentiation[s.id], nil)
+
+		proofs = append(proofs, &DiscreteLogEqualityProofs{})
+
+		for j := 0; j < s.numPrices(); j++ {
+			phi := Multiply(s.group, 0, n, func(h int) zkp.Element {
+				return s.GammasDeltasAfterExponentiation[h][i].deltas[j]
+			})
+
+			phiExp := s.group.Exp(phi, s.myPrivateKey)
+
+			s.PhisBeforeExponentiation[i] =
+				append(s.PhisBeforeExponentiation[i], phi)
+
+			s.PhisAfterExponentiation[s.id][i] =
+				append(s.PhisAfterExponentiation[s.id][i], phiExp)
+
+			// must prove that our exponentiated phi has same exponent as our public key portion
+			gs := []zkp.Element{phi, s.group.Generator()}
+
+			// now generate proof!
+			ts, r := zkp.GroupDiscreteLogEquality(tr.Fork("phi", i, j), s.group, s.myPrivateKey, gs)
+
+			proofs[i].Proofs = append(proofs[i].Proofs, pb.CreateGroupDiscreteLogEquality(s.group, ts, r))
+		}
+
+		log.Printf("Round 3: %v %v\n", i, len(s.PhisAfterExponentiation[s.id][i]))
+
+		doublePhis = append(doublePhis, &Phis{
+			Phis: zkp.EncodeElements(s.group, s.PhisAfterExponentiation[s.id][i]),
+		})
+	}
+
+	var round3 Round3
+	round3 = Round3{
+		DoublePhis:   doublePhis,
+		DoubleProofs: proofs,
+	}
+	if s.id == 0 {
+		s.sellerRound3 = round3
+	}
+	return &round3, true, nil
+}
+
+// epilogue finds the winners and the selling price, and stores them in s.
+// The outcome of a winner decrypts to the identity at the price index of
+// the (M+1)st highest bid, but only the price of that index, never the
+// index itself, is kept: its offset names the bidder of that bid. It fails
+// unless exactly M bidders win, all at the same price.
+func epilogue(s *State) error {
+	var winners, prices []int
+	for a := 0; a < len(s.keys); a++ {
+		for price := 0; price < s.prices.Len(); price++ {
+			if s.wonAt(a, price) {
+				winners = append(winners, a)
+				prices = append(prices, price)
+			}
+		}
+	}
+
+	if len(winners) != s.units {
+		return fmt.Errorf("the outcomes name %v winners %v, not %v", len(winners), winners, s.units)
+	}
+	for i := range winners {
+		if i > 0 && winners[i] == winners[i-1] {
+			return fmt.Errorf("the outcomes name bidder %v as a winner more than once", winners[i])
+		}
+		if prices[i] != prices[0] {
+			return fmt.Errorf("the outcomes give bidders %v and %v different prices, %v and %v",
+				winners[0], winners[i], s.prices.Price(prices[0]), s.prices.Price(prices[i]))
+		}
+	}
+
+	s.winners, s.price = winners, uint(prices[0])
+	for _, a := range s.winners {
+		if a == s.id {
+			log.Printf("I won at selling price %v!", s.Price())
+		} else {
+			log.Printf("I did not win. ID %v won at selling price %v.", s.hostID(a), s.Price())
+		}
+	}
+	return nil
+}
+
+// wonAt returns whether the outcome of bidder a decrypts to the identity at
+// any of the price indices of the given price.
+func (s *State) wonAt(a, price int) bool {
+	n := len(s.keys)
+	won := false
+	for j := price * n; j < (price+1)*n; j++ {
+		numerator := Multiply(s.group, 0, n, func(i int) zkp.Element {
+			return s.GammasDeltasAfterExponentiation[i][a].gammas[j]
+		})
+
+		denominator := Multiply(s.group, 0, n, func(i int) zkp.Element {
+			return s.PhisAfterExponentiation[i][a][j]
+		})
+
+		if s.group.Equal(zkp.Divide(s.group, numerator, denominator), s.group.Identity()) {
+			won = true
+		}
+	}
+	return won
+}
@@ -0,0 +1,201 @@
+// Code generated by protoc-gen-go.
+// source: github.com/ashwinsr/auctions/mplus1/mplus1.proto
+// DO NOT EDIT!
+
+/*
+Package mplus1 is a generated protocol buffer package.
+
+It is generated from these files:
+	github.com/ashwinsr/auctions/mplus1/mplus1.proto
+
+It has these top-level messages:
+	Round1
+	Round2
+	Gammas
+	Deltas
+	DiscreteLogEqualityProofs
+	Round3
+	Phis
+*/
+package mplus1
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+import common_pb "github.com/ashwinsr/auctions/common_pb"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+type Round1 struct {
+	Alphas [][]byte                       `protobuf:"bytes,1,rep,name=alphas,proto3" json:"alphas,omitempty"`
+	Betas  [][]byte                       `protobuf:"bytes,2,rep,name=betas,proto3" json:"betas,omitempty"`
+	Proofs []*common_pb.EqualsOneOfTwo    `protobuf:"bytes,3,rep,name=proofs" json:"proofs,omitempty"`
+	Proof  *common_pb.DiscreteLogEquality `protobuf:"bytes,4,opt,name=proof" json:"proof,omitempty"`
+}
+
+func (m *Round1) Reset()                    { *m = Round1{} }
+func (m *Round1) String() string            { return proto.CompactTextString(m) }
+func (*Round1) ProtoMessage()               {}
+func (*Round1) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+func (m *Round1) GetProofs() []*common_pb.EqualsOneOfTwo {
+	if m != nil {
+		return m.Proofs
+	}
+	return nil
+}
+
+func (m *Round1) GetProof() *common_pb.DiscreteLogEquality {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+type Round2 struct {
+	DoubleGammas []*Gammas                    `protobuf:"bytes,1,rep,name=doubleGammas" json:"doubleGammas,omitempty"`
+	DoubleDeltas []*Deltas                    `protobuf:"bytes,2,rep,name=doubleDeltas" json:"doubleDeltas,omitempty"`
+	DoubleProofs []*DiscreteLogEqualityProofs `protobuf:"bytes,3,rep,name=doubleProofs" json:"doubleProofs,omitempty"`
+}
+
+func (m *Round2) Reset()                    { *m = Round2{} }
+func (m *Round2) String() string            { return proto.CompactTextString(m) }
+func (*Round2) ProtoMessage()               {}
+func (*Round2) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *Round2) GetDoubleGammas() []*Gammas {
+	if m != nil {
+		return m.DoubleGammas
+	}
+	return nil
+}
+
+func (m *Round2) GetDoubleDeltas() []*Deltas {
+	if m != nil {
+		return m.DoubleDeltas
+	}
+	return nil
+}
+
+func (m *Round2) GetDoubleProofs() []*DiscreteLogEqualityProofs {
+	if m != nil {
+		return m.DoubleProofs
+	}
+	return nil
+}
+
+type Gammas struct {
+	Gammas [][]byte `protobuf:"bytes,1,rep,name=gammas,proto3" json:"gammas,omitempty"`
+}
+
+func (m *Gammas) Reset()                    { *m = Gammas{} }
+func (m *Gammas) String() string            { return proto.CompactTextString(m) }
+func (*Gammas) ProtoMessage()               {}
+func (*Gammas) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+type Deltas struct {
+	Deltas [][]byte `protobuf:"bytes,1,rep,name=deltas,proto3" json:"deltas,omitempty"`
+}
+
+func (m *Deltas) Reset()                    { *m = Deltas{} }
+func (m *Deltas) String() string            { return proto.CompactTextString(m) }
+func (*Deltas) ProtoMessage()               {}
+func (*Deltas) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+type DiscreteLogEqualityProofs struct {
+	Proofs []*common_pb.DiscreteLogEquality `protobuf:"bytes,1,rep,name=proofs" json:"proofs,omitempty"`
+}
+
+func (m *DiscreteLogEqualityProofs) Reset()                    { *m = DiscreteLogEqualityProofs{} }
+func (m *DiscreteLogEqualityProofs) String() string            { return proto.CompactTextString(m) }
+func (*DiscreteLogEqualityProofs) ProtoMessage()               {}
+func (*DiscreteLogEqualityProofs) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *DiscreteLogEqualityProofs) GetProofs() []*common_pb.DiscreteLogEquality {
+	if m != nil {
+		return m.Proofs
+	}
+	return nil
+}
+
+type Round3 struct {
+	DoublePhis   []*Phis                      `protobuf:"bytes,1,rep,name=doublePhis" json:"doublePhis,omitempty"`
+	DoubleProofs []*DiscreteLogEqualityProofs `protobuf:"bytes,2,rep,name=doubleProofs" json:"doubleProofs,omitempty"`
+}
+
+func (m *Round3) Reset()                    { *m = Round3{} }
+func (m *Round3) String() string            { return proto.CompactTextString(m) }
+func (*Round3) ProtoMessage()               {}
+func (*Round3) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *Round3) GetDoublePhis() []*Phis {
+	if m != nil {
+		return m.DoublePhis
+	}
+	return nil
+}
+
+func (m *Round3) GetDoubleProofs() []*DiscreteLogEqualityProofs {
+	if m != nil {
+		return m.DoubleProofs
+	}
+	return nil
+}
+
+type Phis struct {
+	Phis [][]byte `protobuf:"bytes,1,rep,name=phis,proto3" json:"phis,omitempty"`
+}
+
+func (m *Phis) Reset()                    { *m = Phis{} }
+func (m *Phis) String() string            { return proto.CompactTextString(m) }
+func (*Phis) ProtoMessage()               {}
+func (*Phis) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+func init() {
+	proto.RegisterType((*Round1)(nil), "mplus1.Round1")
+	proto.RegisterType((*Round2)(nil), "mplus1.Round2")
+	proto.RegisterType((*Gammas)(nil), "mplus1.Gammas")
+	proto.RegisterType((*Deltas)(nil), "mplus1.Deltas")
+	proto.RegisterType((*DiscreteLogEqualityProofs)(nil), "mplus1.DiscreteLogEqualityProofs")
+	proto.RegisterType((*Round3)(nil), "mplus1.Round3")
+	proto.RegisterType((*Phis)(nil), "mplus1.Phis")
+}
+
+func init() {
+	proto.RegisterFile("github.com/ashwinsr/auctions/mplus1/mplus1.proto", fileDescriptor0)
+}
+
+var fileDescriptor0 = []byte{
+	// 351 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x52, 0x4d, 0x4f, 0x83, 0x40,
+	0x10, 0x4d, 0xbf, 0xf6, 0x30, 0x25, 0x1e, 0x36, 0xa6, 0xa1, 0x3d, 0x18, 0xe4, 0xd4, 0x83, 0xa1,
+	0x96, 0x1a, 0x7f, 0x41, 0x1b, 0x2f, 0x26, 0x35, 0xab, 0x77, 0xb3, 0x50, 0x5a, 0x48, 0x80, 0x41,
+	0x16, 0xd2, 0x78, 0xf0, 0xb7, 0xf8, 0x33, 0xfc, 0x7b, 0xa6, 0xec, 0xd0, 0x42, 0x1a, 0x35, 0xf1,
+	0xc4, 0xbc, 0xe1, 0xbd, 0x99, 0x37, 0x0f, 0xe0, 0x76, 0x17, 0x15, 0x61, 0xe9, 0x39, 0x3e, 0x26,
+	0x33, 0xa9, 0xc2, 0x7d, 0x94, 0xaa, 0x7c, 0x26, 0x4b, 0xbf, 0x88, 0x30, 0x55, 0xb3, 0x24, 0x8b,
+	0x4b, 0x35, 0xa7, 0x87, 0x93, 0xe5, 0x58, 0x20, 0x67, 0x1a, 0x4d, 0x16, 0xbf, 0x2a, 0x7d, 0x4c,
+	0x12, 0x4c, 0x5f, 0x33, 0x8f, 0x2a, 0x2d, 0xb6, 0x3f, 0x3b, 0xc0, 0x04, 0x96, 0xe9, 0x66, 0xce,
+	0x47, 0xc0, 0x64, 0x9c, 0x85, 0x52, 0x99, 0x1d, 0xab, 0x37, 0x35, 0x04, 0x21, 0x7e, 0x09, 0x03,
+	0x2f, 0x28, 0xa4, 0x32, 0xbb, 0x55, 0x5b, 0x03, 0x3e, 0x07, 0x96, 0xe5, 0x88, 0x5b, 0x65, 0xf6,
+	0xac, 0xde, 0x74, 0xe8, 0x8e, 0x9d, 0xe3, 0x06, 0x67, 0xf5, 0x56, 0xca, 0x58, 0xad, 0xd3, 0x60,
+	0xbd, 0x7d, 0xd9, 0xa3, 0x20, 0x22, 0xbf, 0x83, 0x41, 0x55, 0x99, 0x7d, 0xab, 0x33, 0x1d, 0xba,
+	0x57, 0x0d, 0xc5, 0x32, 0x52, 0x7e, 0x1e, 0x14, 0xc1, 0x23, 0xee, 0x2a, 0x71, 0x54, 0xbc, 0x0b,
+	0x4d, 0xb6, 0xbf, 0x6a, 0x87, 0x2e, 0x77, 0xc1, 0xd8, 0x60, 0xe9, 0xc5, 0xc1, 0x83, 0x4c, 0x12,
+	0xf2, 0x39, 0x74, 0x2f, 0x1c, 0x8a, 0x43, 0x77, 0x45, 0x8b, 0x73, 0xd2, 0x2c, 0x83, 0xb8, 0x3e,
+	0xa2, 0xa1, 0xd1, 0x5d, 0xd1, 0xe2, 0xf0, 0x55, 0xad, 0x79, 0x6a, 0x5e, 0x78, 0x7d, 0xd4, 0x9c,
+	0x9b, 0xd5, 0x44, 0xd1, 0x92, 0xd9, 0x16, 0x30, 0x32, 0x31, 0x02, 0xb6, 0x3b, 0x59, 0x36, 0x04,
+	0xa1, 0x03, 0x83, 0x56, 0x8e, 0x80, 0x6d, 0xb4, 0x41, 0x62, 0x68, 0x64, 0x3f, 0xc3, 0xf8, 0xc7,
+	0x75, 0xfc, 0xfe, 0xf8, 0x0d, 0x74, 0x12, 0x7f, 0x25, 0x4a, 0x6c, 0xfb, 0x83, 0x12, 0x5d, 0xf0,
+	0x1b, 0x00, 0xb2, 0x1c, 0x46, 0xf5, 0x14, 0xa3, 0xbe, 0xf3, 0xd0, 0x13, 0x8d, 0xf7, 0x67, 0xb9,
+	0x74, 0xff, 0x97, 0xcb, 0x04, 0xfa, 0xd5, 0x38, 0x0e, 0xfd, 0xac, 0x5e, 0x6b, 0x88, 0xaa, 0xf6,
+	0x58, 0xf5, 0x5b, 0x2e, 0xbe, 0x07, 0x00, 0xab, 0x1f, 0x7e, 0xe4, 0x07, 0x03, 0x00, 0x00,
+}
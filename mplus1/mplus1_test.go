@@ -0,0 +1,279 @@
+package mplus1
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/ashwinsr/auctions/common_pb"
+	"github.com/ashwinsr/auctions/lib"
+	"github.com/ashwinsr/auctions/zkp"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// The bid domain of the tests, which keeps the auctions fast
+const testK = 8
+
+// tamperFn changes the message a cheating party computed for its round.
+type tamperFn func(group zkp.Group, msg proto.Message)
+
+// runAuction runs an auction of the given number of units among len(bids)
+// parties over a memory network, in which cheater, unless it is
+// lib.NoClient, tampers with its message of the given step. It returns the
+// state and the error of every party.
+func runAuction(test *testing.T, units int, bids []uint, cheater int, step int, tamper tamperFn) ([]*State, []error) {
+	prices, err := lib.MaxPriceLadder(testK - 1)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	group, err := zkp.NamedGroup(zkp.ToyGroupName)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	n := len(bids)
+	hosts := make([]string, n)
+	network := lib.NewMemoryNetwork(n)
+	states := make([]*State, n)
+	errs := make([]error, n)
+
+	// Once a party fails, the others may wait forever for its messages
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		config := &lib.AuctionConfig{Hosts: hosts, MyID: i, Group: group, AuctionID: "test", Prices: prices}
+		session := lib.NewSession(config, network.Transport(i))
+		states[i], err = NewState(session, config, prices.Price(int(bids[i])), units)
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		rounds := Rounds(config)
+		if i == cheater {
+			rounds = tamperedRounds(group, rounds, step, tamper)
+		}
+
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if errs[i] = session.Connect(ctx); errs[i] == nil {
+				errs[i] = session.Run(ctx, rounds, states[i])
+			}
+			if errs[i] != nil {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return states, errs
+}
+
+// tamperedRounds returns rounds, with tamper applied to the message computed
+// in step.
+func tamperedRounds(group zkp.Group, rounds []lib.Round, step int, tamper tamperFn) []lib.Round {
+	tampered := append([]lib.Round(nil), rounds...)
+	compute := rounds[step-1].Compute
+	tampered[step-1].Compute = func(state interface{}) (proto.Message, bool, error) {
+		msg, sendToSeller, err := compute(state)
+		if err == nil {
+			tamper(group, msg)
+		}
+		return msg, sendToSeller, err
+	}
+	return tampered
+}
+
+// tamperElement returns the encoding of the element enc times the generator.
+func tamperElement(group zkp.Group, enc []byte) []byte {
+	elem, err := group.Decode(enc)
+	if err != nil {
+		panic(err)
+	}
+	return group.Encode(group.Mul(elem, group.Generator()))
+}
+
+func TestSecondPrice(test *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		bids    []uint
+		winners []int
+		price   uint
+	}{
+		{"two parties", []uint{0, 3}, []int{1}, 0},
+		{"seller bids highest", []uint{5, 2, 4}, []int{0}, 4},
+		{"lowest and highest bids", []uint{0, testK - 1, 0}, []int{1}, 0},
+		{"all bid zero", []uint{0, 0, 0}, []int{0}, 0},
+		{"tie", []uint{2, 6, 6, 1}, []int{1}, 6},
+		{"five parties", []uint{4, 1, 7, 3, 2}, []int{2}, 4},
+		{"ten parties", []uint{3, 1, 4, 1, 5, 0, 2, 6, 5, 3}, []int{7}, 5},
+	} {
+		states, errs := runAuction(test, 1, tc.bids, lib.NoClient, 0, nil)
+
+		for i, s := range states {
+			if errs[i] != nil {
+				test.Errorf("%v: party %v failed: %v", tc.name, i, errs[i])
+				continue
+			}
+			if !reflect.DeepEqual(s.winners, tc.winners) || s.price != tc.price {
+				test.Errorf("%v: party %v found winners %v at price %v, want %v at price %v",
+					tc.name, i, s.winners, s.price, tc.winners, tc.price)
+			}
+		}
+	}
+}
+
+func TestMultiUnit(test *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		units   int
+		bids    []uint
+		winners []int
+		price   uint
+	}{
+		{"two units", 2, []uint{3, 6, 1, 5}, []int{1, 3}, 3},
+		{"all but one win", 3, []uint{2, 0, 7, 4}, []int{0, 2, 3}, 0},
+		{"tie for the last unit", 2, []uint{4, 7, 4, 1}, []int{0, 1}, 4},
+		{"tie above the price", 2, []uint{6, 6, 2, 5}, []int{0, 1}, 5},
+		{"lowest and highest bids", 2, []uint{testK - 1, 0, 0, testK - 1, 0}, []int{0, 3}, 0},
+		{"ten parties", 4, []uint{3, 1, 4, 1, 5, 0, 2, 6, 5, 3}, []int{2, 4, 7, 8}, 3},
+	} {
+		states, errs := runAuction(test, tc.units, tc.bids, lib.NoClient, 0, nil)
+
+		for i, s := range states {
+			if errs[i] != nil {
+				test.Errorf("%v: party %v failed: %v", tc.name, i, errs[i])
+				continue
+			}
+			if !reflect.DeepEqual(s.Winners(), tc.winners) || s.Price() != fmt.Sprint(tc.price) {
+				test.Errorf("%v: party %v found winners %v at price %v, want %v at price %v",
+					tc.name, i, s.Winners(), s.Price(), tc.winners, tc.price)
+			}
+		}
+	}
+}
+
+func TestEpilogueCountsWinners(test *testing.T) {
+	states, errs := runAuction(test, 2, []uint{3, 6, 1, 5}, lib.NoClient, 0, nil)
+
+	for i, s := range states {
+		if errs[i] != nil {
+			test.Fatalf("Party %v failed: %v", i, errs[i])
+		}
+		// The outcomes name two winners, so parties selling any other
+		// number of units must reject them
+		for _, units := range []int{1, 3} {
+			s.units = units
+			if err := epilogue(s); err == nil {
+				test.Errorf("Party %v found %v winners %v selling %v units", i, len(s.winners), s.winners, units)
+			}
+		}
+	}
+}
+
+func TestWinnersAreHostIDs(test *testing.T) {
+	prices, err := lib.MaxPriceLadder(testK - 1)
+	if err != nil {
+		test.Fatal(err)
+	}
+	config := &lib.AuctionConfig{Hosts: []string{"host0", "host1", "host2", "host3"}, Prices: prices}
+
+	// Without party 1, party 2 of the run is party 3 of the hosts file
+	s, err := NewState(nil, config.Excluding(1), "0", 1)
+	if err != nil {
+		test.Fatal(err)
+	}
+	s.winners = []int{2}
+	if winners := s.Winners(); !reflect.DeepEqual(winners, []int{3}) {
+		test.Errorf("Got winners %v, want [3]", winners)
+	}
+}
+
+func TestSecondPriceDetectsCheating(test *testing.T) {
+	const cheater = 1
+
+	for _, tc := range []struct {
+		name   string
+		step   int
+		tamper tamperFn
+		kind   lib.ErrorKind
+	}{
+		{"prologue key without its secret", stepPrologue, func(group zkp.Group, msg proto.Message) {
+			key := msg.(*pb.Key)
+			key.Key = tamperElement(group, key.Key)
+		}, lib.ProofError},
+		{"round 1 missing bids", stepRound1, func(group zkp.Group, msg proto.Message) {
+			in := msg.(*Round1)
+			in.Alphas = in.Alphas[1:]
+		}, lib.DecodeError},
+		{"round 1 invalid bid", stepRound1, func(group zkp.Group, msg proto.Message) {
+			in := msg.(*Round1)
+			in.Alphas[0] = tamperElement(group, in.Alphas[0])
+		}, lib.ProofError},
+		{"round 1 missing proof", stepRound1, func(group zkp.Group, msg proto.Message) {
+			msg.(*Round1).Proof = nil
+		}, lib.DecodeError},
+		{"round 2 missing gammas", stepRound2, func(group zkp.Group, msg proto.Message) {
+			in := msg.(*Round2)
+			in.DoubleGammas = in.DoubleGammas[1:]
+		}, lib.DecodeError},
+		{"round 2 wrong exponent", stepRound2, func(group zkp.Group, msg proto.Message) {
+			in := msg.(*Round2)
+			in.DoubleGammas[0].Gammas[0] = tamperElement(group, in.DoubleGammas[0].Gammas[0])
+		}, lib.ProofError},
+		{"round 3 missing phis", stepRound3, func(group zkp.Group, msg proto.Message) {
+			in := msg.(*Round3)
+			in.DoublePhis[0].Phis = in.DoublePhis[0].Phis[1:]
+		}, lib.DecodeError},
+		{"round 3 wrong key", stepRound3, func(group zkp.Group, msg proto.Message) {
+			in := msg.(*Round3)
+			in.DoublePhis[2].Phis[3] = tamperElement(group, in.DoublePhis[2].Phis[3])
+		}, lib.ProofError},
+	} {
+		_, errs := runAuction(test, 1, []uint{2, 5, 3}, cheater, tc.step, tc.tamper)
+		checkCheaterDetected(test, tc.name, errs, cheater, tc.step, tc.kind)
+	}
+}
+
+// checkCheaterDetected checks that some honest party blamed cheater for an
+// error of the given kind in step, and that no party blamed an honest one.
+func checkCheaterDetected(test *testing.T, name string, errs []error, cheater int, step int, kind lib.ErrorKind) {
+	detected := false
+	for i, err := range errs {
+		if i == cheater {
+			continue
+		}
+		if err == nil {
+			test.Errorf("%v: party %v did not notice the cheating", name, i)
+			continue
+		}
+
+		e, ok := err.(*lib.RoundError)
+		if !ok {
+			test.Errorf("%v: party %v failed with %v, want a *lib.RoundError", name, i, err)
+			continue
+		}
+		switch e.Clientid {
+		case cheater:
+			if e.Round != step || e.Kind != kind {
+				test.Errorf("%v: party %v failed with %v, want a %v in round %v", name, i, e, kind, step)
+			}
+			detected = true
+		case lib.NoClient:
+			// Gave up once another party detected the cheating
+		default:
+			test.Errorf("%v: party %v blamed honest party %v: %v", name, i, e.Clientid, e)
+		}
+	}
+
+	if !detected {
+		test.Errorf("%v: no party blamed the cheater", name)
+	}
+}
@@ -0,0 +1,52 @@
+/*
+ * This file runs an (M+1)st price auction among multiple bidders for M
+ * identical units: the M highest bidders win one unit each, and all pay
+ * the (M+1)st highest bid. M is the "units" entry of the hosts file.
+ *
+ * To invoke, run:
+ *          go run *.go -bid=<BID VALUE>
+ */
+
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/ashwinsr/auctions/lib"
+	"github.com/ashwinsr/auctions/mplus1"
+	"golang.org/x/net/context"
+)
+
+var (
+	bid = flag.String("bid", "0", "Amount of money, one of the prices of the hosts file")
+)
+
+func main() {
+	flag.Parse()
+
+	config := lib.GetAuctionConfig("multi_unit")
+
+	log.Println("My address is: ", config.Hosts[config.MyID])
+	log.Println("My ID is: ", config.MyID)
+
+	_, myState, err := lib.RunAuction(context.Background(), config, protocol(*bid, lib.NewGRPCTransport))
+	if err != nil {
+		log.Fatalf("Auction failed: %v", err)
+	}
+
+	lib.PrintResult(myState.(*mplus1.State).Result())
+}
+
+// protocol returns the (M+1)st price auction of a party bidding bid, for
+// config.Units units, talking to the others over the transports of
+// newTransport.
+func protocol(bid string, newTransport func(config *lib.AuctionConfig) lib.Transport) lib.Protocol {
+	return lib.Protocol{
+		NewTransport: newTransport,
+		Start: func(config *lib.AuctionConfig, session *lib.Session) ([]lib.Round, interface{}, error) {
+			myState, err := mplus1.NewState(session, config, bid, config.Units)
+			return mplus1.Rounds(config), myState, err
+		},
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ashwinsr/auctions/lib"
+	"github.com/ashwinsr/auctions/mplus1"
+	"github.com/ashwinsr/auctions/zkp"
+	"golang.org/x/net/context"
+)
+
+// runMultiUnit runs the command selling the given number of units among
+// len(bids) parties over a memory network, and returns the result and the
+// error of every party.
+func runMultiUnit(test *testing.T, units int, bids []string) ([]*lib.Result, []error) {
+	prices, err := lib.MaxPriceLadder(7)
+	if err != nil {
+		test.Fatal(err)
+	}
+	group, err := zkp.NamedGroup(zkp.ToyGroupName)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	n := len(bids)
+	network := lib.NewMemoryNetwork(n)
+	hosts := make([]string, n)
+	for i := range hosts {
+		hosts[i] = fmt.Sprintf("host%v", i)
+	}
+	results := make([]*lib.Result, n)
+	errs := make([]error, n)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		i := i
+		config := &lib.AuctionConfig{Hosts: hosts, MyID: i, Group: group, AuctionID: "test", Prices: prices, Units: units}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, state, err := lib.RunAuction(ctx, config, protocol(bids[i], func(config *lib.AuctionConfig) lib.Transport {
+				return network.Transport(config.MyID)
+			}))
+			if errs[i] = err; err != nil {
+				cancel()
+				return
+			}
+			results[i] = state.(*mplus1.State).Result()
+		}()
+	}
+	wg.Wait()
+
+	return results, errs
+}
+
+func TestMultiUnit(test *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		units   int
+		bids    []string
+		winners []int
+		price   string
+	}{
+		{"one unit", 1, []string{"3", "5", "1"}, []int{1}, "3"},
+		{"two units", 2, []string{"3", "6", "1", "5"}, []int{1, 3}, "3"},
+		{"three units", 3, []string{"2", "7", "4", "1", "6"}, []int{1, 2, 4}, "2"},
+		{"a tie at the price", 2, []string{"4", "4", "4", "1"}, []int{0, 1}, "4"},
+	} {
+		results, errs := runMultiUnit(test, tc.units, tc.bids)
+		for i, r := range results {
+			if errs[i] != nil {
+				test.Errorf("%v: party %v failed: %v", tc.name, i, errs[i])
+				continue
+			}
+			if !reflect.DeepEqual(r.Winners, tc.winners) || r.Price != tc.price {
+				test.Errorf("%v: party %v found winners %v at price %v, want %v at price %v",
+					tc.name, i, r.Winners, r.Price, tc.winners, tc.price)
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/ashwinsr/auctions/lib"
+)
+
+// authority is the certificate authority every party trusts, which issues
+// the certificates of the parties.
+type authority struct {
+	cert     *x509.Certificate
+	key      crypto.Signer
+	validity time.Duration // of the certificates issued
+}
+
+// loadAuthority loads the certificate authority from the PEM files
+// certFile and keyFile, such as ../certs/ca.cert and ../certs/ca.key.
+func loadAuthority(certFile, keyFile string, validity time.Duration) (*authority, error) {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not load the CA certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA certificate: %v", err)
+	}
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("cannot sign with a %T", pair.PrivateKey)
+	}
+	return &authority{cert: cert, key: key, validity: validity}, nil
+}
+
+// pool returns a pool with the certificate of the authority.
+func (ca *authority) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+// issue generates a key and issues a certificate for it, valid for the
+// given hosts, which are IP addresses or host names. If party is not
+// empty, the certificate also names the party serving on it, as
+// lib.PartyURI does, so that others can tell it from the parties on the
+// same host. The certificate may be used by both servers and clients, as
+// the parties are both. It returns the certificate, and the certificate
+// and the key, PEM encoded.
+func (ca *authority) issue(party string, hosts ...string) (cert *x509.Certificate, certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization:       []string{"Doggy Denton"},
+			OrganizationalUnit: []string{"Auctions"},
+			CommonName:         hosts[0],
+		},
+		NotBefore:   now.Add(-time.Hour), // allow for clock skew
+		NotAfter:    now.Add(ca.validity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if party != "" {
+		template.URIs = []*url.URL{lib.PartyURI(party)}
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, key.Public(), ca.key)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("could not issue a certificate for %v: %v", hosts, err)
+	}
+	if cert, err = x509.ParseCertificate(der); err != nil {
+		return nil, nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return cert, certPEM, keyPEM, nil
+}
+
+// serverCertificate issues the certificate the registrar serves with.
+func (ca *authority) serverCertificate(hosts ...string) (tls.Certificate, error) {
+	_, certPEM, keyPEM, err := ca.issue("", hosts...)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
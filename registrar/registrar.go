@@ -0,0 +1,53 @@
+/*
+ * This file contains the registration server of the auctions. It keeps
+ * any number of auctions, by auction id. Parties register for an auction
+ * with it, which issues their certificates from the certificate authority
+ * in certs/, and then download their hosts.auc file from it. It serves
+ * over HTTPS, with a certificate of its own from the same authority.
+ *
+ * To invoke, run:
+ *          go run *.go -name=<HOST NAME OR IP OF THE REGISTRAR>
+ */
+
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	addr      = flag.String("addr", ":443", "Address to serve on")
+	names     = flag.String("name", "localhost", "Comma-separated host names and IPs of the registrar, for its certificate")
+	caCert    = flag.String("ca-cert", "../certs/ca.cert", "Certificate of the certificate authority")
+	caKey     = flag.String("ca-key", "../certs/ca.key", "Key of the certificate authority")
+	firstPort = flag.Int("port", 9001, "Port of the first party of an auction; the others get the ports after it")
+	validity  = flag.Duration("validity", 30*24*time.Hour, "How long the certificates issued are valid")
+	ttl       = flag.Duration("ttl", 24*time.Hour, "How long auctions are kept, unless created with a ttl of their own")
+)
+
+func main() {
+	flag.Parse()
+
+	ca, err := loadAuthority(*caCert, *caKey, *validity)
+	if err != nil {
+		log.Fatalf("Failed to load the CA: %v", err)
+	}
+	cert, err := ca.serverCertificate(strings.Split(*names, ",")...)
+	if err != nil {
+		log.Fatalf("Failed to issue the certificate of the registrar: %v", err)
+	}
+
+	r := newRegistrar(ca, *firstPort, *ttl)
+	server := &http.Server{
+		Addr:      *addr,
+		Handler:   r.handler(),
+		TLSConfig: r.tlsConfig(cert),
+	}
+
+	log.Printf("Serving on %v", *addr)
+	log.Fatal(server.ListenAndServeTLS("", ""))
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ashwinsr/auctions/lib"
+)
+
+// newTestAuthority returns a new certificate authority.
+func newTestAuthority(test *testing.T) *authority {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		test.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		test.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		test.Fatal(err)
+	}
+	return &authority{cert: cert, key: key, validity: time.Hour}
+}
+
+// startRegistrar starts a registrar over HTTPS, with a new authority.
+func startRegistrar(test *testing.T) (*httptest.Server, *registrar) {
+	ca := newTestAuthority(test)
+	cert, err := ca.serverCertificate("127.0.0.1")
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	r := newRegistrar(ca, 9001, time.Hour)
+	server := httptest.NewUnstartedServer(r.handler())
+	server.TLS = r.tlsConfig(cert)
+	server.StartTLS()
+	return server, r
+}
+
+// request sends a request with the given method, path and body to server
+// as a client trusting the authority of r, presenting certs. It returns the
+// status and body of the response.
+func request(test *testing.T, server *httptest.Server, r *registrar, method, path, body string, certs ...tls.Certificate) (int, []byte) {
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: r.ca.pool(), Certificates: certs},
+	}}
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	if err != nil {
+		test.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		test.Fatalf("%v %v failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		test.Fatalf("%v %v failed: %v", method, path, err)
+	}
+	return resp.StatusCode, data
+}
+
+// create creates an auction with the given settings, and returns its id.
+func create(test *testing.T, server *httptest.Server, r *registrar, settings string) string {
+	status, body := request(test, server, r, http.MethodPost, "/create", settings)
+	if status != http.StatusCreated {
+		test.Fatalf("Failed to create an auction with %v, with status %v: %s", settings, status, body)
+	}
+	var info auctionInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		test.Fatalf("Invalid auction %q: %v", body, err)
+	}
+	return info.AuctionID
+}
+
+// register registers for the auction with the given id, and returns the
+// certificate issued.
+func register(test *testing.T, server *httptest.Server, r *registrar, id string, myID int) tls.Certificate {
+	status, body := request(test, server, r, http.MethodPost, "/register?auction="+id, "")
+	if status != http.StatusOK {
+		test.Fatalf("Failed to register party %v of %v, with status %v: %s", myID, id, status, body)
+	}
+	files := unzip(test, body)
+	cert, err := tls.X509KeyPair(files[fmt.Sprintf("%v.cert", myID)], files[fmt.Sprintf("%v.key", myID)])
+	if err != nil {
+		test.Fatalf("Invalid certificate of party %v in %v files: %v", myID, len(files), err)
+	}
+	return cert
+}
+
+// unzip returns the files in the zip file data, by name.
+func unzip(test *testing.T, data []byte) map[string][]byte {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		test.Fatalf("Invalid zip file: %v", err)
+	}
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			test.Fatal(err)
+		}
+		files[f.Name], err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			test.Fatal(err)
+		}
+	}
+	return files
+}
+
+func TestRegistrar(test *testing.T) {
+	const n = 3
+
+	server, r := startRegistrar(test)
+	defer server.Close()
+
+	if status, _ := request(test, server, r, http.MethodPost, "/register?auction=none", ""); status != http.StatusNotFound {
+		test.Errorf("Registered for an unknown auction, with status %v", status)
+	}
+	id := create(test, server, r, `{"prices": {"max": 15}}`)
+
+	// Every party gets a certificate for its IP address and port, which it
+	// can both serve and dial with
+	var certs []tls.Certificate
+	for i := 0; i < n; i++ {
+		cert := register(test, server, r, id, i)
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			test.Fatal(err)
+		}
+		for _, usage := range []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth} {
+			if _, err := leaf.Verify(x509.VerifyOptions{Roots: r.ca.pool(), DNSName: "127.0.0.1", KeyUsages: []x509.ExtKeyUsage{usage}}); err != nil {
+				test.Errorf("Certificate of party %v does not verify: %v", i, err)
+			}
+		}
+		if want := fmt.Sprintf("auction://127.0.0.1:%v", 9001+i); len(leaf.URIs) != 1 || leaf.URIs[0].String() != want {
+			test.Errorf("Certificate of party %v names %v, want %v", i, leaf.URIs, want)
+		}
+		certs = append(certs, cert)
+	}
+
+	// The hosts files are final once the seller closes the auction, after
+	// which nobody registers
+	if status, _ := request(test, server, r, http.MethodGet, "/download_auc?auction="+id, "", certs[1]); status != http.StatusConflict {
+		test.Errorf("Downloaded the hosts file of an open auction, with status %v", status)
+	}
+	for _, tc := range []struct {
+		name   string
+		certs  []tls.Certificate
+		status int
+	}{
+		{"without a certificate", nil, http.StatusForbidden},
+		{"by a bidder", certs[1:2], http.StatusForbidden},
+		{"by the seller", certs[0:1], http.StatusOK},
+	} {
+		if status, body := request(test, server, r, http.MethodPost, "/close?auction="+id, "", tc.certs...); status != tc.status {
+			test.Errorf("Closing %v got status %v, want %v: %s", tc.name, status, tc.status, body)
+		}
+	}
+	if status, _ := request(test, server, r, http.MethodPost, "/register?auction="+id, ""); status != http.StatusConflict {
+		test.Errorf("Registered for a closed auction, with status %v", status)
+	}
+
+	wantHosts := []string{"127.0.0.1:9001", "127.0.0.1:9002", "127.0.0.1:9003"}
+	for _, tc := range []struct {
+		name  string
+		certs []tls.Certificate
+		myID  int
+	}{
+		{"with the certificate of party 2", certs[2:3], 2},
+		{"with the certificate of party 1", certs[1:2], 1},
+		{"with the certificate of the seller", certs[0:1], 0},
+	} {
+		status, body := request(test, server, r, http.MethodGet, "/download_auc?auction="+id, "", tc.certs...)
+		if status != http.StatusOK {
+			test.Errorf("%v: download failed with status %v", tc.name, status)
+			continue
+		}
+		config, err := lib.ReadAuctionConfig(bytes.NewReader(body), "first_price")
+		if err != nil {
+			test.Errorf("%v: invalid hosts file %s: %v", tc.name, body, err)
+			continue
+		}
+		if config.AuctionID != id || config.Prices.Len() != 16 || config.MyID != tc.myID ||
+			!reflect.DeepEqual(config.Hosts, wantHosts) || config.Seller != wantHosts[0] {
+			test.Errorf("%v: got hosts file %s, want id %v among %v with seller %v in auction %v",
+				tc.name, body, tc.myID, wantHosts, wantHosts[0], id)
+		}
+		for i, cert := range certs {
+			if fingerprint := sha256.Sum256(cert.Certificate[0]); !bytes.Equal(config.Fingerprints[i], fingerprint[:]) {
+				test.Errorf("%v: hosts file has fingerprint %x of party %v, want %x", tc.name, config.Fingerprints[i], i, fingerprint)
+			}
+		}
+		if want := r.auctions[id].expires.Truncate(time.Second); !config.Deadline.Equal(want) {
+			test.Errorf("%v: hosts file has deadline %v, want %v", tc.name, config.Deadline, want)
+		}
+	}
+
+	// Parties are known by their certificates, not their IP addresses
+	if status, _ := request(test, server, r, http.MethodGet, "/download_auc?auction="+id, ""); status != http.StatusForbidden {
+		test.Errorf("Downloaded a hosts file without a certificate, with status %v", status)
+	}
+}
+
+func TestRegistrarAuctions(test *testing.T) {
+	server, r := startRegistrar(test)
+	defer server.Close()
+
+	start := time.Now()
+	now := start
+	r.now = func() time.Time { return now }
+
+	first := create(test, server, r, `{"protocol": "first_price", "ttl": "10m"}`)
+	now = now.Add(time.Minute)
+	multi := create(test, server, r, `{"protocol": "multi_unit", "units": 2, "prices": {"bits": 4}}`)
+
+	// The auctions have their own parties
+	register(test, server, r, first, 0)
+	seller := register(test, server, r, multi, 0)
+	other := register(test, server, r, first, 1)
+	bidder := register(test, server, r, multi, 1)
+	register(test, server, r, multi, 2)
+
+	if status, body := request(test, server, r, http.MethodPost, "/close?auction="+multi, "", seller); status != http.StatusOK {
+		test.Fatalf("Failed to close %v, with status %v: %s", multi, status, body)
+	}
+	status, body := request(test, server, r, http.MethodGet, "/download_auc?auction="+multi, "", bidder)
+	var hosts hostsFile
+	if status != http.StatusOK || json.Unmarshal(body, &hosts) != nil {
+		test.Fatalf("Failed to download the hosts file of %v, with status %v: %s", multi, status, body)
+	}
+	if hosts.AuctionID != multi || hosts.Protocol != "multi_unit" || hosts.Units != 2 || hosts.MyID != 1 || len(hosts.Hosts) != 3 {
+		test.Errorf("Got hosts file %s, want party 1 of 3 in multi_unit auction %v of 2 units", body, multi)
+	}
+	if status, _ := request(test, server, r, http.MethodGet, "/download_auc?auction="+multi, "", other); status != http.StatusForbidden {
+		test.Errorf("Downloaded the hosts file of another auction, with status %v", status)
+	}
+
+	list := func() []auctionInfo {
+		status, body := request(test, server, r, http.MethodGet, "/auctions", "")
+		var infos []auctionInfo
+		if status != http.StatusOK || json.Unmarshal(body, &infos) != nil {
+			test.Fatalf("Failed to list the auctions, with status %v: %s", status, body)
+		}
+		return infos
+	}
+	infos := list()
+	if len(infos) != 2 || infos[0].AuctionID != first || infos[0].Parties != 2 ||
+		infos[1].AuctionID != multi || infos[1].Parties != 3 || !infos[1].Expires.Equal(start.Add(time.Minute+time.Hour)) {
+		test.Errorf("Listed %+v, want %v with 2 parties and %v with 3", infos, first, multi)
+	}
+
+	// The first auction expires before the second
+	now = start.Add(10 * time.Minute)
+	if infos := list(); len(infos) != 1 || infos[0].AuctionID != multi {
+		test.Errorf("Listed %+v, want only %v", infos, multi)
+	}
+	if status, _ := request(test, server, r, http.MethodGet, "/download_auc?auction="+first, "", other); status != http.StatusNotFound {
+		test.Errorf("Downloaded the hosts file of an expired auction, with status %v", status)
+	}
+	now = start.Add(2 * time.Hour)
+	if infos := list(); len(infos) != 0 {
+		test.Errorf("Listed %+v, want none", infos)
+	}
+}
+
+func TestRegistrarInvalidRequests(test *testing.T) {
+	server, r := startRegistrar(test)
+	defer server.Close()
+
+	for _, settings := range []string{
+		`{"protocol": "dutch"}`,
+		`{"prices": {"max": "many"}}`,
+		`{"prices": ["2.00", "1.00"]}`,
+		`{"units": 2}`,
+		`{"protocol": "multi_unit", "units": -1}`,
+		`{"protocol": "millionaire", "prices": {"max": 62}}`,
+		`{"ttl": "forever"}`,
+		`{"ttl": "-1h"}`,
+		`not json`,
+	} {
+		if status, _ := request(test, server, r, http.MethodPost, "/create", settings); status != http.StatusBadRequest {
+			test.Errorf("Creating an auction with %v got status %v, want %v", settings, status, http.StatusBadRequest)
+		}
+	}
+
+	for _, tc := range []struct {
+		method, path string
+		status       int
+	}{
+		{http.MethodGet, "/create", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/register?auction=none", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/register", http.StatusBadRequest},
+		{http.MethodGet, "/download_auc?auction=none", http.StatusNotFound},
+	} {
+		if status, _ := request(test, server, r, tc.method, tc.path, ""); status != tc.status {
+			test.Errorf("%v %v got status %v, want %v", tc.method, tc.path, status, tc.status)
+		}
+	}
+
+	// Without settings, an auction is a first price one
+	if status, body := request(test, server, r, http.MethodPost, "/create", ""); status != http.StatusCreated ||
+		!strings.Contains(string(body), `"protocol":"first_price"`) {
+		test.Errorf("Creating an auction without settings got status %v: %s", status, body)
+	}
+}
+
+func TestLoadAuthority(test *testing.T) {
+	ca, err := loadAuthority("../certs/ca.cert", "../certs/ca.key", time.Hour)
+	if err != nil {
+		test.Fatalf("Failed to load the CA of the repository: %v", err)
+	}
+	if _, _, _, err := ca.issue("127.0.0.1:9001", "127.0.0.1"); err != nil {
+		test.Errorf("Failed to issue a certificate: %v", err)
+	}
+
+	if _, err := loadAuthority("../certs/ca.cert", "../certs/0.key", time.Hour); err == nil {
+		test.Errorf("Loaded a CA certificate with the wrong key")
+	}
+}
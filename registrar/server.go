@@ -0,0 +1,436 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ashwinsr/auctions/lib"
+)
+
+// The protocols an auction may run, by the folder of their command
+var protocols = map[string]bool{
+	"first_price":  true,
+	"second_price": true,
+	"multi_unit":   true,
+	"millionaire":  true,
+}
+
+// auction is an auction parties register for. The first party to register
+// is the seller.
+type auction struct {
+	id       string
+	protocol string
+	prices   json.RawMessage // as in the hosts file, nil for the default
+	units    int             // of a multi_unit auction
+
+	created time.Time
+	expires time.Time
+	closed  bool // to new parties
+
+	hosts        []string       // the address every party serves on, by id
+	fingerprints []string       // of the certificate of every party, by id
+	serials      map[string]int // the ids of the certificates issued, by serial number
+}
+
+// auctionSettings are the settings of a new auction.
+type auctionSettings struct {
+	Protocol string          `json:"protocol"`
+	Prices   json.RawMessage `json:"prices"`
+	Units    int             `json:"units"`
+	TTL      string          `json:"ttl"` // how long the auction is kept
+}
+
+// auctionInfo describes an auction to anyone.
+type auctionInfo struct {
+	AuctionID string          `json:"auctionID"`
+	Protocol  string          `json:"protocol"`
+	Prices    json.RawMessage `json:"prices,omitempty"`
+	Units     int             `json:"units,omitempty"`
+	Parties   int             `json:"parties"`
+	Closed    bool            `json:"closed"`
+	Expires   time.Time       `json:"expires"`
+}
+
+// hostsFile is the hosts.auc file of a party, as described at
+// lib.DescriptorVersion.
+type hostsFile struct {
+	Version      int             `json:"version"`
+	AuctionID    string          `json:"auctionID"`
+	Protocol     string          `json:"protocol"`
+	MyID         int             `json:"myID"`
+	Hosts        []string        `json:"hosts"`
+	Seller       string          `json:"seller"`
+	SellerID     int             `json:"sellerID"`
+	Fingerprints []string        `json:"fingerprints"`
+	Deadline     string          `json:"deadline"`
+	Prices       json.RawMessage `json:"prices,omitempty"`
+	Units        int             `json:"units,omitempty"`
+}
+
+// registrar registers parties for auctions, issuing their certificates,
+// and gives every party its hosts.auc file.
+type registrar struct {
+	ca        *authority
+	firstPort int           // of the first party, the next one gets the next port
+	ttl       time.Duration // how long auctions are kept, unless created otherwise
+	now       func() time.Time
+
+	lock     sync.Mutex
+	auctions map[string]*auction // by id
+}
+
+func newRegistrar(ca *authority, firstPort int, ttl time.Duration) *registrar {
+	return &registrar{
+		ca:        ca,
+		firstPort: firstPort,
+		ttl:       ttl,
+		now:       time.Now,
+		auctions:  make(map[string]*auction),
+	}
+}
+
+// handler returns the handler of the requests to the registrar.
+func (r *registrar) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/create", only(http.MethodPost, r.create))
+	mux.HandleFunc("/auctions", only(http.MethodGet, r.list))
+	mux.HandleFunc("/register", only(http.MethodPost, r.register))
+	mux.HandleFunc("/download_auc", only(http.MethodGet, r.downloadAuc))
+	mux.HandleFunc("/close", only(http.MethodPost, r.close))
+	return mux
+}
+
+// tlsConfig returns the TLS configuration to serve with cert. Parties may
+// present the certificate they registered with, to prove who they are.
+func (r *registrar) tlsConfig(cert tls.Certificate) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    r.ca.pool(),
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+	}
+}
+
+// only restricts h to requests with the given method.
+func only(method string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != method {
+			w.Header().Set("Allow", method)
+			http.Error(w, fmt.Sprintf("Only %v is allowed", method), http.StatusMethodNotAllowed)
+			return
+		}
+		h(w, req)
+	}
+}
+
+// writeJSON writes v as the JSON response, with the given status.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, "Could not write the response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// create starts a new auction, with the settings in the body of the
+// request, and returns its description.
+func (r *registrar) create(w http.ResponseWriter, req *http.Request) {
+	var settings auctionSettings
+	if err := json.NewDecoder(io.LimitReader(req.Body, 1<<20)).Decode(&settings); err != nil && err != io.EOF {
+		http.Error(w, fmt.Sprintf("Invalid settings: %v", err), http.StatusBadRequest)
+		return
+	}
+	a, err := r.newAuction(&settings)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid settings: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.auctions[a.id] = a
+	log.Printf("Created auction %v of %v for %v", a.id, a.protocol, req.RemoteAddr)
+	writeJSON(w, http.StatusCreated, a.info())
+}
+
+// newAuction returns a new auction with the given settings, which it
+// checks.
+func (r *registrar) newAuction(settings *auctionSettings) (*auction, error) {
+	a := &auction{
+		protocol: settings.Protocol,
+		units:    settings.Units,
+		created:  r.now(),
+		serials:  make(map[string]int),
+	}
+
+	if a.protocol == "" {
+		a.protocol = "first_price"
+	}
+	if !protocols[a.protocol] {
+		return nil, fmt.Errorf("unknown protocol %q", a.protocol)
+	}
+
+	prices, err := lib.ParsePrices(a.protocol, settings.Prices)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prices: %v", err)
+	}
+	if err := lib.CheckPrices(a.protocol, prices); err != nil {
+		return nil, err
+	}
+	if len(settings.Prices) > 0 && string(settings.Prices) != "null" {
+		a.prices = settings.Prices
+	}
+
+	switch {
+	case a.protocol == "multi_unit" && a.units == 0:
+		a.units = 1
+	case a.protocol == "multi_unit" && a.units < 0:
+		return nil, fmt.Errorf("invalid number of units %v", a.units)
+	case a.protocol != "multi_unit" && a.units != 0:
+		return nil, fmt.Errorf("only a multi_unit auction has units")
+	}
+
+	ttl := r.ttl
+	if settings.TTL != "" {
+		if ttl, err = time.ParseDuration(settings.TTL); err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("invalid ttl %q", settings.TTL)
+		}
+	}
+	a.expires = a.created.Add(ttl)
+
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+	a.id = hex.EncodeToString(id[:])
+	return a, nil
+}
+
+// list returns the descriptions of all auctions, oldest first.
+func (r *registrar) list(w http.ResponseWriter, req *http.Request) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.expire()
+	var auctions []*auction
+	for _, a := range r.auctions {
+		auctions = append(auctions, a)
+	}
+	sort.Slice(auctions, func(i, j int) bool {
+		if !auctions[i].created.Equal(auctions[j].created) {
+			return auctions[i].created.Before(auctions[j].created)
+		}
+		return auctions[i].id < auctions[j].id
+	})
+
+	infos := []*auctionInfo{}
+	for _, a := range auctions {
+		infos = append(infos, a.info())
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+// register registers the requester for the auction given by the "auction"
+// parameter, at its IP address, and returns a zip file with its
+// certificate and key.
+func (r *registrar) register(w http.ResponseWriter, req *http.Request) {
+	ip := requestIP(req)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	a := r.lookup(w, req)
+	if a == nil {
+		return
+	}
+	if a.closed {
+		http.Error(w, "The auction is closed", http.StatusConflict)
+		return
+	}
+
+	id := len(a.hosts)
+	host := net.JoinHostPort(ip, strconv.Itoa(r.firstPort+id))
+	cert, certPEM, keyPEM, err := r.ca.issue(host, ip)
+	if err != nil {
+		log.Printf("Failed to register %v: %v", ip, err)
+		http.Error(w, "Could not issue a certificate", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{
+		{fmt.Sprintf("%v.cert", id), certPEM},
+		{fmt.Sprintf("%v.key", id), keyPEM},
+	} {
+		f, err := zw.Create(file.name)
+		if err == nil {
+			_, err = f.Write(file.data)
+		}
+		if err != nil {
+			http.Error(w, "Could not write the certificates", http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		http.Error(w, "Could not write the certificates", http.StatusInternalServerError)
+		return
+	}
+
+	fingerprint := sha256.Sum256(cert.Raw)
+	a.hosts = append(a.hosts, host)
+	a.fingerprints = append(a.fingerprints, hex.EncodeToString(fingerprint[:]))
+	a.serials[cert.SerialNumber.String()] = id
+	log.Printf("Registered %v as party %v of auction %v", host, id, a.id)
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%v.zip", id))
+	w.Write(buf.Bytes())
+}
+
+// downloadAuc returns the hosts.auc file of the requester for the auction
+// given by the "auction" parameter, once it is closed. The requester is the
+// party whose certificate it presents. The auction must be over by the time
+// it expires.
+func (r *registrar) downloadAuc(w http.ResponseWriter, req *http.Request) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	a := r.lookup(w, req)
+	if a == nil {
+		return
+	}
+	id, ok := a.requesterID(req)
+	if !ok {
+		http.Error(w, "Present the certificate you registered for this auction with", http.StatusForbidden)
+		return
+	}
+	if !a.closed {
+		http.Error(w, "The auction is still open to new parties", http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", "attachment; filename=hosts.auc")
+	writeJSON(w, http.StatusOK, &hostsFile{
+		Version:      lib.DescriptorVersion,
+		AuctionID:    a.id,
+		Protocol:     a.protocol,
+		MyID:         id,
+		Hosts:        a.hosts,
+		Seller:       a.hosts[0],
+		SellerID:     0,
+		Fingerprints: a.fingerprints,
+		Deadline:     a.expires.UTC().Format(time.RFC3339),
+		Prices:       a.prices,
+		Units:        a.units,
+	})
+}
+
+// close closes the auction given by the "auction" parameter to new
+// parties, so that they may download their hosts.auc files. Only the seller
+// may close it, with its certificate.
+func (r *registrar) close(w http.ResponseWriter, req *http.Request) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	a := r.lookup(w, req)
+	if a == nil {
+		return
+	}
+	if id, ok := a.requesterID(req); !ok || id != 0 {
+		http.Error(w, "Only the seller may close the auction, with its certificate", http.StatusForbidden)
+		return
+	}
+
+	if len(a.hosts) < 2 {
+		http.Error(w, "An auction needs at least 2 parties", http.StatusConflict)
+		return
+	}
+
+	a.closed = true
+	log.Printf("Closed auction %v with %v parties", a.id, len(a.hosts))
+	writeJSON(w, http.StatusOK, a.info())
+}
+
+// lookup returns the auction given by the "auction" parameter of req, or
+// writes an error and returns nil if there is none. The caller must hold
+// r.lock.
+func (r *registrar) lookup(w http.ResponseWriter, req *http.Request) *auction {
+	r.expire()
+
+	id := req.URL.Query().Get("auction")
+	if id == "" {
+		http.Error(w, "Missing auction parameter", http.StatusBadRequest)
+		return nil
+	}
+	a, ok := r.auctions[id]
+	if !ok {
+		http.Error(w, fmt.Sprintf("No auction %q exists", id), http.StatusNotFound)
+		return nil
+	}
+	return a
+}
+
+// expire forgets the auctions that expired. The caller must hold r.lock.
+func (r *registrar) expire() {
+	now := r.now()
+	for id, a := range r.auctions {
+		if !now.Before(a.expires) {
+			log.Printf("Auction %v expired", id)
+			delete(r.auctions, id)
+		}
+	}
+}
+
+// info returns the description of a.
+func (a *auction) info() *auctionInfo {
+	return &auctionInfo{
+		AuctionID: a.id,
+		Protocol:  a.protocol,
+		Prices:    a.prices,
+		Units:     a.units,
+		Parties:   len(a.hosts),
+		Closed:    a.closed,
+		Expires:   a.expires,
+	}
+}
+
+// requesterID returns the id of the party that sent req, which is the one
+// whose certificate it presents: an IP address may be shared by several
+// parties, or change hands.
+func (a *auction) requesterID(req *http.Request) (int, bool) {
+	if req.TLS == nil || len(req.TLS.PeerCertificates) == 0 {
+		return 0, false
+	}
+	id, ok := a.serials[req.TLS.PeerCertificates[0].SerialNumber.String()]
+	return id, ok
+}
+
+// requestIP returns the IP address req came from.
+func requestIP(req *http.Request) string {
+	ip, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return ip
+}
@@ -0,0 +1,51 @@
+/*
+ * This file runs a second price (Vickrey) auction among multiple bidders
+ * for a single item: the highest bidder wins, and pays the second highest
+ * bid. It is the (M+1)st price auction of package mplus1 for M = 1.
+ *
+ * To invoke, run:
+ *          go run *.go -bid=<BID VALUE>
+ */
+
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/ashwinsr/auctions/lib"
+	"github.com/ashwinsr/auctions/mplus1"
+	"golang.org/x/net/context"
+)
+
+var (
+	bid = flag.String("bid", "0", "Amount of money, one of the prices of the hosts file")
+)
+
+func main() {
+	flag.Parse()
+
+	config := lib.GetAuctionConfig("second_price")
+
+	log.Println("My address is: ", config.Hosts[config.MyID])
+	log.Println("My ID is: ", config.MyID)
+
+	_, myState, err := lib.RunAuction(context.Background(), config, protocol(*bid, lib.NewGRPCTransport))
+	if err != nil {
+		log.Fatalf("Auction failed: %v", err)
+	}
+
+	lib.PrintResult(myState.(*mplus1.State).Result())
+}
+
+// protocol returns the second price auction of a party bidding bid,
+// talking to the others over the transports of newTransport.
+func protocol(bid string, newTransport func(config *lib.AuctionConfig) lib.Transport) lib.Protocol {
+	return lib.Protocol{
+		NewTransport: newTransport,
+		Start: func(config *lib.AuctionConfig, session *lib.Session) ([]lib.Round, interface{}, error) {
+			myState, err := mplus1.NewState(session, config, bid, 1)
+			return mplus1.Rounds(config), myState, err
+		},
+	}
+}
@@ -1,4 +1,6 @@
 go get google.golang.org/grpc
+go get software.sslmate.com/src/go-pkcs12
+go get filippo.io/nistec
 
 go get -u github.com/golang/protobuf/proto
 go get -u github.com/golang/protobuf/protoc-gen-go
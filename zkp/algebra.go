@@ -0,0 +1,179 @@
+package zkp
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Element is an element of a Group. Elements are only meaningful to the
+// Group that created them, and are never modified once created.
+type Element interface{}
+
+// Group is a cyclic group of prime order q in which the ElGamal encryption
+// and the zero-knowledge proofs are computed. Scalars (exponents) are
+// big.Ints modulo Order(). The group is written multiplicatively, even if
+// the underlying group is an elliptic curve.
+type Group interface {
+	// Name identifies the group, e.g. "modp2048" or "p256".
+	Name() string
+
+	// Order returns q, the order of the group.
+	Order() *big.Int
+
+	// Generator returns the generator g used for keys and encryption.
+	Generator() Element
+	// SecondGenerator returns Brandt's Y, a generator whose discrete
+	// logarithm with respect to Generator() is unknown.
+	SecondGenerator() Element
+	// Identity returns the neutral element 1.
+	Identity() Element
+
+	// Exp returns base^k.
+	Exp(base Element, k *big.Int) Element
+	// Mul returns a * b.
+	Mul(a, b Element) Element
+	// Inv returns a^-1.
+	Inv(a Element) Element
+	// Equal reports whether a == b.
+	Equal(a, b Element) bool
+
+	// Encode returns the canonical, fixed-length encoding of a.
+	Encode(a Element) []byte
+	// Decode parses an encoding, checking that it is a group element.
+	Decode(b []byte) (Element, error)
+}
+
+// NamedGroup returns a group by name: "p256" or any name accepted by
+// StandardGroup.
+func NamedGroup(name string) (Group, error) {
+	if name == P256Name {
+		return P256(), nil
+	}
+	return StandardGroup(name)
+}
+
+// The methods below make GroupParams the mod p implementation of Group.
+// Its elements are *big.Int in the range [1, p).
+
+func (group *GroupParams) Name() string {
+	return group.name
+}
+
+func (group *GroupParams) Order() *big.Int {
+	return group.Q
+}
+
+func (group *GroupParams) Generator() Element {
+	return group.G
+}
+
+func (group *GroupParams) SecondGenerator() Element {
+	return group.Y
+}
+
+func (group *GroupParams) Identity() Element {
+	return One
+}
+
+func (group *GroupParams) Exp(base Element, k *big.Int) Element {
+	return new(big.Int).Exp(base.(*big.Int), k, group.P)
+}
+
+func (group *GroupParams) Mul(a, b Element) Element {
+	var c big.Int
+	c.Mul(a.(*big.Int), b.(*big.Int))
+	return c.Mod(&c, group.P)
+}
+
+func (group *GroupParams) Inv(a Element) Element {
+	return new(big.Int).ModInverse(a.(*big.Int), group.P)
+}
+
+func (group *GroupParams) Equal(a, b Element) bool {
+	return a.(*big.Int).Cmp(b.(*big.Int)) == 0
+}
+
+func (group *GroupParams) Encode(a Element) []byte {
+	return a.(*big.Int).FillBytes(make([]byte, (group.P.BitLen()+7)/8))
+}
+
+func (group *GroupParams) Decode(b []byte) (Element, error) {
+	if len(b) != (group.P.BitLen()+7)/8 {
+		return nil, fmt.Errorf("encoded element has %v bytes, expected %v", len(b), (group.P.BitLen()+7)/8)
+	}
+
+	x := new(big.Int).SetBytes(b)
+	if x.Sign() <= 0 || x.Cmp(group.P) >= 0 {
+		return nil, fmt.Errorf("%v is not in the range [1, p)", x)
+	}
+
+	if !group.contains(x) {
+		return nil, fmt.Errorf("%v is not in the subgroup of order q", x)
+	}
+
+	return x, nil
+}
+
+// contains reports whether x is in G_q. For safe primes G_q is exactly the
+// quadratic residues, which is much cheaper to check than x^q == 1.
+func (group *GroupParams) contains(x *big.Int) bool {
+	var twoQPlusOne big.Int
+	twoQPlusOne.Lsh(group.Q, 1)
+	twoQPlusOne.Add(&twoQPlusOne, One)
+
+	if twoQPlusOne.Cmp(group.P) == 0 {
+		return big.Jacobi(x, group.P) == 1
+	}
+
+	var t big.Int
+	return t.Exp(x, group.Q, group.P).Cmp(One) == 0
+}
+
+// Multiply returns the product of elems, or the identity if elems is empty.
+func Multiply(group Group, elems ...Element) Element {
+	result := group.Identity()
+	for _, e := range elems {
+		result = group.Mul(result, e)
+	}
+	return result
+}
+
+// Divide returns a / b.
+func Divide(group Group, a, b Element) Element {
+	return group.Mul(a, group.Inv(b))
+}
+
+// EncodeElements encodes every element of elems.
+func EncodeElements(group Group, elems []Element) (res [][]byte) {
+	for _, e := range elems {
+		res = append(res, group.Encode(e))
+	}
+	return
+}
+
+// DecodeKey decodes a public key, which unlike other elements must not be
+// the identity: a party with the identity as its key would know the
+// secret key of no one but make the joint key equal that of the others.
+func DecodeKey(group Group, enc []byte) (Element, error) {
+	e, err := group.Decode(enc)
+	if err != nil {
+		return nil, err
+	}
+	if group.Equal(e, group.Identity()) {
+		return nil, fmt.Errorf("the key is the identity")
+	}
+	return e, nil
+}
+
+// DecodeElements decodes every encoding in encs, failing on the first
+// invalid one.
+func DecodeElements(group Group, encs [][]byte) (res []Element, err error) {
+	for i, enc := range encs {
+		e, err := group.Decode(enc)
+		if err != nil {
+			return nil, fmt.Errorf("element %v: %v", i, err)
+		}
+		res = append(res, e)
+	}
+	return
+}
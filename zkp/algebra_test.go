@@ -0,0 +1,153 @@
+package zkp
+
+import (
+	"math/big"
+	"testing"
+)
+
+func testGroups(test *testing.T) []Group {
+	var groups []Group
+	for _, name := range []string{ToyGroupName, "modp2048", P256Name} {
+		group, err := NamedGroup(name)
+		if err != nil {
+			test.Fatal(err)
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+func TestGroupArithmetic(test *testing.T) {
+	for _, group := range testGroups(test) {
+		g := group.Generator()
+		a, b := randomScalar(group), randomScalar(group)
+
+		// g^a * g^b = g^(a+b)
+		sum := new(big.Int).Add(a, b)
+		if !group.Equal(group.Mul(group.Exp(g, a), group.Exp(g, b)), group.Exp(g, sum)) {
+			test.Errorf("%v: g^a * g^b != g^(a+b)", group.Name())
+		}
+
+		// g^a * g^-a = 1
+		ga := group.Exp(g, a)
+		if !group.Equal(group.Mul(ga, group.Inv(ga)), group.Identity()) {
+			test.Errorf("%v: g^a * g^-a != 1", group.Name())
+		}
+
+		// g^q = 1
+		if !group.Equal(group.Exp(g, group.Order()), group.Identity()) {
+			test.Errorf("%v: g^q != 1", group.Name())
+		}
+
+		// Encodings round trip, including the identity
+		for _, e := range []Element{ga, group.Identity(), group.SecondGenerator()} {
+			d, err := group.Decode(group.Encode(e))
+			if err != nil {
+				test.Errorf("%v: %v", group.Name(), err)
+			} else if !group.Equal(d, e) {
+				test.Errorf("%v: decoded element differs", group.Name())
+			}
+		}
+
+		enc := group.Encode(ga)
+		if _, err := group.Decode(enc[1:]); err == nil {
+			test.Errorf("%v: decoded a truncated element", group.Name())
+		}
+
+		// Keys may be anything but the identity
+		if _, err := DecodeKey(group, enc); err != nil {
+			test.Errorf("%v: %v", group.Name(), err)
+		}
+		if _, err := DecodeKey(group, group.Encode(group.Identity())); err == nil {
+			test.Errorf("%v: decoded the identity as a key", group.Name())
+		}
+	}
+}
+
+func TestP256DecodeRejectsInvalidPoints(test *testing.T) {
+	enc := P256().Encode(P256().Generator())
+	fieldPrime, _ := new(big.Int).SetString("ffffffff00000001000000000000000000000000ffffffffffffffffffffffff", 16)
+
+	for _, b := range [][]byte{
+		append([]byte{2}, fieldPrime.Bytes()...), // x out of range
+		append([]byte{5}, enc[1:]...),            // invalid prefix
+		append([]byte{0}, enc[1:]...),            // a broken identity
+	} {
+		if _, err := P256().Decode(b); err == nil {
+			test.Errorf("Decoded the invalid point %x", b)
+		}
+	}
+}
+
+func TestModPDecodeRejectsNonMembers(test *testing.T) {
+	group, _ := StandardGroup("modp2048")
+
+	// p - 1 has order 2, so it is not in G_q
+	var x big.Int
+	x.Sub(group.P, One)
+	if _, err := group.Decode(group.Encode(&x)); err == nil {
+		test.Errorf("Decoded an element outside of G_q")
+	}
+}
+
+func TestGroupElGamal(test *testing.T) {
+	for _, group := range testGroups(test) {
+		x := randomScalar(group)
+		y := group.Exp(group.Generator(), x)
+		m := group.SecondGenerator()
+
+		alpha, beta := GroupEncryptElGamal(group, m, randomScalar(group), y)
+		if !group.Equal(GroupDecryptElGamal(group, alpha, beta, x), m) {
+			test.Errorf("%v: decryption did not return the message", group.Name())
+		}
+	}
+}
+
+func TestGroupProofs(test *testing.T) {
+	for _, group := range testGroups(test) {
+		g := group.Generator()
+		x := randomScalar(group)
+		y := group.Exp(g, x)
+
+		t, r := GroupDiscreteLogKnowledge(testTranscript(), group, x, g)
+		if err := CheckGroupDiscreteLogKnowledgeProof(testTranscript(), group, g, y, t, r); err != nil {
+			test.Errorf("%v: %v", group.Name(), err)
+		}
+		if err := CheckGroupDiscreteLogKnowledgeProof(testTranscript(), group, g, group.Mul(y, g), t, r); err == nil {
+			test.Errorf("%v: accepted a proof for the wrong key", group.Name())
+		}
+
+		bases := []Element{g, group.SecondGenerator(), y}
+		results := make([]Element, len(bases))
+		for i := range bases {
+			results[i] = group.Exp(bases[i], x)
+		}
+		ts, r := GroupDiscreteLogEquality(testTranscript(), group, x, bases)
+		if err := CheckGroupDiscreteLogEqualityProof(testTranscript(), group, bases, results, ts, r); err != nil {
+			test.Errorf("%v: %v", group.Name(), err)
+		}
+		results[1] = group.Mul(results[1], g)
+		if err := CheckGroupDiscreteLogEqualityProof(testTranscript(), group, bases, results, ts, r); err == nil {
+			test.Errorf("%v: accepted a proof for unequal logarithms", group.Name())
+		}
+
+		z := group.SecondGenerator()
+		for _, m := range []Element{group.Identity(), z} {
+			rEnc := randomScalar(group)
+			alpha, beta := GroupEncryptElGamal(group, m, rEnc, y)
+
+			a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2 := GroupEncryptedValueIsOneOfTwo(testTranscript(), group, m, y, rEnc, g, z)
+			if err := CheckGroupEncryptedValueIsOneOfTwo(testTranscript(), group, alpha, beta,
+				a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2, g, y, z); err != nil {
+				test.Errorf("%v: %v", group.Name(), err)
+			}
+
+			// The same proof must not work for an encryption of anything else
+			other := group.Mul(alpha, g)
+			if err := CheckGroupEncryptedValueIsOneOfTwo(testTranscript(), group, other, beta,
+				a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2, g, y, z); err == nil {
+				test.Errorf("%v: accepted a proof for a different ciphertext", group.Name())
+			}
+		}
+	}
+}
@@ -2,7 +2,6 @@ package zkp
 
 import (
 	"math/big"
-	"math/rand"
 )
 
 func EncryptElGamal(m *big.Int, r *big.Int, y *big.Int, p *big.Int, q *big.Int, g *big.Int) (c Ciphertext) {
@@ -41,7 +40,7 @@ func MultiplyElGamal(a Ciphertext, b Ciphertext, p *big.Int) (c Ciphertext) {
 }
 
 func makeRandPerm(n int) Permutation {
-	perm := rand.Perm(n)
+	perm := RandomPerm(n)
 	var revperm []int
 	revperm = make([]int, n)
 
@@ -51,11 +50,10 @@ func makeRandPerm(n int) Permutation {
 	return Permutation{Forward: perm, Backward: revperm}
 }
 
-func RandomlyPermute(e []Ciphertext, p big.Int, q big.Int, g big.Int, y big.Int) (
-	E []Ciphertext,
-	c []big.Int, cd big.Int, cD big.Int, ER Ciphertext,
-	f []big.Int, fd big.Int, yd big.Int, zd big.Int, F []big.Int,
-	yD big.Int, zD big.Int, Z big.Int) {
+// RandomlyPermute shuffles e under the public key y, and returns the
+// shuffle E with the proof that it is one.
+func RandomlyPermute(tr *Transcript, e []Ciphertext, p big.Int, q big.Int, g big.Int, y big.Int) (
+	E []Ciphertext, proof []ShuffleRound) {
 
 	pi := makeRandPerm(len(e))
 
@@ -63,14 +61,14 @@ func RandomlyPermute(e []Ciphertext, p big.Int, q big.Int, g big.Int, y big.Int)
 
 	for j := 0; j < len(e); j++ {
 		var r big.Int
-		r.Rand(RandGen, &q)
+		r.Set(RandomExponent(&q))
 		cOne := EncryptElGamal(One, &r, &y, &p, &q, &g)
 		c := MultiplyElGamal(e[pi.Forward[j]], cOne, &p)
 		E = append(E, c)
 		R = append(R, r)
 	}
 
-	c, cd, cD, ER, f, fd, yd, zd, F, yD, zD, Z = VerifiableSecretShuffle(e, E, y, g, *P, *Q, pi, R)
+	proof = VerifiableSecretShuffle(tr, e, E, y, g, p, q, pi, R)
 
 	return
 }
@@ -0,0 +1,203 @@
+package zkp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// GroupParams describes the prime-order subgroup G_q of Z_p^* that all of
+// the ElGamal encryptions and zero-knowledge proofs are computed in.
+// G generates G_q, and Y is a second generator of G_q whose discrete
+// logarithm with respect to G is unknown to everybody (Brandt's Y, which
+// marks the position of a bid).
+type GroupParams struct {
+	name string
+
+	P *big.Int
+	Q *big.Int
+	G *big.Int
+	Y *big.Int
+}
+
+// The primes below are the MODP groups of RFC 3526 and the FFDHE groups of
+// RFC 7919. All of them are safe primes (p = 2q + 1) for which 2 generates
+// the subgroup of order q.
+var standardPrimes = map[string]string{
+	"modp2048": "FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74020BBEA63B139B22514A08798E3404DDEF9519B3CD3A431B302B0A6DF25F14374FE1356D6D51C245E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7EDEE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3DC2007CB8A163BF0598DA48361C55D39A69163FA8FD24CF5F83655D23DCA3AD961C62F356208552BB9ED529077096966D670C354E4ABC9804F1746C08CA18217C32905E462E36CE3BE39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9DE2BCBF6955817183995497CEA956AE515D2261898FA051015728E5A8AACAA68FFFFFFFFFFFFFFFF",
+	"modp3072": "FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74020BBEA63B139B22514A08798E3404DDEF9519B3CD3A431B302B0A6DF25F14374FE1356D6D51C245E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7EDEE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3DC2007CB8A163BF0598DA48361C55D39A69163FA8FD24CF5F83655D23DCA3AD961C62F356208552BB9ED529077096966D670C354E4ABC9804F1746C08CA18217C32905E462E36CE3BE39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9DE2BCBF6955817183995497CEA956AE515D2261898FA051015728E5A8AAAC42DAD33170D04507A33A85521ABDF1CBA64ECFB850458DBEF0A8AEA71575D060C7DB3970F85A6E1E4C7ABF5AE8CDB0933D71E8C94E04A25619DCEE3D2261AD2EE6BF12FFA06D98A0864D87602733EC86A64521F2B18177B200CBBE117577A615D6C770988C0BAD946E208E24FA074E5AB3143DB5BFCE0FD108E4B82D120A93AD2CAFFFFFFFFFFFFFFFF",
+	"modp4096": "FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74020BBEA63B139B22514A08798E3404DDEF9519B3CD3A431B302B0A6DF25F14374FE1356D6D51C245E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7EDEE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3DC2007CB8A163BF0598DA48361C55D39A69163FA8FD24CF5F83655D23DCA3AD961C62F356208552BB9ED529077096966D670C354E4ABC9804F1746C08CA18217C32905E462E36CE3BE39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9DE2BCBF6955817183995497CEA956AE515D2261898FA051015728E5A8AAAC42DAD33170D04507A33A85521ABDF1CBA64ECFB850458DBEF0A8AEA71575D060C7DB3970F85A6E1E4C7ABF5AE8CDB0933D71E8C94E04A25619DCEE3D2261AD2EE6BF12FFA06D98A0864D87602733EC86A64521F2B18177B200CBBE117577A615D6C770988C0BAD946E208E24FA074E5AB3143DB5BFCE0FD108E4B82D120A92108011A723C12A787E6D788719A10BDBA5B2699C327186AF4E23C1A946834B6150BDA2583E9CA2AD44CE8DBBBC2DB04DE8EF92E8EFC141FBECAA6287C59474E6BC05D99B2964FA090C3A2233BA186515BE7ED1F612970CEE2D7AFB81BDD762170481CD0069127D5B05AA993B4EA988D8FDDC186FFB7DC90A6C08F4DF435C934063199FFFFFFFFFFFFFFFF",
+
+	"ffdhe2048": "FFFFFFFFFFFFFFFFADF85458A2BB4A9AAFDC5620273D3CF1D8B9C583CE2D3695A9E13641146433FBCC939DCE249B3EF97D2FE363630C75D8F681B202AEC4617AD3DF1ED5D5FD65612433F51F5F066ED0856365553DED1AF3B557135E7F57C935984F0C70E0E68B77E2A689DAF3EFE8721DF158A136ADE73530ACCA4F483A797ABC0AB182B324FB61D108A94BB2C8E3FBB96ADAB760D7F4681D4F42A3DE394DF4AE56EDE76372BB190B07A7C8EE0A6D709E02FCE1CDF7E2ECC03404CD28342F619172FE9CE98583FF8E4F1232EEF28183C3FE3B1B4C6FAD733BB5FCBC2EC22005C58EF1837D1683B2C6F34A26C1B2EFFA886B423861285C97FFFFFFFFFFFFFFFF",
+	"ffdhe3072": "FFFFFFFFFFFFFFFFADF85458A2BB4A9AAFDC5620273D3CF1D8B9C583CE2D3695A9E13641146433FBCC939DCE249B3EF97D2FE363630C75D8F681B202AEC4617AD3DF1ED5D5FD65612433F51F5F066ED0856365553DED1AF3B557135E7F57C935984F0C70E0E68B77E2A689DAF3EFE8721DF158A136ADE73530ACCA4F483A797ABC0AB182B324FB61D108A94BB2C8E3FBB96ADAB760D7F4681D4F42A3DE394DF4AE56EDE76372BB190B07A7C8EE0A6D709E02FCE1CDF7E2ECC03404CD28342F619172FE9CE98583FF8E4F1232EEF28183C3FE3B1B4C6FAD733BB5FCBC2EC22005C58EF1837D1683B2C6F34A26C1B2EFFA886B4238611FCFDCDE355B3B6519035BBC34F4DEF99C023861B46FC9D6E6C9077AD91D2691F7F7EE598CB0FAC186D91CAEFE130985139270B4130C93BC437944F4FD4452E2D74DD364F2E21E71F54BFF5CAE82AB9C9DF69EE86D2BC522363A0DABC521979B0DEADA1DBF9A42D5C4484E0ABCD06BFA53DDEF3C1B20EE3FD59D7C25E41D2B66C62E37FFFFFFFFFFFFFFFF",
+	"ffdhe4096": "FFFFFFFFFFFFFFFFADF85458A2BB4A9AAFDC5620273D3CF1D8B9C583CE2D3695A9E13641146433FBCC939DCE249B3EF97D2FE363630C75D8F681B202AEC4617AD3DF1ED5D5FD65612433F51F5F066ED0856365553DED1AF3B557135E7F57C935984F0C70E0E68B77E2A689DAF3EFE8721DF158A136ADE73530ACCA4F483A797ABC0AB182B324FB61D108A94BB2C8E3FBB96ADAB760D7F4681D4F42A3DE394DF4AE56EDE76372BB190B07A7C8EE0A6D709E02FCE1CDF7E2ECC03404CD28342F619172FE9CE98583FF8E4F1232EEF28183C3FE3B1B4C6FAD733BB5FCBC2EC22005C58EF1837D1683B2C6F34A26C1B2EFFA886B4238611FCFDCDE355B3B6519035BBC34F4DEF99C023861B46FC9D6E6C9077AD91D2691F7F7EE598CB0FAC186D91CAEFE130985139270B4130C93BC437944F4FD4452E2D74DD364F2E21E71F54BFF5CAE82AB9C9DF69EE86D2BC522363A0DABC521979B0DEADA1DBF9A42D5C4484E0ABCD06BFA53DDEF3C1B20EE3FD59D7C25E41D2B669E1EF16E6F52C3164DF4FB7930E9E4E58857B6AC7D5F42D69F6D187763CF1D5503400487F55BA57E31CC7A7135C886EFB4318AED6A1E012D9E6832A907600A918130C46DC778F971AD0038092999A333CB8B7A1A1DB93D7140003C2A4ECEA9F98D0ACC0A8291CDCEC97DCF8EC9B55A7F88A46B4DB5A851F44182E1C68A007E5E655F6AFFFFFFFFFFFFFFFF",
+}
+
+// DefaultGroupName is the group used when an auction does not specify one.
+const DefaultGroupName = "modp2048"
+
+// ToyGroupName names the small group in constants.go. It is only fit for
+// tests and debugging, since its discrete logarithms can be brute-forced.
+const ToyGroupName = "toy"
+
+// StandardGroup returns the named group: one of modp2048, modp3072,
+// modp4096 (RFC 3526), ffdhe2048, ffdhe3072, ffdhe4096 (RFC 7919) or toy.
+func StandardGroup(name string) (*GroupParams, error) {
+	name = strings.ToLower(name)
+
+	if name == ToyGroupName {
+		return &GroupParams{
+			name: ToyGroupName,
+			P:    P,
+			Q:    Q,
+			G:    G,
+			Y:    DeriveGenerator(P, Q, []byte("auctions/Y/toy")),
+		}, nil
+	}
+
+	hex, ok := standardPrimes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown group %q", name)
+	}
+
+	p, _ := new(big.Int).SetString(hex, 16)
+	q := new(big.Int).Rsh(p, 1) // q = (p - 1) / 2
+
+	return &GroupParams{
+		name: name,
+		P:    p,
+		Q:    q,
+		G:    big.NewInt(2),
+		Y:    DeriveGenerator(p, q, []byte("auctions/Y/"+name)),
+	}, nil
+}
+
+// GenerateGroup generates a fresh safe prime p = 2q + 1 of the given bit
+// length together with two generators of the subgroup of order q. This is
+// slow for the bit lengths that are actually secure (2048 and up), so
+// prefer StandardGroup unless a fresh group is needed.
+func GenerateGroup(bits int) (*GroupParams, error) {
+	if bits < 16 {
+		return nil, fmt.Errorf("group of %v bits is too small", bits)
+	}
+
+	var p big.Int
+	for {
+		q, err := rand.Prime(rand.Reader, bits-1)
+		if err != nil {
+			return nil, err
+		}
+
+		p.Lsh(q, 1)
+		p.Add(&p, One) // p = 2q + 1
+
+		if p.BitLen() == bits && p.ProbablyPrime(20) {
+			seed := p.Bytes()
+			return &GroupParams{
+				name: fmt.Sprintf("generated%v", bits),
+				P:    new(big.Int).Set(&p),
+				Q:    q,
+				G:    DeriveGenerator(&p, q, append([]byte("auctions/G/"), seed...)),
+				Y:    DeriveGenerator(&p, q, append([]byte("auctions/Y/"), seed...)),
+			}, nil
+		}
+	}
+}
+
+// DeriveGenerator deterministically hashes seed into a generator of the
+// subgroup of order q of Z_p^*. Since the result is a hash output, nobody
+// knows its discrete logarithm with respect to any other generator.
+func DeriveGenerator(p *big.Int, q *big.Int, seed []byte) *big.Int {
+	var h, j, g, pMinusOne big.Int
+
+	pMinusOne.Sub(p, One)
+	j.Div(&pMinusOne, q)
+
+	// Expand the seed to len(p) + 128 bits so that reducing mod p is
+	// close to uniform.
+	numBlocks := (p.BitLen()+128)/256 + 1
+
+	for counter := uint32(0); ; counter++ {
+		var buf []byte
+		for block := 0; block < numBlocks; block++ {
+			var ctr [8]byte
+			binary.BigEndian.PutUint32(ctr[:4], counter)
+			binary.BigEndian.PutUint32(ctr[4:], uint32(block))
+
+			sum := sha256.Sum256(append(append([]byte{}, seed...), ctr[:]...))
+			buf = append(buf, sum[:]...)
+		}
+
+		h.SetBytes(buf)
+		h.Mod(&h, p)
+
+		g.Exp(&h, &j, p) // g = h^((p-1)/q) mod p
+		if g.Cmp(One) > 0 {
+			return new(big.Int).Set(&g)
+		}
+	}
+}
+
+// NewGroupParams returns the group described by explicitly given
+// parameters, after checking that they are valid.
+func NewGroupParams(p, q, g, y *big.Int) (*GroupParams, error) {
+	group := &GroupParams{name: "custom", P: p, Q: q, G: g, Y: y}
+	if err := group.Validate(); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// Validate checks that p and q are prime, that q divides p - 1 and that G
+// and Y both generate the subgroup of order q.
+func (group *GroupParams) Validate() error {
+	if group == nil || group.P == nil || group.Q == nil || group.G == nil || group.Y == nil {
+		return fmt.Errorf("group parameters are incomplete")
+	}
+
+	if !group.P.ProbablyPrime(20) {
+		return fmt.Errorf("p is not prime")
+	}
+
+	if !group.Q.ProbablyPrime(20) {
+		return fmt.Errorf("q is not prime")
+	}
+
+	var pMinusOne, rem big.Int
+	pMinusOne.Sub(group.P, One)
+	if rem.Mod(&pMinusOne, group.Q); rem.Sign() != 0 {
+		return fmt.Errorf("q does not divide p - 1")
+	}
+
+	if err := group.checkGenerator(group.G); err != nil {
+		return fmt.Errorf("g: %v", err)
+	}
+
+	if err := group.checkGenerator(group.Y); err != nil {
+		return fmt.Errorf("y: %v", err)
+	}
+
+	if group.G.Cmp(group.Y) == 0 {
+		return fmt.Errorf("g and y must be different generators")
+	}
+
+	return nil
+}
+
+// checkGenerator checks that x is in G_q and is not the identity, which
+// means that it generates G_q because q is prime.
+func (group *GroupParams) checkGenerator(x *big.Int) error {
+	if x.Cmp(One) <= 0 || x.Cmp(group.P) >= 0 {
+		return fmt.Errorf("%v is not in the range (1, p)", x)
+	}
+
+	var t big.Int
+	if t.Exp(x, group.Q, group.P); t.Cmp(One) != 0 {
+		return fmt.Errorf("%v does not have order q", x)
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package zkp
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestStandardGroups(test *testing.T) {
+	for _, name := range []string{
+		"modp2048", "modp3072", "modp4096",
+		"ffdhe2048", "ffdhe3072", "ffdhe4096",
+		ToyGroupName,
+	} {
+		group, err := StandardGroup(name)
+		if err != nil {
+			test.Fatalf("%v: %v", name, err)
+		}
+
+		if err := group.Validate(); err != nil {
+			test.Errorf("%v: %v", name, err)
+		}
+	}
+
+	if _, err := StandardGroup("modp1024"); err == nil {
+		test.Errorf("Expected an error for an unknown group")
+	}
+}
+
+func TestGenerateGroup(test *testing.T) {
+	group, err := GenerateGroup(256)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if group.P.BitLen() != 256 {
+		test.Errorf("Generated a %v-bit prime, expected 256 bits", group.P.BitLen())
+	}
+
+	var q big.Int
+	q.Rsh(group.P, 1)
+	if q.Cmp(group.Q) != 0 {
+		test.Errorf("p is not a safe prime")
+	}
+
+	if err := group.Validate(); err != nil {
+		test.Error(err)
+	}
+}
+
+func TestValidateRejectsBadGroups(test *testing.T) {
+	good, _ := StandardGroup(ToyGroupName)
+
+	withP := *good
+	withP.P = big.NewInt(34531111) // not prime
+	withQ := *good
+	withQ.Q = big.NewInt(8632771) // does not divide p - 1
+	withG := *good
+	withG.G = big.NewInt(3) // not in G_q
+	withY := *good
+	withY.Y = new(big.Int).Set(good.G)
+
+	for name, group := range map[string]*GroupParams{
+		"p": &withP, "q": &withQ, "g": &withG, "y": &withY,
+	} {
+		if err := group.Validate(); err == nil {
+			test.Errorf("Expected bad %v to be rejected", name)
+		}
+	}
+}
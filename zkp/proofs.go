@@ -0,0 +1,263 @@
+package zkp
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+)
+
+/*
+ * These are the proofs of zkp.go written against the Group interface, so
+ * that they work in any group (mod p or elliptic curve). Variable names
+ * follow the same Wikipedia article and papers as zkp.go.
+ *
+ * Every challenge is computed from a Transcript, which the caller binds to
+ * the auction, round and prover, and to which the proof adds its kind, its
+ * statement and its commitments.
+ */
+
+// statement is a labelled list of elements that goes into a challenge.
+type statement struct {
+	label string
+	elems []Element
+}
+
+// computeC computes the Fiat–Shamir challenge of a proof of the given kind.
+func computeC(tr *Transcript, group Group, kind string, stmts ...statement) *big.Int {
+	t := tr.Clone()
+	t.AppendString("proof", kind)
+
+	for _, stmt := range stmts {
+		t.AppendElements(group, stmt.label, stmt.elems...)
+	}
+
+	return t.Challenge("c", group.Order())
+}
+
+// randomScalar returns a random exponent in [1, q).
+func randomScalar(group Group) *big.Int {
+	return RandomExponent(group.Order())
+}
+
+// GroupEncryptElGamal encrypts m under the public key y with randomness r
+// and returns the ciphertext (alpha, beta) = (m*y^r, g^r).
+func GroupEncryptElGamal(group Group, m Element, r *big.Int, y Element) (alpha Element, beta Element) {
+	alpha = group.Mul(m, group.Exp(y, r))
+	beta = group.Exp(group.Generator(), r)
+	return
+}
+
+// GroupDecryptElGamal decrypts (alpha, beta) with the private key x.
+func GroupDecryptElGamal(group Group, alpha Element, beta Element, x *big.Int) Element {
+	return Divide(group, alpha, group.Exp(beta, x))
+}
+
+// GroupDiscreteLogKnowledge generates a ZKP of the knowledge of x = log_g(y)
+// and returns the proof pair (t, r).
+func GroupDiscreteLogKnowledge(tr *Transcript, group Group, x *big.Int, g Element) (t Element, r *big.Int) {
+	q := group.Order()
+
+	// Calculate public key from private key
+	y := group.Exp(g, x) // y = g^x
+
+	// Compute t
+	v := randomScalar(group) // v = rand() mod q
+	t = group.Exp(g, v)      // t = g^v
+
+	// Compute c = H(transcript, g, y, t) mod q
+	c := computeC(tr, group, "dlog", statement{"g", []Element{g}},
+		statement{"y", []Element{y}}, statement{"t", []Element{t}})
+
+	// Calculate r = v - cx mod q
+	r = new(big.Int).Mul(c, x)
+	r.Sub(v, r)
+	r.Mod(r, q)
+
+	return
+}
+
+// CheckGroupDiscreteLogKnowledgeProof checks a proof (t, r) of the
+// knowledge of log_g(y).
+func CheckGroupDiscreteLogKnowledgeProof(tr *Transcript, group Group, g Element, y Element, t Element, r *big.Int) error {
+	c := computeC(tr, group, "dlog", statement{"g", []Element{g}},
+		statement{"y", []Element{y}}, statement{"t", []Element{t}})
+
+	// Compute tv = g^r * y^c
+	tv := group.Mul(group.Exp(g, r), group.Exp(y, c))
+
+	// Check equality of t's
+	if !group.Equal(t, tv) {
+		return fmt.Errorf("WRONG! Calculated %x, received %x.", group.Encode(tv), group.Encode(t))
+	}
+
+	return nil
+}
+
+// GroupDiscreteLogEquality generates a ZKP of the fact that the discrete
+// logarithms of k values to the k bases g are all equal to x, and returns
+// the proof tuple (t[], r).
+func GroupDiscreteLogEquality(tr *Transcript, group Group, x *big.Int, g []Element) (t []Element, r *big.Int) {
+	if len(g) < 2 {
+		log.Fatalf("Passed not enough bases to GroupDiscreteLogEquality!\n")
+	}
+
+	q := group.Order()
+
+	// Compute t
+	v := randomScalar(group)
+	t = make([]Element, len(g))
+	Y := make([]Element, len(g))
+
+	for i := 0; i < len(g); i++ {
+		t[i] = group.Exp(g[i], v) // t[i] = g[i]^v
+		Y[i] = group.Exp(g[i], x) // Y[i] = g[i]^x
+	}
+
+	// Compute c = H(transcript, g[], Y[], t[])
+	c := computeC(tr, group, "dleq", statement{"g", g}, statement{"Y", Y}, statement{"t", t})
+
+	// Calculate r = v - cx mod q
+	r = new(big.Int).Mul(c, x)
+	r.Sub(v, r)
+	r.Mod(r, q)
+
+	return
+}
+
+// CheckGroupDiscreteLogEqualityProof checks a proof (t[], r) that
+// log_G[i](Y[i]) is the same for all i.
+func CheckGroupDiscreteLogEqualityProof(tr *Transcript, group Group, G []Element, Y []Element, t []Element, r *big.Int) (err error) {
+	if len(G) < 2 {
+		return fmt.Errorf("Passed not enough bases to CheckGroupDiscreteLogEqualityProof!")
+	}
+
+	if len(Y) != len(G) || len(t) != len(G) {
+		return fmt.Errorf("Proof has %v results and %v commitments for %v bases", len(Y), len(t), len(G))
+	}
+
+	c := computeC(tr, group, "dleq", statement{"g", G}, statement{"Y", Y}, statement{"t", t})
+
+	for i := 0; i < len(G); i++ {
+		// Compute tv = g^r * y^c
+		tv := group.Mul(group.Exp(G[i], r), group.Exp(Y[i], c))
+
+		if !group.Equal(t[i], tv) {
+			// Record all the errors, not just the first or last (for testing purposes)
+			err2 := fmt.Errorf("WRONG! Calculated %x, received %x.", group.Encode(tv), group.Encode(t[i]))
+			if err != nil {
+				err = fmt.Errorf("%v\n%v", err, err2)
+			} else {
+				err = err2
+			}
+		}
+	}
+
+	return
+}
+
+// GroupEncryptedValueIsOneOfTwo generates a zero knowledge proof that
+// guarantees that an El-gamal encrypted value (alpha, beta) = (m*y^r, g^r)
+// decrypts to either 1 or z, where m is one of those two.
+func GroupEncryptedValueIsOneOfTwo(tr *Transcript, group Group, m Element, y Element, r *big.Int, g Element, z Element) (
+	a_1, a_2, b_1, b_2 Element, d_1, d_2, r_1, r_2 *big.Int) {
+	q := group.Order()
+
+	// Compute alpha = m*y^r and beta = g^r
+	alpha, beta := group.Mul(m, group.Exp(y, r)), group.Exp(g, r)
+
+	r_1 = randomScalar(group)
+	r_2 = randomScalar(group)
+	d_1 = randomScalar(group)
+	d_2 = randomScalar(group)
+	w := randomScalar(group)
+
+	isOne := group.Equal(m, group.Identity())
+
+	if isOne {
+		// a_1 = g^r_1*beta^d_1, a_2 = g^w
+		a_1 = group.Mul(group.Exp(g, r_1), group.Exp(beta, d_1))
+		a_2 = group.Exp(g, w)
+
+		// b_1 = y^r_1*(alpha/z)^d_1, b_2 = y^w
+		b_1 = group.Mul(group.Exp(y, r_1), group.Exp(Divide(group, alpha, z), d_1))
+		b_2 = group.Exp(y, w)
+	} else {
+		// a_1 = g^w, a_2 = g^r_2*beta^d_2
+		a_1 = group.Exp(g, w)
+		a_2 = group.Mul(group.Exp(g, r_2), group.Exp(beta, d_2))
+
+		// b_1 = y^w, b_2 = y^r_2*alpha^d_2
+		b_1 = group.Exp(y, w)
+		b_2 = group.Mul(group.Exp(y, r_2), group.Exp(alpha, d_2))
+	}
+
+	// Compute c = H(transcript, g, y, z, alpha, beta, a_1, a_2, b_1, b_2) mod q
+	c := computeC(tr, group, "oneoftwo",
+		statement{"bases", []Element{g, y, z}},
+		statement{"ciphertext", []Element{alpha, beta}},
+		statement{"commitments", []Element{a_1, a_2, b_1, b_2}})
+
+	if isOne {
+		// d_2 = c - d1 mod q, r_2 = w - r*d_2 mod q
+		d_2.Sub(c, d_1)
+		d_2.Mod(d_2, q)
+		r_2.Mul(r, d_2)
+		r_2.Sub(w, r_2)
+		r_2.Mod(r_2, q)
+	} else {
+		// d_1 = c - d_2 mod q, r_1 = w - r*d_1 mod q
+		d_1.Sub(c, d_2)
+		d_1.Mod(d_1, q)
+		r_1.Mul(r, d_1)
+		r_1.Sub(w, r_1)
+		r_1.Mod(r_1, q)
+	}
+
+	return
+}
+
+// CheckGroupEncryptedValueIsOneOfTwo checks a proof that (alpha, beta)
+// decrypts to either 1 or z.
+func CheckGroupEncryptedValueIsOneOfTwo(tr *Transcript, group Group, alpha Element, beta Element,
+	a_1, a_2, b_1, b_2 Element,
+	d_1, d_2, r_1, r_2 *big.Int,
+	g Element, y Element, z Element) (err error) {
+	q := group.Order()
+
+	// Compute c = H(transcript, g, y, z, alpha, beta, a_1, a_2, b_1, b_2) mod q
+	c := computeC(tr, group, "oneoftwo",
+		statement{"bases", []Element{g, y, z}},
+		statement{"ciphertext", []Element{alpha, beta}},
+		statement{"commitments", []Element{a_1, a_2, b_1, b_2}})
+
+	// Check c = d_1 + d_2 mod q
+	var sum big.Int
+	sum.Add(d_1, d_2)
+	sum.Mod(&sum, q)
+	if sum.Cmp(c) != 0 {
+		err = fmt.Errorf("1 - WRONG! Calculated %v, received %v.", &sum, c)
+	}
+
+	checks := []struct {
+		calculated Element
+		received   Element
+	}{
+		// a_1 = g^r_1 * beta^d_1
+		{group.Mul(group.Exp(g, r_1), group.Exp(beta, d_1)), a_1},
+		// a_2 = g^r_2 * beta^d_2
+		{group.Mul(group.Exp(g, r_2), group.Exp(beta, d_2)), a_2},
+		// b_1 = y^r_1 * (alpha/z)^d_1
+		{group.Mul(group.Exp(y, r_1), group.Exp(Divide(group, alpha, z), d_1)), b_1},
+		// b_2 = y^r_2 * alpha^d_2
+		{group.Mul(group.Exp(y, r_2), group.Exp(alpha, d_2)), b_2},
+	}
+
+	for i, check := range checks {
+		if !group.Equal(check.calculated, check.received) {
+			err = fmt.Errorf("%v - WRONG! Calculated %x, received %x.", i+2,
+				group.Encode(check.calculated), group.Encode(check.received))
+		}
+	}
+
+	return
+}
@@ -0,0 +1,88 @@
+package zkp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"log"
+	"math/big"
+	"sync"
+)
+
+// RandomSource is where every private key, encryption nonce, proof
+// commitment and permutation of the package comes from. It is crypto/rand
+// by default; tests may replace it with NewSeededSource to get
+// reproducible transcripts.
+var RandomSource io.Reader = rand.Reader
+
+// RandomInt returns a uniformly random integer in [0, n).
+func RandomInt(n *big.Int) *big.Int {
+	r, err := rand.Int(RandomSource, n)
+	if err != nil {
+		log.Fatalf("Failed to read randomness: %v\n", err)
+	}
+	return r
+}
+
+// RandomExponent returns a uniformly random exponent in [1, q).
+func RandomExponent(q *big.Int) *big.Int {
+	r := RandomInt(new(big.Int).Sub(q, One))
+	return r.Add(r, One)
+}
+
+// RandomPerm returns a uniformly random permutation of [0, n), using a
+// Fisher-Yates shuffle.
+func RandomPerm(n int) []int {
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+
+	for i := n - 1; i > 0; i-- {
+		j := int(RandomInt(big.NewInt(int64(i + 1))).Int64())
+		perm[i], perm[j] = perm[j], perm[i]
+	}
+
+	return perm
+}
+
+// seededSource is a deterministic stream of bytes, SHA256(seed || counter)
+// for counter = 0, 1, 2, ... It may be read concurrently, as by the parties
+// of a test running in goroutines, though which of them reads which bytes
+// then depends on how they are scheduled.
+type seededSource struct {
+	lock    sync.Mutex
+	seed    [sha256.Size]byte
+	counter uint64
+	buf     []byte
+}
+
+// NewSeededSource returns a deterministic randomness source that yields the
+// same bytes for the same seed. It must only be used in tests: anybody who
+// knows the seed knows every secret derived from it.
+func NewSeededSource(seed []byte) io.Reader {
+	return &seededSource{seed: sha256.Sum256(seed)}
+}
+
+func (s *seededSource) Read(p []byte) (n int, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for n < len(p) {
+		if len(s.buf) == 0 {
+			var block [sha256.Size + 8]byte
+			copy(block[:], s.seed[:])
+			binary.BigEndian.PutUint64(block[sha256.Size:], s.counter)
+			s.counter++
+
+			sum := sha256.Sum256(block[:])
+			s.buf = sum[:]
+		}
+
+		c := copy(p[n:], s.buf)
+		s.buf = s.buf[c:]
+		n += c
+	}
+	return
+}
@@ -0,0 +1,108 @@
+package zkp
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+	"sync"
+	"testing"
+)
+
+// withSeed runs f with RandomSource replaced by a seeded source.
+func withSeed(seed string, f func()) {
+	old := RandomSource
+	RandomSource = NewSeededSource([]byte(seed))
+	defer func() { RandomSource = old }()
+	f()
+}
+
+func TestSeededSourceIsDeterministic(test *testing.T) {
+	a, b := make([]byte, 100), make([]byte, 100)
+	io.ReadFull(NewSeededSource([]byte("seed")), a)
+	io.ReadFull(NewSeededSource([]byte("seed")), b)
+	if !bytes.Equal(a, b) {
+		test.Errorf("Same seed gave different bytes")
+	}
+
+	io.ReadFull(NewSeededSource([]byte("other seed")), b)
+	if bytes.Equal(a, b) {
+		test.Errorf("Different seeds gave the same bytes")
+	}
+
+	// Reproducible key generation and proofs
+	var x1, x2 *big.Int
+	var r1, r2 *big.Int
+	withSeed("keys", func() {
+		x1 = RandomExponent(Q)
+		_, r1 = GroupDiscreteLogKnowledge(testTranscript(), modP(P, Q), x1, G)
+	})
+	withSeed("keys", func() {
+		x2 = RandomExponent(Q)
+		_, r2 = GroupDiscreteLogKnowledge(testTranscript(), modP(P, Q), x2, G)
+	})
+	if x1.Cmp(x2) != 0 || r1.Cmp(r2) != 0 {
+		test.Errorf("Seeded runs differ: x %v/%v, r %v/%v", x1, x2, r1, r2)
+	}
+}
+
+func TestSeededSourceConcurrentReads(test *testing.T) {
+	const readers, reads = 8, 50
+
+	// Read concurrently, every read gets blocks of the stream no other read
+	// gets
+	source := NewSeededSource([]byte("seed"))
+	got := make(map[string]bool)
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < reads; j++ {
+				block := make([]byte, 32)
+				io.ReadFull(source, block)
+				lock.Lock()
+				got[string(block)] = true
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	stream := NewSeededSource([]byte("seed"))
+	for i := 0; i < readers*reads; i++ {
+		block := make([]byte, 32)
+		io.ReadFull(stream, block)
+		if !got[string(block)] {
+			test.Fatalf("Block %v of the stream was not read, or read twice", i)
+		}
+	}
+}
+
+func TestRandomExponentRange(test *testing.T) {
+	q := big.NewInt(3)
+	seen := make(map[int64]bool)
+	for i := 0; i < 100; i++ {
+		x := RandomExponent(q)
+		if x.Cmp(One) < 0 || x.Cmp(q) >= 0 {
+			test.Fatalf("%v is not in [1, %v)", x, q)
+		}
+		seen[x.Int64()] = true
+	}
+	if len(seen) != 2 {
+		test.Errorf("Expected both 1 and 2, saw %v", seen)
+	}
+}
+
+func TestRandomPerm(test *testing.T) {
+	for n := 0; n < 10; n++ {
+		perm := RandomPerm(n)
+		seen := make([]bool, n)
+		for _, i := range perm {
+			if i < 0 || i >= n || seen[i] {
+				test.Fatalf("%v is not a permutation", perm)
+			}
+			seen[i] = true
+		}
+	}
+}
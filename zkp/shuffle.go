@@ -0,0 +1,162 @@
+package zkp
+
+import (
+	"fmt"
+	"math/big"
+)
+
+/*
+ * The proof of a verifiable secret shuffle is a cut-and-choose proof. In
+ * each of its rounds the prover shuffles the input e into an intermediate
+ * list, and then opens either the shuffle of e into that list or the shuffle
+ * of that list into the output E, as a bit of the challenge asks. A prover
+ * who cannot open both, because E is not a shuffle of e, is caught in every
+ * round with probability 1/2. The intermediate shuffles are fresh, so that
+ * neither opening tells anything about the permutation of e into E.
+ */
+
+// ShuffleRounds is the number of rounds of a shuffle proof. A prover whose
+// output is not a shuffle of its input gets through with probability
+// 2^-ShuffleRounds.
+const ShuffleRounds = 128
+
+// ShuffleRound is a round of a shuffle proof: the intermediate shuffle
+// Mixed, and the permutation and nonces that re-encrypt e into Mixed, if the
+// challenge bit of the round is 0, or Mixed into E, if it is 1.
+type ShuffleRound struct {
+	Mixed       []Ciphertext
+	Permutation []int
+	Randomness  []big.Int
+}
+
+// reencrypt returns the ciphertexts e[perm[j]] * E(1; r[j]).
+func reencrypt(e []Ciphertext, perm []int, r []big.Int, y big.Int, g big.Int, p big.Int, q big.Int) []Ciphertext {
+	res := make([]Ciphertext, len(e))
+	for j := range res {
+		res[j] = MultiplyElGamal(e[perm[j]], EncryptElGamal(One, &r[j], &y, &p, &q, &g), &p)
+	}
+	return res
+}
+
+// appendCiphertexts appends labelled ciphertexts to the transcript.
+func appendCiphertexts(t *Transcript, label string, c []Ciphertext) {
+	t.AppendInt(label, int64(len(c)))
+	for i := range c {
+		t.AppendBigInt("alpha", &c[i].Alpha)
+		t.AppendBigInt("beta", &c[i].Beta)
+	}
+}
+
+// shuffleChallenge computes the Fiat–Shamir challenge of a shuffle proof,
+// whose bit k is the challenge bit of round k.
+func shuffleChallenge(tr *Transcript, e []Ciphertext, E []Ciphertext, y big.Int, g big.Int, rounds []ShuffleRound) *big.Int {
+	t := tr.Clone()
+	t.AppendString("proof", "shuffle")
+	t.AppendBigInt("g", &g)
+	t.AppendBigInt("y", &y)
+	appendCiphertexts(t, "e", e)
+	appendCiphertexts(t, "E", E)
+	for _, round := range rounds {
+		appendCiphertexts(t, "mixed", round.Mixed)
+	}
+
+	return t.Challenge("c", new(big.Int).Lsh(One, ShuffleRounds))
+}
+
+// isPermutation reports whether perm is a permutation of [0, len(perm)).
+func isPermutation(perm []int) bool {
+	seen := make([]bool, len(perm))
+	for _, i := range perm {
+		if i < 0 || i >= len(perm) || seen[i] {
+			return false
+		}
+		seen[i] = true
+	}
+	return true
+}
+
+// VerifiableSecretShuffle generates a ZKP of the fact that E is a shuffle
+// of e under the public key y, i.e. that E[j] = e[pi.Forward[j]] * E(1; R[j]),
+// and returns the rounds of the proof.
+func VerifiableSecretShuffle(tr *Transcript, e []Ciphertext, E []Ciphertext,
+	y big.Int, g big.Int, p big.Int, q big.Int,
+	pi Permutation, R []big.Int) (rounds []ShuffleRound) {
+	n := len(e)
+
+	// Shuffle e into an intermediate list for every round
+	sigma := make([]Permutation, ShuffleRounds)
+	S := make([][]big.Int, ShuffleRounds)
+	rounds = make([]ShuffleRound, ShuffleRounds)
+	for k := range rounds {
+		sigma[k] = makeRandPerm(n)
+		S[k] = make([]big.Int, n)
+		for j := range S[k] {
+			S[k][j].Set(RandomExponent(&q))
+		}
+		rounds[k].Mixed = reencrypt(e, sigma[k].Forward, S[k], y, g, p, q)
+	}
+
+	c := shuffleChallenge(tr, e, E, y, g, rounds)
+
+	for k := range rounds {
+		if c.Bit(k) == 0 {
+			rounds[k].Permutation = sigma[k].Forward
+			rounds[k].Randomness = S[k]
+			continue
+		}
+
+		// E[j] = Mixed[tau[j]] * E(1; T[j]), where tau[j] = sigma^-1(pi(j))
+		// and T[j] = R[j] - S[tau[j]] mod q
+		tau := make([]int, n)
+		T := make([]big.Int, n)
+		for j := 0; j < n; j++ {
+			tau[j] = sigma[k].Backward[pi.Forward[j]]
+			T[j].Sub(&R[j], &S[k][tau[j]])
+			T[j].Mod(&T[j], &q)
+		}
+		rounds[k].Permutation = tau
+		rounds[k].Randomness = T
+	}
+
+	return
+}
+
+// CheckVerifiableSecretShuffle checks a proof that E is a shuffle of e
+// under the public key y.
+func CheckVerifiableSecretShuffle(tr *Transcript, e []Ciphertext, E []Ciphertext,
+	p big.Int, q big.Int, g big.Int, y big.Int, rounds []ShuffleRound) error {
+	n := len(e)
+	if len(E) != n {
+		return fmt.Errorf("Shuffled %v ciphertexts into %v", n, len(E))
+	}
+	if len(rounds) != ShuffleRounds {
+		return fmt.Errorf("Proof has %v rounds, want %v", len(rounds), ShuffleRounds)
+	}
+	for k, round := range rounds {
+		if len(round.Mixed) != n || len(round.Permutation) != n || len(round.Randomness) != n {
+			return fmt.Errorf("Round %v of the proof does not shuffle %v ciphertexts", k, n)
+		}
+		if !isPermutation(round.Permutation) {
+			return fmt.Errorf("Round %v of the proof opens %v, which is not a permutation", k, round.Permutation)
+		}
+	}
+
+	c := shuffleChallenge(tr, e, E, y, g, rounds)
+
+	for k, round := range rounds {
+		from, to := e, round.Mixed
+		if c.Bit(k) == 1 {
+			from, to = round.Mixed, E
+		}
+
+		calculated := reencrypt(from, round.Permutation, round.Randomness, y, g, p, q)
+		for j := range calculated {
+			if calculated[j].Alpha.Cmp(&to[j].Alpha) != 0 || calculated[j].Beta.Cmp(&to[j].Beta) != 0 {
+				return fmt.Errorf("%v - WRONG! Calculated (%v, %v), received (%v, %v).", k,
+					&calculated[j].Alpha, &calculated[j].Beta, &to[j].Alpha, &to[j].Beta)
+			}
+		}
+	}
+
+	return nil
+}
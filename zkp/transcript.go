@@ -0,0 +1,112 @@
+package zkp
+
+import (
+	"crypto/sha512"
+	"encoding/binary"
+	"math/big"
+)
+
+// Transcript binds a Fiat–Shamir challenge to everything it must depend on:
+// the protocol, the auction, the round, the prover and the statement being
+// proved. Every input is labelled and length-prefixed, so that no two
+// different sequences of inputs hash alike.
+//
+// Prover and verifier must build the same transcript. The proof functions
+// never modify the transcript they are given; they work on a copy.
+type Transcript struct {
+	data []byte
+}
+
+// NewTranscript starts a transcript for the protocol with the given label.
+func NewTranscript(label string) *Transcript {
+	t := new(Transcript)
+	t.AppendString("protocol", label)
+	return t
+}
+
+// NewRoundTranscript returns the transcript of the proofs sent by client in
+// the given step of an auction.
+func NewRoundTranscript(protocol string, auctionID string, group Group, step int, client int) *Transcript {
+	t := NewTranscript(protocol)
+	t.AppendString("auction", auctionID)
+	t.AppendGroup(group)
+	t.AppendInt("step", int64(step))
+	t.AppendInt("client", int64(client))
+	return t
+}
+
+// Clone returns an independent copy of the transcript.
+func (t *Transcript) Clone() *Transcript {
+	return &Transcript{data: append([]byte(nil), t.data...)}
+}
+
+// Fork returns a copy of the transcript for one of several proofs made in
+// the same round, e.g. the proof for (i, j).
+func (t *Transcript) Fork(label string, indices ...int) *Transcript {
+	f := t.Clone()
+	f.AppendString("fork", label)
+	for _, i := range indices {
+		f.AppendInt("index", int64(i))
+	}
+	return f
+}
+
+// AppendBytes appends labelled data to the transcript.
+func (t *Transcript) AppendBytes(label string, b []byte) {
+	var n [8]byte
+
+	binary.BigEndian.PutUint64(n[:], uint64(len(label)))
+	t.data = append(t.data, n[:]...)
+	t.data = append(t.data, label...)
+
+	binary.BigEndian.PutUint64(n[:], uint64(len(b)))
+	t.data = append(t.data, n[:]...)
+	t.data = append(t.data, b...)
+}
+
+// AppendString appends a labelled string to the transcript.
+func (t *Transcript) AppendString(label string, s string) {
+	t.AppendBytes(label, []byte(s))
+}
+
+// AppendInt appends a labelled integer to the transcript.
+func (t *Transcript) AppendInt(label string, n int64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(n))
+	t.AppendBytes(label, b[:])
+}
+
+// AppendBigInt appends a labelled non-negative big.Int to the transcript.
+func (t *Transcript) AppendBigInt(label string, n *big.Int) {
+	t.AppendBytes(label, n.Bytes())
+}
+
+// AppendGroup appends the parameters of group to the transcript.
+func (t *Transcript) AppendGroup(group Group) {
+	t.AppendString("group", group.Name())
+	t.AppendBigInt("order", group.Order())
+	t.AppendElements(group, "generators", group.Generator(), group.SecondGenerator())
+	if params, ok := group.(*GroupParams); ok && params.P != nil {
+		t.AppendBigInt("modulus", params.P)
+	}
+}
+
+// AppendElements appends labelled group elements to the transcript.
+func (t *Transcript) AppendElements(group Group, label string, elems ...Element) {
+	t.AppendInt(label, int64(len(elems)))
+	for _, e := range elems {
+		t.AppendBytes(label, group.Encode(e))
+	}
+}
+
+// Challenge returns the challenge of the transcript, a number in [0, q).
+// SHA-512 is used so that reducing the hash mod a 256 bit q is almost
+// unbiased.
+func (t *Transcript) Challenge(label string, q *big.Int) *big.Int {
+	f := t.Clone()
+	f.AppendString("challenge", label)
+
+	sum := sha512.Sum512(f.data)
+	c := new(big.Int).SetBytes(sum[:])
+	return c.Mod(c, q)
+}
@@ -0,0 +1,50 @@
+package zkp
+
+import (
+	"testing"
+)
+
+func testTranscript() *Transcript {
+	return NewTranscript("zkp test")
+}
+
+func TestTranscriptIsUnambiguous(test *testing.T) {
+	a, b := NewTranscript("test"), NewTranscript("test")
+	a.AppendString("x", "ab")
+	a.AppendString("x", "c")
+	b.AppendString("x", "a")
+	b.AppendString("x", "bc")
+
+	if a.Challenge("c", Q).Cmp(b.Challenge("c", Q)) == 0 {
+		test.Errorf("Different inputs gave the same challenge")
+	}
+
+	if a.Challenge("c", Q).Cmp(a.Challenge("c", Q)) != 0 {
+		test.Errorf("Challenge is not deterministic")
+	}
+}
+
+func TestProofsAreBoundToTranscript(test *testing.T) {
+	for _, group := range testGroups(test) {
+		g := group.Generator()
+		x := randomScalar(group)
+		y := group.Exp(g, x)
+
+		round := func(step, client int) *Transcript {
+			return NewRoundTranscript("test", "auction", group, step, client)
+		}
+
+		t, r := GroupDiscreteLogKnowledge(round(1, 0), group, x, g)
+		if err := CheckGroupDiscreteLogKnowledgeProof(round(1, 0), group, g, y, t, r); err != nil {
+			test.Errorf("%v: %v", group.Name(), err)
+		}
+
+		// Replaying the proof in another step or as another client fails
+		for _, tr := range []*Transcript{round(2, 0), round(1, 1),
+			NewRoundTranscript("test", "other auction", group, 1, 0), round(1, 0).Fork("x", 0)} {
+			if err := CheckGroupDiscreteLogKnowledgeProof(tr, group, g, y, t, r); err == nil {
+				test.Errorf("%v: accepted a replayed proof", group.Name())
+			}
+		}
+	}
+}
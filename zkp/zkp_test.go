@@ -15,14 +15,14 @@ func TestDiscreteLogKnowledge(test *testing.T) {
 		g := GenerateG(P, Q)
 
 		// Generate private key, public key pair
-		x.Rand(RandGen, new(big.Int).Sub(Q, One))
+		x.Set(RandomInt(new(big.Int).Sub(Q, One)))
 		x.Add(&x, One)   // x is in [1, ..., Q]
 		y.Exp(&g, &x, P) // y = g^x mod P
 
 		// Generate zero-knowledge proof
-		t, r := DiscreteLogKnowledge(x, g, *P, *Q)
+		t, r := DiscreteLogKnowledge(testTranscript(), x, g, *P, *Q)
 
-		err := CheckDiscreteLogKnowledgeProof(g, y, t, r, *P, *Q)
+		err := CheckDiscreteLogKnowledgeProof(testTranscript(), g, y, t, r, *P, *Q)
 
 		if err != nil {
 			test.Error(err)
@@ -35,7 +35,7 @@ func TestDiscreteLogEquality(test *testing.T) {
 
 		// Generate private key
 		var x big.Int
-		x.Rand(RandGen, new(big.Int).Sub(Q, One))
+		x.Set(RandomInt(new(big.Int).Sub(Q, One)))
 		x.Add(&x, One) // x is in [1, ..., Q]
 
 		G := GenerateGs(P, Q, 10)
@@ -47,9 +47,9 @@ func TestDiscreteLogEquality(test *testing.T) {
 		}
 
 		// Generate zero-knowledge proof
-		t, r := DiscreteLogEquality(x, G, *P, *Q)
+		t, r := DiscreteLogEquality(testTranscript(), x, G, *P, *Q)
 
-		err := CheckDiscreteLogEqualityProof(G, Y, t, r, *P, *Q)
+		err := CheckDiscreteLogEqualityProof(testTranscript(), G, Y, t, r, *P, *Q)
 
 		if err != nil {
 			test.Error(err)
@@ -65,10 +65,10 @@ func TestEncryptedValueIsOneOfTwo(test *testing.T) {
 
 		// Generate public/private key pairs
 		g := GenerateG(P, Q)
-		x.Rand(RandGen, new(big.Int).Sub(Q, One))
+		x.Set(RandomInt(new(big.Int).Sub(Q, One)))
 		x.Add(&x, One)     // x is in [1, ..., Q]
 		y.Exp(&g, &x, P)   // y = g^x mod P
-		r.Rand(RandGen, Q) // r = rand() mod Q
+		r.Set(RandomInt(Q)) // r = rand() mod Q
 
 		// Test case 1: m = 1 and z = 42 returns TRUE
 		m.Set(One)
@@ -80,8 +80,8 @@ func TestEncryptedValueIsOneOfTwo(test *testing.T) {
 		beta.Exp(&g, &r, P)  // beta = g^r mod P
 
 		// Generate and verify ZKP
-		a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2 := EncryptedValueIsOneOfTwo(m, y, r, g, z, *P, *Q)
-		err := CheckEncryptedValueIsOneOfTwo(alpha, beta, *P, *Q, a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2, g, y, z)
+		a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2 := EncryptedValueIsOneOfTwo(testTranscript(), m, y, r, g, z, *P, *Q)
+		err := CheckEncryptedValueIsOneOfTwo(testTranscript(), alpha, beta, *P, *Q, a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2, g, y, z)
 		if err != nil {
 			test.Error(err)
 		}
@@ -96,8 +96,8 @@ func TestEncryptedValueIsOneOfTwo(test *testing.T) {
 		beta.Exp(&g, &r, P)  // beta = g^r mod P
 
 		// Generate and verify ZKP
-		a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2 = EncryptedValueIsOneOfTwo(m, y, r, g, z, *P, *Q)
-		err = CheckEncryptedValueIsOneOfTwo(alpha, beta, *P, *Q, a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2, g, y, z)
+		a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2 = EncryptedValueIsOneOfTwo(testTranscript(), m, y, r, g, z, *P, *Q)
+		err = CheckEncryptedValueIsOneOfTwo(testTranscript(), alpha, beta, *P, *Q, a_1, a_2, b_1, b_2, d_1, d_2, r_1, r_2, g, y, z)
 		if err != nil {
 			test.Error(err)
 		}
@@ -113,15 +113,15 @@ func TestVerifiableSecretShuffle(test *testing.T) {
 		g := GenerateG(P, Q)
 
 		// Generate private key, public key pair
-		x.Rand(RandGen, new(big.Int).Sub(Q, One))
+		x.Set(RandomInt(new(big.Int).Sub(Q, One)))
 		x.Add(&x, One)   // x is in [1, ..., Q]
 		y.Exp(&g, &x, P) // y = g^x mod P
 
 		n := 100
 
 		for j := 0; j < n; j++ {
-			m.Rand(RandGen, P) // Some message
-			r.Rand(RandGen, Q)
+			m.Set(RandomInt(P)) // Some message
+			r.Set(RandomInt(Q))
 			c := EncryptElGamal(&m, &r, &y, P, Q, &g)
 			e = append(e, c)
 		}
@@ -129,53 +129,27 @@ func TestVerifiableSecretShuffle(test *testing.T) {
 		pi := makeRandPerm(n)
 
 		for j := 0; j < n; j++ {
-			r.Rand(RandGen, Q)
+			r.Set(RandomInt(Q))
 			cOne := EncryptElGamal(One, &r, &y, P, Q, &g)
 			c := MultiplyElGamal(e[pi.Forward[j]], cOne, P)
 			E = append(E, c)
-			R = append(R, r)
+			R = append(R, *new(big.Int).Set(&r)) // r is reused, so copy it
 		}
 
-		c, cd, cD, ER, f, fd, yd, zd, F, yD, zD, Z := VerifiableSecretShuffle(e, E, y, g, *P, *Q, pi, R)
-		err := CheckVerifiableSecretShuffle(e, E, *P, *Q, g, y, c, cd, cD, ER, f, fd, yd, zd, F, yD, zD, Z)
+		proof := VerifiableSecretShuffle(testTranscript(), e, E, y, g, *P, *Q, pi, R)
+		err := CheckVerifiableSecretShuffle(testTranscript(), e, E, *P, *Q, g, y, proof)
 
 		if err != nil {
 			test.Error(err)
 		}
-	}
 
-	// G := GenerateGsCommitment(10)
-	//
-	// for i := 0; i < 10; i++ {
-	//   fmt.Println(G[i].String())
-	// }
-
-	// var RegularCiphertexts = []Ciphertext{
-	//   Ciphertext{
-	//     alpha: *One,
-	//     beta:  *FortyTwo,
-	//   },
-	//   Ciphertext{
-	//     alpha: *One,
-	//     beta:  *One,
-	//   },
-	// }
-	// var ShuffledCiphertexts = []Ciphertext{
-	//   Ciphertext{
-	//     alpha: *One,
-	//     beta:  *One,
-	//   },
-	//   Ciphertext{
-	//     alpha: *One,
-	//     beta:  *FortyTwo,
-	//   },
-	// }
-
-	// g := GenerateG(P, Q)
-	// pi := makeRandPerm(len(e))
-
-	// c, cd, cD, ER, f, fd, yd, zd, F, yD, zD, Z := VerifiableSecretShuffle(RegularCiphertexts, ShuffledCiphertexts, *One, g, *P, *Q, pi, R []big.Int)
-
-	// err := CheckVerifiableSecretShuffle(e, E, p, q, g, y, c, cd, cD, ER, f, fd, yd, zd, F, yD, zD, Z)
+		// A ciphertext that is not one of e must not pass for a shuffle of it
+		E[0].Alpha.Mul(&E[0].Alpha, &g)
+		E[0].Alpha.Mod(&E[0].Alpha, P)
+		err = CheckVerifiableSecretShuffle(testTranscript(), e, E, *P, *Q, g, y, proof)
 
+		if err == nil {
+			test.Error("Accepted the proof of a shuffle for a tampered output")
+		}
+	}
 }